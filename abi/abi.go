@@ -1,6 +1,7 @@
 package abi
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"reflect"
@@ -16,7 +17,9 @@ import (
 // ErrInvalidType is returned when processing a zero valued 'Type' (aka Invalid)
 var ErrInvalidType = fmt.Errorf("invalid type")
 
-// Type represents a type that can be passed through the filecoin ABI
+// Type represents a type that can be passed through the filecoin ABI. Besides
+// the basic types below there are composite types, see ArrayOf, MapOf and
+// RegisterStruct.
 type Type uint64
 
 const (
@@ -38,7 +41,8 @@ const (
 	Bytes
 	// String is a string
 	String
-	// UintArray is an array of uint64
+	// UintArray is an array of uint64, serialized as a cbor array of integers
+	// unlike ArrayOf(SectorID).
 	UintArray
 	// PeerID is a libp2p peer ID
 	PeerID
@@ -77,7 +81,7 @@ func (t Type) String() string {
 	case CommitmentsMap:
 		return "map[string]Commitments"
 	default:
-		return "<unknown type>"
+		return compositeString(t)
 	}
 }
 
@@ -116,7 +120,14 @@ func (av *Value) String() string {
 	case CommitmentsMap:
 		return fmt.Sprint(av.Val.(map[string]types.Commitments))
 	default:
-		return "<unknown type>"
+		if av.Type.kind() == 0 {
+			return "<unknown type>"
+		}
+		out, err := json.Marshal(av.Val)
+		if err != nil {
+			return fmt.Sprint(av.Val)
+		}
+		return string(out)
 	}
 }
 
@@ -215,7 +226,10 @@ func (av *Value) Serialize() ([]byte, error) {
 
 		return cbor.DumpObject(m)
 	default:
-		return nil, fmt.Errorf("unrecognized Type: %d", av.Type)
+		if av.Type.kind() == 0 {
+			return nil, fmt.Errorf("unrecognized Type: %d", av.Type)
+		}
+		return serializeComposite(av.Type, av.Val)
 	}
 }
 
@@ -254,7 +268,11 @@ func ToValues(i []interface{}) ([]*Value, error) {
 		case map[string]types.Commitments:
 			out = append(out, &Value{Type: CommitmentsMap, Val: v})
 		default:
-			return nil, fmt.Errorf("unsupported type: %T", v)
+			t, ok := typeOf(reflect.TypeOf(v))
+			if !ok {
+				return nil, fmt.Errorf("unsupported type: %T", v)
+			}
+			out = append(out, &Value{Type: t, Val: v})
 		}
 	}
 	return out, nil
@@ -360,7 +378,10 @@ func Deserialize(data []byte, t Type) (*Value, error) {
 	case Invalid:
 		return nil, ErrInvalidType
 	default:
-		return nil, fmt.Errorf("unrecognized Type: %d", t)
+		if t.kind() == 0 {
+			return nil, fmt.Errorf("unrecognized Type: %d", t)
+		}
+		return deserializeComposite(data, t)
 	}
 }
 
@@ -381,7 +402,7 @@ var typeTable = map[Type]reflect.Type{
 
 // TypeMatches returns whether or not 'val' is the go type expected for the given ABI type
 func TypeMatches(t Type, val reflect.Type) bool {
-	rt, ok := typeTable[t]
+	rt, ok := goType(t)
 	if !ok {
 		return false
 	}
@@ -0,0 +1,313 @@
+package abi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	cbor "gx/ipfs/QmcZLyosDwMKdB6NLRsiss9HXzDPhVhhRtPy67JFKTDQDX/go-ipld-cbor"
+)
+
+// Composite types are built from other types with ArrayOf and MapOf, or
+// registered with RegisterStruct. Their kind is kept in the low byte of their
+// Type, above the basic types, and the type they are built from, or the index
+// of a registered struct, in the bytes above it. This keeps Type comparable, so
+// ArrayOf(Address) == ArrayOf(Address).
+const (
+	arrayKind  = Type(0x80)
+	mapKind    = Type(0x81)
+	structKind = Type(0x82)
+
+	kindMask = Type(0xff)
+)
+
+// maxElem is the largest Type that composite types can be built from.
+const maxElem = Type(1)<<56 - 1
+
+// ArrayOf returns the type of arrays of elem. Its go type is a slice of the go
+// type of elem, e.g. ArrayOf(Address) is a []address.Address. ToValues infers
+// UintArray rather than ArrayOf(SectorID) for a []uint64.
+func ArrayOf(elem Type) Type {
+	if elem > maxElem {
+		panic("abi: array type nested too deeply")
+	}
+	return elem<<8 | arrayKind
+}
+
+// MapOf returns the type of maps from strings to elem. Its go type is a map
+// from string to the go type of elem.
+func MapOf(elem Type) Type {
+	if elem > maxElem {
+		panic("abi: map type nested too deeply")
+	}
+	return elem<<8 | mapKind
+}
+
+type structType struct {
+	name string
+	rt   reflect.Type
+}
+
+// structs are the struct types registered with RegisterStruct, by index.
+var structs []structType
+
+// RegisterStruct registers the go type of v, which must be registered with
+// cbor.RegisterCborType, as an ABI type called name and returns it. Values of
+// the type are serialized as cbor. v is usually a nil pointer to the struct,
+// e.g. RegisterStruct("miner.Ask", (*Ask)(nil)).
+//
+// Structs should be registered in package variables, so that they are
+// registered before any actor exports use them.
+func RegisterStruct(name string, v interface{}) Type {
+	rt := reflect.TypeOf(v)
+	for _, s := range structs {
+		if s.name == name || s.rt == rt {
+			panic(fmt.Sprintf("abi: struct %s (%s) is already registered", name, rt))
+		}
+	}
+
+	structs = append(structs, structType{name: name, rt: rt})
+	return Type(len(structs)-1)<<8 | structKind
+}
+
+func (t Type) kind() Type {
+	if t < arrayKind {
+		return 0
+	}
+	return t & kindMask
+}
+
+// Elem returns the type of the elements of an array or map type.
+func (t Type) Elem() Type {
+	switch t.kind() {
+	case arrayKind, mapKind:
+		return t >> 8
+	default:
+		panic(fmt.Sprintf("abi: Elem of non-composite type %s", t))
+	}
+}
+
+func (t Type) structType() (structType, bool) {
+	if t.kind() != structKind || int(t>>8) >= len(structs) {
+		return structType{}, false
+	}
+	return structs[t>>8], true
+}
+
+// goType returns the go type of values of type t.
+func goType(t Type) (reflect.Type, bool) {
+	switch t.kind() {
+	case arrayKind:
+		et, ok := goType(t.Elem())
+		if !ok {
+			return nil, false
+		}
+		return reflect.SliceOf(et), true
+	case mapKind:
+		et, ok := goType(t.Elem())
+		if !ok {
+			return nil, false
+		}
+		return reflect.MapOf(reflect.TypeOf(""), et), true
+	case structKind:
+		s, ok := t.structType()
+		return s.rt, ok
+	default:
+		rt, ok := typeTable[t]
+		return rt, ok
+	}
+}
+
+// typeOf returns the type of go values of type rt.
+func typeOf(rt reflect.Type) (Type, bool) {
+	for t, bt := range typeTable {
+		if bt == rt {
+			return t, true
+		}
+	}
+	for i, s := range structs {
+		if s.rt == rt {
+			return Type(i)<<8 | structKind, true
+		}
+	}
+
+	switch rt.Kind() {
+	case reflect.Slice:
+		if et, ok := typeOf(rt.Elem()); ok && et <= maxElem {
+			return ArrayOf(et), true
+		}
+	case reflect.Map:
+		if rt.Key().Kind() != reflect.String {
+			return Invalid, false
+		}
+		if et, ok := typeOf(rt.Elem()); ok && et <= maxElem {
+			return MapOf(et), true
+		}
+	}
+	return Invalid, false
+}
+
+func compositeString(t Type) string {
+	switch t.kind() {
+	case arrayKind:
+		return "[]" + t.Elem().String()
+	case mapKind:
+		return "map[string]" + t.Elem().String()
+	case structKind:
+		if s, ok := t.structType(); ok {
+			return s.name
+		}
+	}
+	return "<unknown type>"
+}
+
+// valueOf checks that val is a go value of the composite type t.
+func valueOf(t Type, val interface{}) (reflect.Value, error) {
+	rt, ok := goType(t)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("unrecognized Type: %d", t)
+	}
+	rv := reflect.ValueOf(val)
+	if !rv.IsValid() || rv.Type() != rt {
+		return reflect.Value{}, &typeError{reflect.Zero(rt).Interface(), val}
+	}
+	return rv, nil
+}
+
+// serializeComposite serializes arrays and maps as cbor arrays and maps of
+// the serializations of their elements, and structs as cbor.
+func serializeComposite(t Type, val interface{}) ([]byte, error) {
+	rv, err := valueOf(t, val)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t.kind() {
+	case arrayKind:
+		arr := make([][]byte, rv.Len())
+		for i := range arr {
+			v := &Value{Type: t.Elem(), Val: rv.Index(i).Interface()}
+			if arr[i], err = v.Serialize(); err != nil {
+				return nil, err
+			}
+		}
+		return cbor.DumpObject(arr)
+	case mapKind:
+		m := make(map[string][]byte, rv.Len())
+		for _, k := range rv.MapKeys() {
+			v := &Value{Type: t.Elem(), Val: rv.MapIndex(k).Interface()}
+			if m[k.String()], err = v.Serialize(); err != nil {
+				return nil, err
+			}
+		}
+		return cbor.DumpObject(m)
+	default:
+		return cbor.DumpObject(val)
+	}
+}
+
+func deserializeComposite(data []byte, t Type) (*Value, error) {
+	rt, ok := goType(t)
+	if !ok {
+		return nil, fmt.Errorf("unrecognized Type: %d", t)
+	}
+
+	switch t.kind() {
+	case arrayKind:
+		var arr [][]byte
+		if err := cbor.DecodeInto(data, &arr); err != nil {
+			return nil, err
+		}
+		rv := reflect.MakeSlice(rt, len(arr), len(arr))
+		for i, d := range arr {
+			v, err := Deserialize(d, t.Elem())
+			if err != nil {
+				return nil, err
+			}
+			rv.Index(i).Set(reflect.ValueOf(v.Val))
+		}
+		return &Value{Type: t, Val: rv.Interface()}, nil
+	case mapKind:
+		var m map[string][]byte
+		if err := cbor.DecodeInto(data, &m); err != nil {
+			return nil, err
+		}
+		rv := reflect.MakeMapWithSize(rt, len(m))
+		for k, d := range m {
+			v, err := Deserialize(d, t.Elem())
+			if err != nil {
+				return nil, err
+			}
+			rv.SetMapIndex(reflect.ValueOf(k), reflect.ValueOf(v.Val))
+		}
+		return &Value{Type: t, Val: rv.Interface()}, nil
+	default:
+		ptr := reflect.New(rt)
+		if rt.Kind() == reflect.Ptr {
+			ptr.Elem().Set(reflect.New(rt.Elem()))
+			if err := cbor.DecodeInto(data, ptr.Elem().Interface()); err != nil {
+				return nil, err
+			}
+		} else if err := cbor.DecodeInto(data, ptr.Interface()); err != nil {
+			return nil, err
+		}
+		return &Value{Type: t, Val: ptr.Elem().Interface()}, nil
+	}
+}
+
+func compositeSchema(t Type) *Schema {
+	switch t.kind() {
+	case arrayKind:
+		return &Schema{Title: t.String(), Type: "array", Items: t.Elem().JSONSchema()}
+	case mapKind:
+		return &Schema{Title: t.String(), Type: "object", AdditionalProperties: t.Elem().JSONSchema()}
+	default:
+		return &Schema{Title: t.String(), Type: "object"}
+	}
+}
+
+// decodeJSONComposite decodes arrays and maps element by element, so that
+// their elements are validated as DecodeJSONValue validates them.
+func decodeJSONComposite(t Type, data []byte) (interface{}, error) {
+	rt, ok := goType(t)
+	if !ok {
+		return nil, fmt.Errorf("unrecognized Type: %d", t)
+	}
+
+	switch t.kind() {
+	case arrayKind:
+		var raw []json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		rv := reflect.MakeSlice(rt, len(raw), len(raw))
+		for i, r := range raw {
+			v, err := DecodeJSONValue(t.Elem(), r)
+			if err != nil {
+				return nil, fmt.Errorf("invalid item %d: %s", i, err)
+			}
+			rv.Index(i).Set(reflect.ValueOf(v))
+		}
+		return rv.Interface(), nil
+	case mapKind:
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		rv := reflect.MakeMapWithSize(rt, len(raw))
+		for k, r := range raw {
+			v, err := DecodeJSONValue(t.Elem(), r)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of %q: %s", k, err)
+			}
+			rv.SetMapIndex(reflect.ValueOf(k), reflect.ValueOf(v))
+		}
+		return rv.Interface(), nil
+	default:
+		v := reflect.New(rt)
+		if err := json.Unmarshal(data, v.Interface()); err != nil {
+			return nil, err
+		}
+		return v.Elem().Interface(), nil
+	}
+}
@@ -0,0 +1,103 @@
+package abi
+
+import (
+	"reflect"
+	"testing"
+
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
+	cbor "gx/ipfs/QmcZLyosDwMKdB6NLRsiss9HXzDPhVhhRtPy67JFKTDQDX/go-ipld-cbor"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+type testStruct struct {
+	Owner address.Address `json:"owner"`
+	Name  string          `json:"name"`
+}
+
+var testStructType = func() Type {
+	cbor.RegisterCborType(testStruct{})
+	return RegisterStruct("abi.testStruct", (*testStruct)(nil))
+}()
+
+func TestCompositeEncodingRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	addrGetter := address.NewForTestGetter()
+
+	cases := map[string]interface{}{
+		"addresses":       []address.Address{addrGetter(), addrGetter()},
+		"no addresses":    []address.Address{},
+		"map of attofil":  map[string]*types.AttoFIL{"a": types.NewAttoFILFromFIL(1), "b": types.NewAttoFILFromFIL(2)},
+		"struct":          &testStruct{Owner: addrGetter(), Name: "beep"},
+		"map of structs":  map[string]*testStruct{"x": {Owner: addrGetter(), Name: "boop"}},
+		"nested arrays":   [][]address.Address{{addrGetter()}, {addrGetter(), addrGetter()}},
+		"array of uint64": [][]uint64{{1, 2}, {3}},
+	}
+
+	for tname, tcase := range cases {
+		tcase := tcase
+		t.Run(tname, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			vals, err := ToValues([]interface{}{tcase})
+			require.NoError(err)
+			require.True(TypeMatches(vals[0].Type, reflect.TypeOf(tcase)))
+
+			data, err := EncodeValues(vals)
+			require.NoError(err)
+
+			out, err := DecodeValues(data, []Type{vals[0].Type})
+			require.NoError(err)
+			assert.Equal(tcase, out[0].Val)
+		})
+	}
+}
+
+func TestCompositeTypes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("are comparable", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.Equal(ArrayOf(Address), ArrayOf(Address))
+		assert.NotEqual(ArrayOf(Address), MapOf(Address))
+		assert.NotEqual(ArrayOf(ArrayOf(Address)), ArrayOf(Address))
+		assert.Equal(Address, ArrayOf(Address).Elem())
+		assert.Equal(ArrayOf(Address), MapOf(ArrayOf(Address)).Elem())
+	})
+
+	t.Run("are named after their go types", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.Equal("[]address.Address", ArrayOf(Address).String())
+		assert.Equal("map[string]*types.AttoFIL", MapOf(AttoFIL).String())
+		assert.Equal("map[string]abi.testStruct", MapOf(testStructType).String())
+	})
+
+	t.Run("serialize only values of their go type", func(t *testing.T) {
+		_, err := (&Value{Type: ArrayOf(Address), Val: []string{"foo"}}).Serialize()
+		assert.Error(t, err)
+	})
+
+	t.Run("decode from JSON", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		addr := address.NewForTestGetter()()
+
+		v, err := DecodeJSONValue(MapOf(ArrayOf(Address)), []byte(`{"a": ["`+addr.String()+`"]}`))
+		require.NoError(err)
+		assert.Equal(map[string][]address.Address{"a": {addr}}, v)
+
+		v, err = DecodeJSONValue(testStructType, []byte(`{"owner": "`+addr.String()+`", "name": "beep"}`))
+		require.NoError(err)
+		assert.Equal(&testStruct{Owner: addr, Name: "beep"}, v)
+
+		_, err = DecodeJSONValue(ArrayOf(SectorID), []byte(`[1, -1]`))
+		assert.Error(err)
+	})
+}
@@ -0,0 +1,183 @@
+package abi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"gx/ipfs/QmTu65MVbemtUxJEWgsTtzv9Zv9P8rvmqNA4eG9TrTRGYc/go-libp2p-peer"
+)
+
+// Schema is a JSON schema (draft 7) describing the JSON encoding of ABI values,
+// for clients that are not written in Go.
+type Schema struct {
+	// Title is the name of the ABI type described.
+	Title           string `json:"title,omitempty"`
+	Description     string `json:"description,omitempty"`
+	Type            string `json:"type,omitempty"`
+	Format          string `json:"format,omitempty"`
+	Pattern         string `json:"pattern,omitempty"`
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+	Minimum         *int64 `json:"minimum,omitempty"`
+	// Items is the schema of the items of an array, or a list of the schemas
+	// of each item of a tuple.
+	Items                interface{} `json:"items,omitempty"`
+	MinItems             *int        `json:"minItems,omitempty"`
+	MaxItems             *int        `json:"maxItems,omitempty"`
+	AdditionalItems      *bool       `json:"additionalItems,omitempty"`
+	AdditionalProperties *Schema     `json:"additionalProperties,omitempty"`
+}
+
+var zero = int64(0)
+
+var schemaTable = map[Type]Schema{
+	Address: {
+		Type:        "string",
+		Format:      "filecoin-address",
+		Description: "a filecoin address",
+	},
+	AttoFIL: {
+		Type:        "string",
+		Pattern:     `^[0-9]+(\.[0-9]+)?$`,
+		Description: "an amount of FIL in decimal",
+	},
+	BytesAmount: {
+		Type:        "string",
+		Pattern:     `^[0-9]+$`,
+		Description: "a number of bytes in decimal",
+	},
+	ChannelID: {
+		Type:        "integer",
+		Minimum:     &zero,
+		Description: "the id of a payment channel",
+	},
+	BlockHeight: {
+		Type:        "integer",
+		Minimum:     &zero,
+		Description: "a block height",
+	},
+	Integer: {
+		Type: "integer",
+	},
+	Bytes: {
+		Type:            "string",
+		ContentEncoding: "base64",
+	},
+	String: {
+		Type: "string",
+	},
+	UintArray: {
+		Type:  "array",
+		Items: &Schema{Type: "integer", Minimum: &zero},
+	},
+	PeerID: {
+		Type:        "string",
+		Format:      "libp2p-peer-id",
+		Description: "a base58 encoded libp2p peer id",
+	},
+	SectorID: {
+		Type:        "integer",
+		Minimum:     &zero,
+		Description: "the id of a sector",
+	},
+	CommitmentsMap: {
+		Type:                 "object",
+		Description:          "the commitments of sectors by sector id",
+		AdditionalProperties: &Schema{Type: "object"},
+	},
+}
+
+// JSONSchema returns the schema of the JSON encoding of values of type t.
+func (t Type) JSONSchema() *Schema {
+	if t.kind() != 0 {
+		return compositeSchema(t)
+	}
+	s, ok := schemaTable[t]
+	if !ok {
+		return &Schema{Title: t.String()}
+	}
+	s.Title = t.String()
+	return &s
+}
+
+// TupleSchema returns the schema of a JSON array holding a value of each of
+// the types ts in order, such as the parameters of a method.
+func TupleSchema(ts []Type) *Schema {
+	items := make([]*Schema, len(ts))
+	for i, t := range ts {
+		items[i] = t.JSONSchema()
+	}
+	n := len(ts)
+	no := false
+	return &Schema{
+		Type:            "array",
+		Items:           items,
+		MinItems:        &n,
+		MaxItems:        &n,
+		AdditionalItems: &no,
+	}
+}
+
+// DecodeJSONValue decodes the JSON encoding of a value of type t, as
+// described by t.JSONSchema, into its go representation.
+func DecodeJSONValue(t Type, data []byte) (interface{}, error) {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil, fmt.Errorf("expected %s, got null", t)
+	}
+
+	if t.kind() != 0 {
+		return decodeJSONComposite(t, data)
+	}
+
+	if t == PeerID {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		return peer.IDB58Decode(s)
+	}
+
+	if s, ok := schemaTable[t]; ok && s.Type == "integer" && s.Minimum != nil {
+		var n big.Int
+		if err := json.Unmarshal(data, &n); err != nil {
+			return nil, err
+		}
+		if n.Sign() < 0 {
+			return nil, fmt.Errorf("expected %s, got negative %s", t, n.String())
+		}
+	}
+
+	rt, ok := typeTable[t]
+	if !ok {
+		return nil, fmt.Errorf("unrecognized Type: %d", t)
+	}
+	v := reflect.New(rt)
+	if err := json.Unmarshal(data, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
+// DecodeJSONValues decodes a JSON array holding a value of each of the types
+// ts, as described by TupleSchema(ts), into their go representations.
+func DecodeJSONValues(ts []Type, data []byte) ([]interface{}, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if len(raw) != len(ts) {
+		return nil, fmt.Errorf("expected %d values, got %d", len(ts), len(raw))
+	}
+
+	out := make([]interface{}, len(raw))
+	for i, r := range raw {
+		v, err := DecodeJSONValue(ts[i], r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %d: %s", i, err)
+		}
+		out[i] = v
+	}
+	return out, nil
+}
@@ -0,0 +1,79 @@
+package abi
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestDecodeJSONValues(t *testing.T) {
+	t.Parallel()
+
+	t.Run("decodes each value by its type", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		addr := address.NewForTestGetter()()
+		data := []byte(`["` + addr.String() + `", "1.5", 7, "beep"]`)
+
+		vals, err := DecodeJSONValues([]Type{Address, AttoFIL, Integer, String}, data)
+		require.NoError(err)
+		require.Len(vals, 4)
+
+		assert.Equal(addr, vals[0])
+		expected, _ := types.NewAttoFILFromFILString("1.5")
+		assert.Equal(expected, vals[1])
+		assert.Equal(big.NewInt(7), vals[2])
+		assert.Equal("beep", vals[3])
+
+		// The decoded values encode as the types they were decoded as.
+		abiVals, err := ToValues(vals)
+		require.NoError(err)
+		assert.Equal(AttoFIL, abiVals[1].Type)
+	})
+
+	t.Run("rejects the wrong number of values", func(t *testing.T) {
+		_, err := DecodeJSONValues([]Type{Integer, Integer}, []byte(`[1]`))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects values of the wrong type", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := DecodeJSONValues([]Type{Integer}, []byte(`["one"]`))
+		assert.Error(err)
+
+		_, err = DecodeJSONValues([]Type{Address}, []byte(`[null]`))
+		assert.Error(err)
+
+		_, err = DecodeJSONValues([]Type{SectorID}, []byte(`[-1]`))
+		assert.Error(err)
+	})
+}
+
+func TestTupleSchema(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+	require := require.New(t)
+
+	data, err := json.Marshal(TupleSchema([]Type{Address, SectorID}))
+	require.NoError(err)
+
+	var s map[string]interface{}
+	require.NoError(json.Unmarshal(data, &s))
+	assert.Equal("array", s["type"])
+	assert.Equal(float64(2), s["minItems"])
+	assert.Equal(float64(2), s["maxItems"])
+
+	items := s["items"].([]interface{})
+	require.Len(items, 2)
+	assert.Equal("address.Address", items[0].(map[string]interface{})["title"])
+	assert.Equal("integer", items[1].(map[string]interface{})["type"])
+}
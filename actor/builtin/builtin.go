@@ -3,6 +3,8 @@ package builtin
 
 import (
 	cid "gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	ipld "gx/ipfs/QmRL22E4paat7ky7vx9MLpR97JHHbFPrg3ytFQw6qp1y1s/go-ipld-format"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 
 	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
@@ -12,15 +14,52 @@ import (
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
-// Actors is list of all actors that ship with Filecoin.
-// They are indexed by their CID.
+// Actors is list of all actors that ship with Filecoin, along with those
+// registered with Register. They are indexed by their CID.
 var Actors = map[cid.Cid]exec.ExecutableActor{}
 
+// codeObjs are the code objects of the actors in Actors, in the order they
+// were registered.
+var codeObjs []ipld.Node
+
 func init() {
 	// Instance Actors
-	Actors[types.AccountActorCodeCid] = &account.Actor{}
-	Actors[types.StorageMarketActorCodeCid] = &storagemarket.Actor{}
-	Actors[types.PaymentBrokerActorCodeCid] = &paymentbroker.Actor{}
-	Actors[types.MinerActorCodeCid] = &miner.Actor{}
-	Actors[types.BootstrapMinerActorCodeCid] = &miner.Actor{Bootstrap: true}
+	MustRegister(types.AccountActorCodeObj, "AccountActor", &account.Actor{})
+	MustRegister(types.StorageMarketActorCodeObj, "StorageMarketActor", &storagemarket.Actor{})
+	MustRegister(types.PaymentBrokerActorCodeObj, "PaymentBrokerActor", &paymentbroker.Actor{})
+	MustRegister(types.MinerActorCodeObj, "MinerActor", &miner.Actor{})
+	MustRegister(types.BootstrapMinerActorCodeObj, "MinerActor", &miner.Actor{Bootstrap: true})
+}
+
+// Register makes impl the implementation of actors whose code is the cid of
+// code, so that genesis can create them and the vm can run them. name is the
+// name of the actor type, as reported by types.ActorCodeTypeName.
+//
+// Actors defined outside of this repository should be registered from the
+// init function of their package, before any state is loaded. Every node of a
+// network must register the same actors under the same code, or they will not
+// agree on the state.
+func Register(code ipld.Node, name string, impl exec.ExecutableActor) error {
+	c := code.Cid()
+	if _, ok := Actors[c]; ok {
+		return errors.Errorf("actor code %s is already registered", c)
+	}
+
+	Actors[c] = impl
+	codeObjs = append(codeObjs, code)
+	types.ActorCodeCidTypeNames[c] = name
+	return nil
+}
+
+// MustRegister is like Register but panics if code is already registered.
+func MustRegister(code ipld.Node, name string, impl exec.ExecutableActor) {
+	if err := Register(code, name, impl); err != nil {
+		panic(err)
+	}
+}
+
+// CodeObjs returns the code objects of the registered actors, in the order
+// they were registered.
+func CodeObjs() []ipld.Node {
+	return append([]ipld.Node{}, codeObjs...)
 }
@@ -0,0 +1,36 @@
+package builtin
+
+import (
+	"testing"
+
+	ipld "gx/ipfs/QmRL22E4paat7ky7vx9MLpR97JHHbFPrg3ytFQw6qp1y1s/go-ipld-format"
+
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestRegister(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	code := types.NewActorCodeObj("testregisteractor")
+	defer func(objs []ipld.Node) { codeObjs = objs }(codeObjs)
+	defer delete(Actors, code.Cid())
+	defer delete(types.ActorCodeCidTypeNames, code.Cid())
+
+	impl := &actor.FakeActor{}
+	require.NoError(Register(code, "TestRegisterActor", impl))
+
+	assert.Equal(impl, Actors[code.Cid()])
+	assert.Equal("TestRegisterActor", types.ActorCodeTypeName(code.Cid()))
+	objs := CodeObjs()
+	assert.Equal(code.Cid(), objs[len(objs)-1].Cid())
+
+	// Code may only be registered once.
+	assert.Error(Register(code, "Other", &actor.FakeActor{}))
+	assert.Error(Register(types.AccountActorCodeObj, "AccountActor", &actor.FakeActor{}))
+	assert.Equal(impl, Actors[code.Cid()])
+}
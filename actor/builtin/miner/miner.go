@@ -23,6 +23,33 @@ import (
 func init() {
 	cbor.RegisterCborType(State{})
 	cbor.RegisterCborType(Ask{})
+	cbor.RegisterCborType(SectorCommittedEvent{})
+	cbor.RegisterCborType(PoStSubmittedEvent{})
+}
+
+// Topics of the events the miner actor emits.
+const (
+	// EventSectorCommitted is emitted with a SectorCommittedEvent when a
+	// sector is committed.
+	EventSectorCommitted = "miner/sector-committed"
+	// EventPoStSubmitted is emitted with a PoStSubmittedEvent when a proof of
+	// spacetime is accepted.
+	EventPoStSubmitted = "miner/post-submitted"
+)
+
+// SectorCommittedEvent is the data of the event emitted when a sector is
+// committed. Power is the power of the miner including the sector.
+type SectorCommittedEvent struct {
+	SectorID    types.Uint64      `json:"sectorId"`
+	Commitments types.Commitments `json:"commitments"`
+	Power       *big.Int          `json:"power"`
+}
+
+// PoStSubmittedEvent is the data of the event emitted when a proof of
+// spacetime is accepted. ProvingPeriodStart is the start of the next proving
+// period.
+type PoStSubmittedEvent struct {
+	ProvingPeriodStart *types.BlockHeight `json:"provingPeriodStart"`
 }
 
 // MaximumPublicKeySize is a limit on how big a public key can be.
@@ -97,6 +124,9 @@ type Ask struct {
 	ID     *big.Int
 }
 
+// AskType is the ABI type of a *Ask.
+var AskType = abi.RegisterStruct("miner.Ask", (*Ask)(nil))
+
 // State is the miner actors storage.
 type State struct {
 	Owner address.Address
@@ -179,6 +209,7 @@ func (ma *Actor) InitializeState(storage exec.Storage, initializerData interface
 }
 
 var _ exec.ExecutableActor = (*Actor)(nil)
+var _ exec.ErrorCoder = (*Actor)(nil)
 
 var minerExports = exec.Exports{
 	"addAsk": &exec.FunctionSignature{
@@ -191,7 +222,7 @@ var minerExports = exec.Exports{
 	},
 	"getAsk": &exec.FunctionSignature{
 		Params: []abi.Type{abi.Integer},
-		Return: []abi.Type{abi.Bytes},
+		Return: []abi.Type{AskType},
 	},
 	"getOwner": &exec.FunctionSignature{
 		Params: nil,
@@ -244,13 +275,14 @@ func (ma *Actor) Exports() exec.Exports {
 	return minerExports
 }
 
+// Errors returns the errors the methods of this actor return, by exit code.
+func (ma *Actor) Errors() map[uint8]error {
+	return Errors
+}
+
 // AddAsk adds an ask to this miners ask list
 func (ma *Actor) AddAsk(ctx exec.VMContext, price *types.AttoFIL, expiry *big.Int) (*big.Int, uint8,
 	error) {
-	if err := ctx.Charge(100); err != nil {
-		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
 	var state State
 	out, err := actor.WithState(ctx, &state, func() (interface{}, error) {
 		if ctx.Message().From != state.Owner {
@@ -297,9 +329,6 @@ func (ma *Actor) AddAsk(ctx exec.VMContext, price *types.AttoFIL, expiry *big.In
 // GetAsks returns all the asks for this miner. (TODO: this isnt a great function signature, it returns the asks in a
 // serialized array. Consider doing this some other way)
 func (ma *Actor) GetAsks(ctx exec.VMContext) ([]uint64, uint8, error) {
-	if err := ctx.Charge(100); err != nil {
-		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
 	var state State
 	out, err := actor.WithState(ctx, &state, func() (interface{}, error) {
 		var askids []uint64
@@ -325,39 +354,24 @@ func (ma *Actor) GetAsks(ctx exec.VMContext) ([]uint64, uint8, error) {
 }
 
 // GetAsk returns an ask by ID
-func (ma *Actor) GetAsk(ctx exec.VMContext, askid *big.Int) ([]byte, uint8, error) {
-	if err := ctx.Charge(100); err != nil {
-		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
+func (ma *Actor) GetAsk(ctx exec.VMContext, askid *big.Int) (*Ask, uint8, error) {
 	var state State
 	out, err := actor.WithState(ctx, &state, func() (interface{}, error) {
-		var ask *Ask
 		for _, a := range state.Asks {
 			if a.ID.Cmp(askid) == 0 {
-				ask = a
-				break
+				return a, nil
 			}
 		}
 
-		if ask == nil {
-			return nil, Errors[ErrAskNotFound]
-		}
-
-		out, err := cbor.DumpObject(ask)
-		if err != nil {
-			return nil, err
-		}
-
-		return out, nil
+		return nil, Errors[ErrAskNotFound]
 	})
 	if err != nil {
 		return nil, errors.CodeError(err), err
 	}
 
-	ask, ok := out.([]byte)
+	ask, ok := out.(*Ask)
 	if !ok {
-		return nil, 1, errors.NewRevertErrorf("expected a Bytes return value from call, but got %T instead", out)
+		return nil, 1, errors.NewRevertErrorf("expected an *Ask return value from call, but got %T instead", out)
 	}
 
 	return ask, 0, nil
@@ -365,10 +379,6 @@ func (ma *Actor) GetAsk(ctx exec.VMContext, askid *big.Int) ([]byte, uint8, erro
 
 // GetOwner returns the miners owner.
 func (ma *Actor) GetOwner(ctx exec.VMContext) (address.Address, uint8, error) {
-	if err := ctx.Charge(100); err != nil {
-		return address.Address{}, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
 	var state State
 	out, err := actor.WithState(ctx, &state, func() (interface{}, error) {
 		return state.Owner, nil
@@ -387,9 +397,6 @@ func (ma *Actor) GetOwner(ctx exec.VMContext) (address.Address, uint8, error) {
 
 // GetLastUsedSectorID returns the last used sector id.
 func (ma *Actor) GetLastUsedSectorID(ctx exec.VMContext) (uint64, uint8, error) {
-	if err := ctx.Charge(100); err != nil {
-		return 0, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
 	var state State
 	out, err := actor.WithState(ctx, &state, func() (interface{}, error) {
 		return state.LastUsedSectorID, nil
@@ -408,10 +415,6 @@ func (ma *Actor) GetLastUsedSectorID(ctx exec.VMContext) (uint64, uint8, error)
 
 // GetSectorCommitments returns all sector commitments posted by this miner.
 func (ma *Actor) GetSectorCommitments(ctx exec.VMContext) (map[string]types.Commitments, uint8, error) {
-	if err := ctx.Charge(100); err != nil {
-		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
 	var state State
 	out, err := actor.WithState(ctx, &state, func() (interface{}, error) {
 		return state.SectorCommitments, nil
@@ -431,9 +434,6 @@ func (ma *Actor) GetSectorCommitments(ctx exec.VMContext) (map[string]types.Comm
 // CommitSector adds a commitment to the specified sector. The sector must not
 // already be committed.
 func (ma *Actor) CommitSector(ctx exec.VMContext, sectorID uint64, commD, commR, commRStar, proof []byte) (uint8, error) {
-	if err := ctx.Charge(100); err != nil {
-		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
 	if len(commD) != int(proofs.CommitmentBytesLen) {
 		return 1, errors.NewRevertError("invalid sized commD")
 	}
@@ -457,6 +457,10 @@ func (ma *Actor) CommitSector(ctx exec.VMContext, sectorID uint64, commD, commR,
 			sectorStoreType = proofs.Test
 		}
 
+		if err := ctx.ChargeSealVerification(); err != nil {
+			return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+		}
+
 		req := proofs.VerifySealRequest{}
 		copy(req.CommD[:], commD)
 		copy(req.CommR[:], commR)
@@ -513,7 +517,11 @@ func (ma *Actor) CommitSector(ctx exec.VMContext, sectorID uint64, commD, commR,
 		if ret != 0 {
 			return nil, Errors[ErrStoragemarketCallFailed]
 		}
-		return nil, nil
+		return nil, ctx.EmitEvent(EventSectorCommitted, &SectorCommittedEvent{
+			SectorID:    types.Uint64(sectorID),
+			Commitments: comms,
+			Power:       state.Power,
+		})
 	})
 	if err != nil {
 		return errors.CodeError(err), err
@@ -524,10 +532,6 @@ func (ma *Actor) CommitSector(ctx exec.VMContext, sectorID uint64, commD, commR,
 
 // GetKey returns the public key for this miner.
 func (ma *Actor) GetKey(ctx exec.VMContext) ([]byte, uint8, error) {
-	if err := ctx.Charge(100); err != nil {
-		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
 	var state State
 	out, err := actor.WithState(ctx, &state, func() (interface{}, error) {
 		return state.PublicKey, nil
@@ -546,10 +550,6 @@ func (ma *Actor) GetKey(ctx exec.VMContext) ([]byte, uint8, error) {
 
 // GetPeerID returns the libp2p peer ID that this miner can be reached at.
 func (ma *Actor) GetPeerID(ctx exec.VMContext) (peer.ID, uint8, error) {
-	if err := ctx.Charge(100); err != nil {
-		return peer.ID(""), exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
 	var state State
 
 	chunk, err := ctx.ReadStorage()
@@ -566,10 +566,6 @@ func (ma *Actor) GetPeerID(ctx exec.VMContext) (peer.ID, uint8, error) {
 
 // UpdatePeerID is used to update the peerID this miner is operating under.
 func (ma *Actor) UpdatePeerID(ctx exec.VMContext, pid peer.ID) (uint8, error) {
-	if err := ctx.Charge(100); err != nil {
-		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
 	var storage State
 	_, err := actor.WithState(ctx, &storage, func() (interface{}, error) {
 		// verify that the caller is authorized to perform update
@@ -590,10 +586,6 @@ func (ma *Actor) UpdatePeerID(ctx exec.VMContext, pid peer.ID) (uint8, error) {
 
 // GetPledge returns the number of pledged sectors
 func (ma *Actor) GetPledge(ctx exec.VMContext) (*big.Int, uint8, error) {
-	if err := ctx.Charge(100); err != nil {
-		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
 	var state State
 	ret, err := actor.WithState(ctx, &state, func() (interface{}, error) {
 		return state.PledgeSectors, nil
@@ -612,10 +604,6 @@ func (ma *Actor) GetPledge(ctx exec.VMContext) (*big.Int, uint8, error) {
 
 // GetPower returns the amount of proven sectors for this miner.
 func (ma *Actor) GetPower(ctx exec.VMContext) (*big.Int, uint8, error) {
-	if err := ctx.Charge(100); err != nil {
-		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
 	var state State
 	ret, err := actor.WithState(ctx, &state, func() (interface{}, error) {
 		return state.Power, nil
@@ -635,14 +623,14 @@ func (ma *Actor) GetPower(ctx exec.VMContext) (*big.Int, uint8, error) {
 // SubmitPoSt is used to submit a coalesced PoST to the chain to convince the chain
 // that you have been actually storing the files you claim to be.
 func (ma *Actor) SubmitPoSt(ctx exec.VMContext, proof []byte) (uint8, error) {
-	if err := ctx.Charge(100); err != nil {
-		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
 	if len(proof) != PoStProofLength {
 		return 0, errors.NewRevertError("invalid sized proof")
 	}
 
+	if err := ctx.ChargePoStVerification(); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
 	var state State
 	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
 		// verify that the caller is authorized to perform update
@@ -688,7 +676,9 @@ func (ma *Actor) SubmitPoSt(ctx exec.VMContext, proof []byte) (uint8, error) {
 			return nil, errors.NewRevertErrorf("submitted PoSt late, need to pay a fee")
 		}
 
-		return nil, nil
+		return nil, ctx.EmitEvent(EventPoStSubmitted, &PoStSubmittedEvent{
+			ProvingPeriodStart: state.ProvingPeriodStart,
+		})
 	})
 	if err != nil {
 		return errors.CodeError(err), err
@@ -699,10 +689,6 @@ func (ma *Actor) SubmitPoSt(ctx exec.VMContext, proof []byte) (uint8, error) {
 
 // GetProvingPeriodStart returns the current ProvingPeriodStart value.
 func (ma *Actor) GetProvingPeriodStart(ctx exec.VMContext) (*types.BlockHeight, uint8, error) {
-	if err := ctx.Charge(100); err != nil {
-		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
 	chunk, err := ctx.ReadStorage()
 	if err != nil {
 		return nil, errors.CodeError(err), err
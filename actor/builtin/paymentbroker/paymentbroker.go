@@ -55,8 +55,20 @@ var Errors = map[uint8]error{
 	ErrInvalidSignature:         errors.NewCodedRevertErrorf(ErrInvalidSignature, "signature failed to validate"),
 }
 
+// Topics of the events the payment broker emits. Their data is a
+// ChannelEvent.
+const (
+	// EventChannelCreated is emitted when a payment channel is created.
+	EventChannelCreated = "paymentbroker/channel-created"
+	// EventChannelRedeemed is emitted when the target redeems a voucher.
+	EventChannelRedeemed = "paymentbroker/channel-redeemed"
+	// EventChannelClosed is emitted when the target closes a channel.
+	EventChannelClosed = "paymentbroker/channel-closed"
+)
+
 func init() {
 	cbor.RegisterCborType(PaymentChannel{})
+	cbor.RegisterCborType(ChannelEvent{})
 }
 
 // PaymentChannel records the intent to pay funds to a target account.
@@ -67,6 +79,19 @@ type PaymentChannel struct {
 	Eol            *types.BlockHeight `json:"eol"`
 }
 
+// PaymentChannelType is the ABI type of a *PaymentChannel.
+var PaymentChannelType = abi.RegisterStruct("paymentbroker.PaymentChannel", (*PaymentChannel)(nil))
+
+// ChannelEvent is the data of the events about a payment channel. Amount is
+// the amount deposited when the channel is created and the total amount
+// redeemed from it when a voucher is redeemed or the channel closed.
+type ChannelEvent struct {
+	Payer   address.Address  `json:"payer"`
+	Target  address.Address  `json:"target"`
+	Channel *types.ChannelID `json:"channel"`
+	Amount  *types.AttoFIL   `json:"amount"`
+}
+
 // Actor provides a mechanism for off chain payments.
 // It allows the creation of payment channels that hold funds for a target account
 // and permits that account to withdraw funds only with a voucher signed by the
@@ -84,7 +109,13 @@ func (pb *Actor) Exports() exec.Exports {
 	return paymentBrokerExports
 }
 
+// Errors returns the errors the methods of this actor return, by exit code.
+func (pb *Actor) Errors() map[uint8]error {
+	return Errors
+}
+
 var _ exec.ExecutableActor = (*Actor)(nil)
+var _ exec.ErrorCoder = (*Actor)(nil)
 
 var paymentBrokerExports = exec.Exports{
 	"close": &exec.FunctionSignature{
@@ -101,7 +132,7 @@ var paymentBrokerExports = exec.Exports{
 	},
 	"ls": &exec.FunctionSignature{
 		Params: []abi.Type{abi.Address},
-		Return: []abi.Type{abi.Bytes},
+		Return: []abi.Type{abi.MapOf(PaymentChannelType)},
 	},
 	"reclaim": &exec.FunctionSignature{
 		Params: []abi.Type{abi.ChannelID},
@@ -121,10 +152,6 @@ var paymentBrokerExports = exec.Exports{
 // The value attached to the invocation is used as the deposit, and the channel
 // will expire and return all of its money to the owner after the given block height.
 func (pb *Actor) CreateChannel(vmctx exec.VMContext, target address.Address, eol *types.BlockHeight) (*types.ChannelID, uint8, error) {
-	if err := vmctx.Charge(100); err != nil {
-		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
 	// require that from account be an account actor to ensure nonce is a valid id
 	if !vmctx.IsFromAccountActor() {
 		return nil, errors.CodeError(Errors[ErrNonAccountActor]), Errors[ErrNonAccountActor]
@@ -156,7 +183,12 @@ func (pb *Actor) CreateChannel(vmctx exec.VMContext, target address.Address, eol
 			return errors.FaultErrorWrap(err, "Could not set payment channel")
 		}
 
-		return nil
+		return vmctx.EmitEvent(EventChannelCreated, &ChannelEvent{
+			Payer:   payerAddress,
+			Target:  target,
+			Channel: channelID,
+			Amount:  vmctx.Message().Value,
+		})
 	})
 
 	if err != nil {
@@ -183,10 +215,9 @@ func (pb *Actor) CreateChannel(vmctx exec.VMContext, target address.Address, eol
 // target Close(500)           -> Payer: 1500, Target: 500, Channel: 0
 //
 func (pb *Actor) Redeem(vmctx exec.VMContext, payer address.Address, chid *types.ChannelID, amt *types.AttoFIL, validAt *types.BlockHeight, sig []byte) (uint8, error) {
-	if err := vmctx.Charge(100); err != nil {
+	if err := vmctx.ChargeSignatureVerification(); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
-
 	if !VerifyVoucherSignature(payer, chid, amt, validAt, sig) {
 		return errors.CodeError(Errors[ErrInvalidSignature]), Errors[ErrInvalidSignature]
 	}
@@ -216,7 +247,16 @@ func (pb *Actor) Redeem(vmctx exec.VMContext, payer address.Address, chid *types
 			return err
 		}
 
-		return byChannelID.Set(ctx, chid.KeyString(), channel)
+		if err := byChannelID.Set(ctx, chid.KeyString(), channel); err != nil {
+			return err
+		}
+
+		return vmctx.EmitEvent(EventChannelRedeemed, &ChannelEvent{
+			Payer:   payer,
+			Target:  channel.Target,
+			Channel: chid,
+			Amount:  channel.AmountRedeemed,
+		})
 	})
 
 	if err != nil {
@@ -233,10 +273,9 @@ func (pb *Actor) Redeem(vmctx exec.VMContext, payer address.Address, chid *types
 // Close first executes the logic performed in the the Update method, then returns all
 // funds remaining in the channel to the payer account and deletes the channel.
 func (pb *Actor) Close(vmctx exec.VMContext, payer address.Address, chid *types.ChannelID, amt *types.AttoFIL, validAt *types.BlockHeight, sig []byte) (uint8, error) {
-	if err := vmctx.Charge(100); err != nil {
+	if err := vmctx.ChargeSignatureVerification(); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
-
 	if !VerifyVoucherSignature(payer, chid, amt, validAt, sig) {
 		return errors.CodeError(Errors[ErrInvalidSignature]), Errors[ErrInvalidSignature]
 	}
@@ -270,7 +309,16 @@ func (pb *Actor) Close(vmctx exec.VMContext, payer address.Address, chid *types.
 		}
 
 		// return funds to payer
-		return reclaim(ctx, vmctx, byChannelID, payer, chid, channel)
+		if err := reclaim(ctx, vmctx, byChannelID, payer, chid, channel); err != nil {
+			return err
+		}
+
+		return vmctx.EmitEvent(EventChannelClosed, &ChannelEvent{
+			Payer:   payer,
+			Target:  channel.Target,
+			Channel: chid,
+			Amount:  channel.AmountRedeemed,
+		})
 	})
 
 	if err != nil {
@@ -287,10 +335,6 @@ func (pb *Actor) Close(vmctx exec.VMContext, payer address.Address, chid *types.
 // Extend can be used by the owner of a channel to add more funds to it and
 // extend the Channel's lifespan.
 func (pb *Actor) Extend(vmctx exec.VMContext, chid *types.ChannelID, eol *types.BlockHeight) (uint8, error) {
-	if err := vmctx.Charge(100); err != nil {
-		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
 	ctx := context.Background()
 	storage := vmctx.Storage()
 	payerAddress := vmctx.Message().From
@@ -337,10 +381,6 @@ func (pb *Actor) Extend(vmctx exec.VMContext, chid *types.ChannelID, eol *types.
 // Reclaim is used by the owner of a channel to reclaim unspent funds in timed
 // out payment Channels they own.
 func (pb *Actor) Reclaim(vmctx exec.VMContext, chid *types.ChannelID) (uint8, error) {
-	if err := vmctx.Charge(100); err != nil {
-		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
 	ctx := context.Background()
 	storage := vmctx.Storage()
 	payerAddress := vmctx.Message().From
@@ -385,10 +425,6 @@ func (pb *Actor) Reclaim(vmctx exec.VMContext, chid *types.ChannelID) (uint8, er
 // Voucher errors if the channel doesn't exist or contains less than request
 // amount.
 func (pb *Actor) Voucher(vmctx exec.VMContext, chid *types.ChannelID, amount *types.AttoFIL, validAt *types.BlockHeight) ([]byte, uint8, error) {
-	if err := vmctx.Charge(100); err != nil {
-		return []byte{}, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
 	ctx := context.Background()
 	storage := vmctx.Storage()
 	payerAddress := vmctx.Message().From
@@ -444,12 +480,8 @@ func (pb *Actor) Voucher(vmctx exec.VMContext, chid *types.ChannelID, amount *ty
 }
 
 // Ls returns all payment channels for a given payer address.
-// The slice of channels will be returned as cbor encoded map from string channelId to PaymentChannel.
-func (pb *Actor) Ls(vmctx exec.VMContext, payer address.Address) ([]byte, uint8, error) {
-	if err := vmctx.Charge(100); err != nil {
-		return []byte{}, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
+// The channels are returned by the key string of their channel id.
+func (pb *Actor) Ls(vmctx exec.VMContext, payer address.Address) (map[string]*PaymentChannel, uint8, error) {
 	ctx := context.Background()
 	storage := vmctx.Storage()
 	channels := map[string]*PaymentChannel{}
@@ -479,12 +511,7 @@ func (pb *Actor) Ls(vmctx exec.VMContext, payer address.Address) ([]byte, uint8,
 		return nil, errors.CodeError(err), err
 	}
 
-	channelsBytes, err := actor.MarshalStorage(channels)
-	if err != nil {
-		return nil, 1, errors.FaultErrorWrap(err, "Error marshalling voucher")
-	}
-
-	return channelsBytes, 0, nil
+	return channels, 0, nil
 }
 
 func updateChannel(ctx exec.VMContext, target address.Address, channel *PaymentChannel, amt *types.AttoFIL, validAt *types.BlockHeight) error {
@@ -628,3 +655,42 @@ func findByChannelLookup(ctx context.Context, storage exec.Storage, byPayer exec
 
 	return actor.LoadTypedLookup(ctx, storage, byChannelCID, &PaymentChannel{})
 }
+
+// TotalUnredeemed returns the sum of the amounts left in all payment channels
+// of the broker whose storage is given, which its balance should equal.
+func TotalUnredeemed(ctx context.Context, storage exec.Storage) (*types.AttoFIL, error) {
+	total := types.NewZeroAttoFIL()
+	err := actor.WithLookupForReading(ctx, storage, storage.Head(), func(byPayer exec.Lookup) error {
+		payers, err := byPayer.Values(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, kv := range payers {
+			byChannelCID, ok := kv.Value.(cid.Cid)
+			if !ok {
+				return errors.NewFaultError("Paymentbroker payer is not a Cid")
+			}
+			byChannelID, err := actor.LoadTypedLookup(ctx, storage, byChannelCID, &PaymentChannel{})
+			if err != nil {
+				return err
+			}
+			channels, err := byChannelID.Values(ctx)
+			if err != nil {
+				return err
+			}
+			for _, ch := range channels {
+				pc, ok := ch.Value.(*PaymentChannel)
+				if !ok {
+					return errors.NewFaultError("Expected PaymentChannel from channel lookup")
+				}
+				total = total.Add(pc.Amount.Sub(pc.AmountRedeemed))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return total, nil
+}
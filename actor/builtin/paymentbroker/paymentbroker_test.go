@@ -22,6 +22,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/state"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/upgrade"
 	"github.com/filecoin-project/go-filecoin/vm"
 
 	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
@@ -257,6 +258,68 @@ func TestPaymentBrokerClose(t *testing.T) {
 	assert.Equal(payerBalancePriorToClose.Add(types.NewAttoFILFromFIL(900)), payerActor.Balance)
 }
 
+func TestPaymentBrokerEmitsChannelEvents(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	sys := setup(t)
+
+	defer func(s upgrade.Schedule) { require.NoError(upgrade.SetSchedule(s)) }(upgrade.CurrentSchedule())
+	require.NoError(upgrade.SetSchedule(upgrade.Schedule{
+		{Height: 0, Version: upgrade.Version0},
+		{Height: 1, Version: upgrade.Version2},
+	}))
+
+	requireChannelEvent := func(result *consensus.ApplicationResult, topic string, amount uint64) {
+		require.NoError(result.ExecutionError)
+		require.Len(result.Events, 1)
+		e := result.Events[0]
+		assert.Equal(address.PaymentBrokerAddress, e.Emitter)
+		assert.Equal(topic, e.Topic)
+
+		var data ChannelEvent
+		require.NoError(cbor.DecodeInto(e.Data, &data))
+		assert.Equal(sys.payer, data.Payer)
+		assert.Equal(sys.target, data.Target)
+		assert.Equal(sys.channelID, data.Channel)
+		assert.Equal(types.NewAttoFILFromFIL(amount), data.Amount)
+
+		root, err := types.EventsRoot(result.Events)
+		require.NoError(err)
+		assert.Equal(root, result.Receipt.EventsRoot)
+	}
+
+	// Before the upgrade that enables them events are dropped.
+	pdata := core.MustConvertParams(sys.target, big.NewInt(10))
+	msg := types.NewMessage(sys.payer, address.PaymentBrokerAddress, 1, types.NewAttoFILFromFIL(1000), "createChannel", pdata)
+	result, err := sys.ApplyMessage(msg, 0)
+	require.NoError(err)
+	require.NoError(result.ExecutionError)
+	assert.Empty(result.Events)
+	assert.False(result.Receipt.EventsRoot.Defined())
+
+	msg = types.NewMessage(sys.payer, address.PaymentBrokerAddress, 2, types.NewAttoFILFromFIL(1000), "createChannel", pdata)
+	result, err = sys.ApplyMessage(msg, 1)
+	require.NoError(err)
+	require.NoError(result.ExecutionError)
+	require.Len(result.Events, 1)
+	assert.Equal(EventChannelCreated, result.Events[0].Topic)
+
+	result, err = sys.ApplyRedeemMessageWithBlockHeight(sys.target, 100, 0, 1)
+	require.NoError(err)
+	requireChannelEvent(result, EventChannelRedeemed, 100)
+
+	result, err = sys.ApplySignatureMessageWithValidAtAndBlockHeight(sys.target, 300, 1, 0, 1, "close")
+	require.NoError(err)
+	requireChannelEvent(result, EventChannelClosed, 300)
+
+	// A failed message emits nothing.
+	result, err = sys.ApplyRedeemMessageWithBlockHeight(sys.payer, 400, 2, 1)
+	require.NoError(err)
+	require.Error(result.ExecutionError)
+	assert.Empty(result.Events)
+	assert.False(result.Receipt.EventsRoot.Defined())
+}
+
 func TestPaymentBrokerCloseErrorsBeforeValidAt(t *testing.T) {
 	require := require.New(t)
 	assert := assert.New(t)
@@ -438,9 +501,9 @@ func TestPaymentBrokerLs(t *testing.T) {
 		require.NoError(err)
 		assert.Equal(uint8(0), exitCode)
 
-		channels := make(map[string]*PaymentChannel)
-		err = cbor.DecodeInto(returnValue[0], &channels)
+		val, err := abi.Deserialize(returnValue[0], abi.MapOf(PaymentChannelType))
 		require.NoError(err)
+		channels := val.Val.(map[string]*PaymentChannel)
 
 		assert.Equal(2, len(channels))
 
@@ -475,9 +538,9 @@ func TestPaymentBrokerLs(t *testing.T) {
 		require.NoError(err)
 		assert.Equal(uint8(0), exitCode)
 
-		channels := make(map[string]*PaymentChannel)
-		err = cbor.DecodeInto(returnValue[0], &channels)
+		val, err := abi.Deserialize(returnValue[0], abi.MapOf(PaymentChannelType))
 		require.NoError(err)
+		channels := val.Val.(map[string]*PaymentChannel)
 
 		assert.Equal(0, len(channels))
 	})
@@ -671,9 +734,9 @@ func (sys *system) retrieveChannel(paymentBroker *actor.Actor) *PaymentChannel {
 	require.NoError(err)
 	assert.Equal(uint8(0), exitCode)
 
-	channels := make(map[string]*PaymentChannel)
-	err = cbor.DecodeInto(returnValue[0], &channels)
+	val, err := abi.Deserialize(returnValue[0], abi.MapOf(PaymentChannelType))
 	require.NoError(err)
+	channels := val.Val.(map[string]*PaymentChannel)
 
 	channel := channels[sys.channelID.KeyString()]
 	require.NotNil(channel)
@@ -701,14 +764,14 @@ func (sys *system) ApplyMessage(msg *types.Message, height uint64) (*consensus.A
 
 func requireGetPaymentChannel(t *testing.T, ctx context.Context, st state.Tree, vms vm.StorageMap, payer address.Address, channelId *types.ChannelID) *PaymentChannel {
 	require := require.New(t)
-	var paymentMap map[string]*PaymentChannel
-
 	pdata := core.MustConvertParams(payer)
 	values, ec, err := consensus.CallQueryMethod(ctx, st, vms, address.PaymentBrokerAddress, "ls", pdata, payer, types.NewBlockHeight(0))
 	require.Zero(ec)
 	require.NoError(err)
 
-	actor.UnmarshalStorage(values[0], &paymentMap)
+	val, err := abi.Deserialize(values[0], abi.MapOf(PaymentChannelType))
+	require.NoError(err)
+	paymentMap := val.Val.(map[string]*PaymentChannel)
 
 	result, ok := paymentMap[channelId.KeyString()]
 	require.True(ok)
@@ -44,6 +44,18 @@ var Errors = map[uint8]error{
 func init() {
 	cbor.RegisterCborType(State{})
 	cbor.RegisterCborType(struct{}{})
+	cbor.RegisterCborType(MinerCreatedEvent{})
+}
+
+// EventMinerCreated is the topic of the event the storage market emits with a
+// MinerCreatedEvent when it creates a miner.
+const EventMinerCreated = "storagemarket/miner-created"
+
+// MinerCreatedEvent is the data of the event emitted when a miner is created.
+type MinerCreatedEvent struct {
+	Miner  address.Address `json:"miner"`
+	Owner  address.Address `json:"owner"`
+	Pledge *big.Int        `json:"pledge"`
 }
 
 // Actor implements the filecoin storage market. It is responsible
@@ -83,12 +95,18 @@ func (sma *Actor) InitializeState(storage exec.Storage, _ interface{}) error {
 }
 
 var _ exec.ExecutableActor = (*Actor)(nil)
+var _ exec.ErrorCoder = (*Actor)(nil)
 
 // Exports returns the actors exports.
 func (sma *Actor) Exports() exec.Exports {
 	return storageMarketExports
 }
 
+// Errors returns the errors the methods of this actor return, by exit code.
+func (sma *Actor) Errors() map[uint8]error {
+	return Errors
+}
+
 var storageMarketExports = exec.Exports{
 	"createMiner": &exec.FunctionSignature{
 		Params: []abi.Type{abi.Integer, abi.Bytes, abi.PeerID},
@@ -107,10 +125,6 @@ var storageMarketExports = exec.Exports{
 // CreateMiner creates a new miner with the a pledge of the given amount of sectors. The
 // miners collateral is set by the value in the message.
 func (sma *Actor) CreateMiner(vmctx exec.VMContext, pledge *big.Int, publicKey []byte, pid peer.ID) (address.Address, uint8, error) {
-	if err := vmctx.Charge(100); err != nil {
-		return address.Address{}, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
 	var state State
 	ret, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
 		if pledge.Cmp(MinimumPledge) < 0 {
@@ -150,6 +164,15 @@ func (sma *Actor) CreateMiner(vmctx exec.VMContext, pledge *big.Int, publicKey [
 			return nil, errors.FaultErrorWrapf(err, "could not set miner key value for lookup with CID: %s", state.Miners)
 		}
 
+		err = vmctx.EmitEvent(EventMinerCreated, &MinerCreatedEvent{
+			Miner:  addr,
+			Owner:  vmctx.Message().From,
+			Pledge: pledge,
+		})
+		if err != nil {
+			return nil, err
+		}
+
 		return addr, nil
 	})
 	if err != nil {
@@ -163,10 +186,6 @@ func (sma *Actor) CreateMiner(vmctx exec.VMContext, pledge *big.Int, publicKey [
 // This occurs either when a miner adds a new commitment, or when one is removed
 // (via slashing or willful removal). The delta is in number of sectors.
 func (sma *Actor) UpdatePower(vmctx exec.VMContext, delta *big.Int) (uint8, error) {
-	if err := vmctx.Charge(100); err != nil {
-		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
 	var state State
 	_, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
 		miner := vmctx.Message().From
@@ -198,10 +217,6 @@ func (sma *Actor) UpdatePower(vmctx exec.VMContext, delta *big.Int) (uint8, erro
 
 // GetTotalStorage returns the total amount of proven storage in the system.
 func (sma *Actor) GetTotalStorage(vmctx exec.VMContext) (*big.Int, uint8, error) {
-	if err := vmctx.Charge(100); err != nil {
-		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
 	var state State
 	ret, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
 		return state.TotalCommittedStorage, nil
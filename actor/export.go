@@ -108,11 +108,13 @@ func MakeTypedExport(actor exec.ExecutableActor, method string) exec.ExportedFun
 			return nil, exitCode, outErr
 		}
 
-		vals := make([]interface{}, 0, len(out)-2)
-		for _, vv := range out[:len(out)-2] {
-			vals = append(vals, vv.Interface())
+		// Encode by the signature rather than the go types of the values, since
+		// composite types may share go types with basic ones.
+		vals := make([]*abi.Value, 0, len(out)-2)
+		for i, vv := range out[:len(out)-2] {
+			vals = append(vals, &abi.Value{Type: signature.Return[i], Val: vv.Interface()})
 		}
-		retVal, err := abi.ToEncodedValues(vals...)
+		retVal, err := abi.EncodeValues(vals)
 		if err != nil {
 			return nil, 1, errors.FaultErrorWrap(err, "failed to marshal output value")
 		}
@@ -103,18 +103,12 @@ func (ma *FakeActor) Exports() exec.Exports {
 
 // HasReturnValue is a dummy method that does nothing.
 func (ma *FakeActor) HasReturnValue(ctx exec.VMContext) (address.Address, uint8, error) {
-	if err := ctx.Charge(100); err != nil {
-		return address.Address{}, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
-
 	return address.Address{}, 0, nil
 }
 
-// ChargeGasAndRevertError simply charges gas and returns a revert error
+// ChargeGasAndRevertError simply returns a revert error after the vm has
+// charged gas for its invocation.
 func (ma *FakeActor) ChargeGasAndRevertError(ctx exec.VMContext) (uint8, error) {
-	if err := ctx.Charge(100); err != nil {
-		panic("Unexpected error charging gas")
-	}
 	return 1, errors.NewRevertError("boom")
 }
 
@@ -201,15 +195,12 @@ func (ma *FakeActor) AttemptMultiSpend2(ctx exec.VMContext, self, target address
 
 // RunsAnotherMessage sends a message
 func (ma *FakeActor) RunsAnotherMessage(ctx exec.VMContext, target address.Address) (uint8, error) {
-	if err := ctx.Charge(100); err != nil {
-		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
-	}
 	_, code, err := ctx.Send(target, "hasReturnValue", types.ZeroAttoFIL, []interface{}{})
 	return code, err
 }
 
 // BlockLimitTestMethod is designed to be used with block gas limit tests. It consumes 1/4 of the
-// block gas limit per run. Please ensure message.gasLimit >= 1/4 of block limit or it will panic.
+// block gas limit per run. Please ensure message.gasLimit exceeds 1/4 of block limit or it will panic.
 func (ma *FakeActor) BlockLimitTestMethod(ctx exec.VMContext) (uint8, error) {
 	if err := ctx.Charge(types.BlockGasLimit / 4); err != nil {
 		panic("designed for block limit testing, ensure msg limit is adequate")
@@ -5,6 +5,7 @@ import (
 
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 
+	"github.com/filecoin-project/go-filecoin/chain"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
@@ -33,5 +34,5 @@ type ReadableExports map[string]*ReadableFunctionSignature
 // Actor is the interface that defines methods to inspect actors, which are Filecoin's
 // notion of smart contracts.
 type Actor interface {
-	Ls(ctx context.Context) ([]*ActorView, error)
+	Ls(ctx context.Context, at chain.TipSetRef) ([]*ActorView, error)
 }
@@ -12,6 +12,7 @@ type API interface {
 	Log() Log
 	Miner() Miner
 	Mining() Mining
+	Mpool() Mpool
 	Paych() Paych
 	Ping() Ping
 	RetrievalClient() RetrievalClient
@@ -11,6 +11,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/api"
+	"github.com/filecoin-project/go-filecoin/chain"
 	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/node"
 	"github.com/filecoin-project/go-filecoin/state"
@@ -25,12 +26,12 @@ func newNodeActor(api *nodeAPI) *nodeActor {
 	return &nodeActor{api: api}
 }
 
-func (api *nodeActor) Ls(ctx context.Context) ([]*api.ActorView, error) {
-	return ls(ctx, api.api.node, state.GetAllActors)
+func (api *nodeActor) Ls(ctx context.Context, at chain.TipSetRef) ([]*api.ActorView, error) {
+	return ls(ctx, at, api.api.node, state.GetAllActors)
 }
 
-func ls(ctx context.Context, fcn *node.Node, actorGetter state.GetAllActorsFunc) ([]*api.ActorView, error) {
-	st, err := fcn.ChainReader.LatestState(ctx)
+func ls(ctx context.Context, at chain.TipSetRef, fcn *node.Node, actorGetter state.GetAllActorsFunc) ([]*api.ActorView, error) {
+	st, err := fcn.ChainReader.StateAt(ctx, at)
 	if err != nil {
 		return nil, err
 	}
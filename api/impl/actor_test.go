@@ -38,7 +38,7 @@ func TestActorLs(t *testing.T) {
 
 		nd := node.MakeOfflineNode(t)
 
-		_, err := ls(ctx, nd, getActorsNoOp)
+		_, err := ls(ctx, chain.TipSetRef{}, nd, getActorsNoOp)
 		require.Error(err)
 	})
 
@@ -80,7 +80,7 @@ func TestActorLs(t *testing.T) {
 			return []string{"address1", "address2", "address3", "address4"}, []*actor.Actor{actor1, actor2, actor3, actor4}
 		}
 
-		actorViews, err := ls(ctx, nd, getActors)
+		actorViews, err := ls(ctx, chain.TipSetRef{}, nd, getActors)
 		require.NoError(err)
 
 		assert.Equal(4, len(actorViews))
@@ -11,6 +11,7 @@ import (
 
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/api"
+	"github.com/filecoin-project/go-filecoin/chain"
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
 	"github.com/filecoin-project/go-filecoin/wallet"
@@ -33,10 +34,10 @@ func (api *nodeAddress) Addrs() api.Addrs {
 	return api.addrs
 }
 
-func (api *nodeAddress) Balance(ctx context.Context, addr address.Address) (*types.AttoFIL, error) {
+func (api *nodeAddress) Balance(ctx context.Context, at chain.TipSetRef, addr address.Address) (*types.AttoFIL, error) {
 	fcn := api.api.node
 
-	tree, err := fcn.ChainReader.LatestState(ctx)
+	tree, err := fcn.ChainReader.StateAt(ctx, at)
 	if err != nil {
 		return types.ZeroAttoFIL, err
 	}
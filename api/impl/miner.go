@@ -9,6 +9,7 @@ import (
 	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
 	"github.com/filecoin-project/go-filecoin/porcelain"
 	"github.com/filecoin-project/go-filecoin/types"
 )
@@ -82,9 +83,10 @@ func (nm *nodeMiner) GetOwner(ctx context.Context, minerAddr address.Address) (a
 	return address.NewFromBytes(bytes[0])
 }
 
-func (nm *nodeMiner) GetPower(ctx context.Context, minerAddr address.Address) (*big.Int, error) {
-	bytes, _, err := nm.porcelainAPI.MessageQuery(
+func (nm *nodeMiner) GetPower(ctx context.Context, at chain.TipSetRef, minerAddr address.Address) (*big.Int, error) {
+	bytes, _, err := nm.porcelainAPI.MessageQueryAt(
 		ctx,
+		at,
 		address.Address{},
 		minerAddr,
 		"getPower",
@@ -114,9 +116,10 @@ func (nm *nodeMiner) GetPledge(ctx context.Context, minerAddr address.Address) (
 	return power, nil
 }
 
-func (nm *nodeMiner) GetTotalPower(ctx context.Context) (*big.Int, error) {
-	bytes, _, err := nm.porcelainAPI.MessageQuery(
+func (nm *nodeMiner) GetTotalPower(ctx context.Context, at chain.TipSetRef) (*big.Int, error) {
+	bytes, _, err := nm.porcelainAPI.MessageQueryAt(
 		ctx,
+		at,
 		address.Address{},
 		address.StorageMarketAddress,
 		"getTotalStorage",
@@ -3,8 +3,11 @@ package impl
 import (
 	"context"
 
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/api"
 	"github.com/filecoin-project/go-filecoin/chain"
 	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/mining"
@@ -25,7 +28,39 @@ func (api *nodeMining) Once(ctx context.Context) (*types.Block, error) {
 	nd := api.api.node
 	ts := nd.ChainReader.Head()
 
-	blockTime, mineDelay := nd.MiningTimes()
+	_, mineDelay := nd.MiningTimes()
+	worker, err := newDefaultWorker(nd)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := mining.MineOnce(ctx, worker, mineDelay, ts)
+	if err != nil {
+		return nil, err
+	}
+	if res.Err != nil {
+		return nil, res.Err
+	}
+
+	if err := nd.AddNewBlock(ctx, res.NewBlock); err != nil {
+		return nil, err
+	}
+
+	return res.NewBlock, nil
+}
+
+func (api *nodeMining) Preview(ctx context.Context) (*mining.BlockPreview, error) {
+	nd := api.api.node
+	worker, err := newDefaultWorker(nd)
+	if err != nil {
+		return nil, err
+	}
+	return worker.Preview(ctx, nd.ChainReader.Head())
+}
+
+// newDefaultWorker returns a worker mining for the node's configured miner.
+func newDefaultWorker(nd *node.Node) (*mining.DefaultWorker, error) {
+	blockTime, _ := nd.MiningTimes()
 
 	getStateByKey := func(ctx context.Context, tsKey string) (state.Tree, error) {
 		tsas, err := nd.ChainReader.GetTipSetAndState(ctx, tsKey)
@@ -68,26 +103,61 @@ func (api *nodeMining) Once(ctx context.Context) (*types.Block, error) {
 	getAncestors := func(ctx context.Context, ts types.TipSet, newBlockHeight *types.BlockHeight) ([]types.TipSet, error) {
 		return chain.GetRecentAncestors(ctx, ts, nd.ChainReader, newBlockHeight, consensus.AncestorRoundsNeeded, consensus.LookBackParameter)
 	}
-	worker := mining.NewDefaultWorker(nd.MsgPool, getState, getWeight, getAncestors, consensus.NewDefaultProcessor(),
-		nd.PowerTable, nd.Blockstore, nd.CborStore(), miningAddr, blockSignerAddr, nd.Wallet, blockTime)
+	return mining.NewDefaultWorker(nd.MsgPool, getState, getWeight, getAncestors, consensus.NewDefaultProcessor(),
+		nd.PowerTable, nd.Blockstore, nd.CborStore(), miningAddr, blockSignerAddr, nd.Wallet, blockTime), nil
+}
 
-	res, err := mining.MineOnce(ctx, worker, mineDelay, ts)
+func (api *nodeMining) Start(ctx context.Context) error {
+	return node.StartMining(ctx, api.api.node)
+}
+
+func (api *nodeMining) Status(ctx context.Context) (*api.MiningStatus, error) {
+	return miningStatus(ctx, api.api.node)
+}
+
+func miningStatus(ctx context.Context, nd *node.Node) (*api.MiningStatus, error) {
+	st, err := nd.ChainReader.LatestState(ctx)
 	if err != nil {
-		return nil, err
+		return nil, errors.Wrap(err, "failed to get latest state")
 	}
-	if res.Err != nil {
-		return nil, res.Err
-	}
-
-	if err := nd.AddNewBlock(ctx, res.NewBlock); err != nil {
-		return nil, err
+	totalPower, err := nd.PowerTable.Total(ctx, st, nd.Blockstore)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get total power")
 	}
 
-	return res.NewBlock, nil
-}
+	status := &api.MiningStatus{Active: nd.IsMining()}
+	for _, mcfg := range nd.MinerConfigs() {
+		owner, err := nd.PorcelainAPI.MinerGetOwnerAddress(ctx, mcfg.Address)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get owner of miner %s", mcfg.Address)
+		}
+		power, err := nd.PowerTable.Miner(ctx, st, nd.Blockstore, mcfg.Address)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get power of miner %s", mcfg.Address)
+		}
+		stats, err := nd.MiningStats(ctx, mcfg.Address)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get mining stats of miner %s", mcfg.Address)
+		}
 
-func (api *nodeMining) Start(ctx context.Context) error {
-	return node.StartMining(ctx, api.api.node)
+		ms := &api.MinerStatus{
+			Address:        mcfg.Address,
+			Owner:          owner,
+			BlockSigner:    mcfg.BlockSignerAddress,
+			Power:          power,
+			TotalPower:     totalPower,
+			LastBase:       stats.LastBase,
+			TicketsTried:   stats.TicketsTried,
+			BlocksWon:      stats.BlocksWon,
+			BlocksMined:    stats.BlocksMined,
+			BlocksOrphaned: stats.BlocksOrphaned,
+		}
+		if totalPower > 0 {
+			ms.ExpectedWinRate = float64(power) / float64(totalPower)
+		}
+		status.Miners = append(status.Miners, ms)
+	}
+	return status, nil
 }
 
 func (api *nodeMining) Stop(ctx context.Context) error {
@@ -0,0 +1,26 @@
+package impl
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-filecoin/plumbing/msg"
+	"github.com/filecoin-project/go-filecoin/porcelain"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+type nodeMpool struct {
+	api          *nodeAPI
+	porcelainAPI *porcelain.API
+}
+
+func newNodeMpool(api *nodeAPI, porcelainAPI *porcelain.API) *nodeMpool {
+	return &nodeMpool{api: api, porcelainAPI: porcelainAPI}
+}
+
+func (nm *nodeMpool) View(ctx context.Context, messageCount uint) ([]*types.SignedMessage, error) {
+	return nm.porcelainAPI.MessagePoolWait(ctx, messageCount)
+}
+
+func (nm *nodeMpool) Status(ctx context.Context) ([]*msg.SentMessage, error) {
+	return nm.porcelainAPI.MessageSentStatus(ctx), nil
+}
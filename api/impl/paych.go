@@ -2,12 +2,15 @@ package impl
 
 import (
 	"context"
+	"fmt"
 
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 	cbor "gx/ipfs/QmcZLyosDwMKdB6NLRsiss9HXzDPhVhhRtPy67JFKTDQDX/go-ipld-cbor"
 
+	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
 	"github.com/filecoin-project/go-filecoin/porcelain"
 	"github.com/filecoin-project/go-filecoin/types"
 )
@@ -34,7 +37,7 @@ func (np *nodePaych) Create(ctx context.Context, fromAddr address.Address, gasPr
 	)
 }
 
-func (np *nodePaych) Ls(ctx context.Context, fromAddr, payerAddr address.Address) (map[string]*paymentbroker.PaymentChannel, error) {
+func (np *nodePaych) Ls(ctx context.Context, at chain.TipSetRef, fromAddr, payerAddr address.Address) (map[string]*paymentbroker.PaymentChannel, error) {
 	nd := np.api.node
 
 	if err := setDefaultFromAddr(&fromAddr, nd); err != nil {
@@ -45,8 +48,9 @@ func (np *nodePaych) Ls(ctx context.Context, fromAddr, payerAddr address.Address
 		payerAddr = fromAddr
 	}
 
-	values, _, err := np.porcelainAPI.MessageQuery(
+	values, sig, err := np.porcelainAPI.MessageQueryAt(
 		ctx,
+		at,
 		fromAddr,
 		address.PaymentBrokerAddress,
 		"ls",
@@ -56,12 +60,15 @@ func (np *nodePaych) Ls(ctx context.Context, fromAddr, payerAddr address.Address
 		return nil, err
 	}
 
-	var channels map[string]*paymentbroker.PaymentChannel
-
-	if err := cbor.DecodeInto(values[0], &channels); err != nil {
+	val, err := abi.Deserialize(values[0], sig.Return[0])
+	if err != nil {
 		return nil, err
 	}
 
+	channels, ok := val.Val.(map[string]*paymentbroker.PaymentChannel)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type for payment channels: %T", val.Val)
+	}
 	return channels, nil
 }
 
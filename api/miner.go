@@ -8,6 +8,7 @@ import (
 	"gx/ipfs/QmTu65MVbemtUxJEWgsTtzv9Zv9P8rvmqNA4eG9TrTRGYc/go-libp2p-peer"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
@@ -18,6 +19,6 @@ type Miner interface {
 	AddAsk(ctx context.Context, fromAddr, minerAddr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, price *types.AttoFIL, expiry *big.Int) (cid.Cid, error)
 	GetOwner(ctx context.Context, minerAddr address.Address) (address.Address, error)
 	GetPledge(ctx context.Context, minerAddr address.Address) (*big.Int, error)
-	GetPower(ctx context.Context, minerAddr address.Address) (*big.Int, error)
-	GetTotalPower(ctx context.Context) (*big.Int, error)
+	GetPower(ctx context.Context, at chain.TipSetRef, minerAddr address.Address) (*big.Int, error)
+	GetTotalPower(ctx context.Context, at chain.TipSetRef) (*big.Int, error)
 }
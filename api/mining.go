@@ -3,12 +3,51 @@ package api
 import (
 	"context"
 
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/mining"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
 // Mining is the interface that defines methods to manage mining operations.
 type Mining interface {
 	Once(ctx context.Context) (*types.Block, error)
+	Preview(ctx context.Context) (*mining.BlockPreview, error)
 	Start(ctx context.Context) error
+	Status(ctx context.Context) (*MiningStatus, error)
 	Stop(ctx context.Context) error
 }
+
+// MiningStatus is the state of mining on a node.
+type MiningStatus struct {
+	// Active is true if the node is mining.
+	Active bool
+	// Miners are the miners the node mines for.
+	Miners []*MinerStatus
+}
+
+// MinerStatus describes a miner actor a node mines for.
+type MinerStatus struct {
+	Address     address.Address
+	Owner       address.Address
+	BlockSigner address.Address
+
+	// Power is the miner's storage power and TotalPower that of the
+	// network, in the latest state.
+	Power      uint64
+	TotalPower uint64
+	// ExpectedWinRate is the chance the miner wins an epoch, its share of
+	// the total power.
+	ExpectedWinRate float64
+
+	// LastBase is the tipset the node last mined on for the miner.
+	LastBase types.SortedCidSet
+	// TicketsTried is how many elections the node ran for the miner since it
+	// started, and BlocksWon how many of those it won.
+	TicketsTried uint64
+	BlocksWon    uint64
+	// BlocksMined is how many blocks the node mined for the miner since it
+	// started, and BlocksOrphaned how many of the latest of them did not make
+	// it into the chain.
+	BlocksMined    uint64
+	BlocksOrphaned uint64
+}
@@ -3,10 +3,14 @@ package api
 import (
 	"context"
 
+	"github.com/filecoin-project/go-filecoin/plumbing/msg"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
 // Mpool is the interface that defines methods to interact with the memory pool.
 type Mpool interface {
 	View(ctx context.Context, messageCount uint) ([]*types.SignedMessage, error)
+	// Status returns the status of the messages this node sent that are
+	// still in the pool, oldest first.
+	Status(ctx context.Context) ([]*msg.SentMessage, error)
 }
@@ -1,33 +1,115 @@
 package chain
 
 import (
+	"encoding/json"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
+	"gx/ipfs/QmTu65MVbemtUxJEWgsTtzv9Zv9P8rvmqNA4eG9TrTRGYc/go-libp2p-peer"
+	"gx/ipfs/QmUadX5EcvrBmxAV9sE7wUWtWSqxns5K84qKJBixmcT1w9/go-datastore"
+	"gx/ipfs/QmUadX5EcvrBmxAV9sE7wUWtWSqxns5K84qKJBixmcT1w9/go-datastore/query"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/repo"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
+// badTipSetsKey is the datastore namespace under which bad tipsets are persisted.
+var badTipSetsKey = datastore.NewKey("/chain/badTipSets")
+
+// BadTipSet records a tipset the syncer found to be invalid, and why.
+type BadTipSet struct {
+	// TipSetKey is the key of the invalid tipset.
+	TipSetKey string
+	// Reason is the validation error that caused the tipset to be rejected.
+	Reason string
+	// FirstSeen is the time the tipset was first rejected.
+	FirstSeen time.Time
+	// Source is the peer that sent the tipset, it is empty if the tipset
+	// did not come from a known peer.
+	Source peer.ID
+}
+
 // badTipSetCache keeps track of bad tipsets that the syncer should not try to
-// download.  Readers and writers grab a lock.
+// download.  Entries are persisted to the datastore so that they survive
+// restarts.  Readers and writers grab a lock.
 // TODO: this needs to be limited.
 type badTipSetCache struct {
 	mu  sync.Mutex
-	bad map[string]struct{}
+	bad map[string]*BadTipSet
+	ds  repo.Datastore
+}
+
+// newBadTipSetCache returns a cache persisting entries to ds.  Call load to
+// populate it with previously persisted entries.
+func newBadTipSetCache(ds repo.Datastore) *badTipSetCache {
+	return &badTipSetCache{
+		bad: make(map[string]*BadTipSet),
+		ds:  ds,
+	}
+}
+
+// load reads all persisted entries from the datastore into the cache.
+func (cache *badTipSetCache) load() error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	res, err := cache.ds.Query(query.Query{Prefix: badTipSetsKey.String()})
+	if err != nil {
+		return errors.Wrap(err, "failed to query bad tipsets from datastore")
+	}
+	for entry := range res.Next() {
+		if entry.Error != nil {
+			return errors.Wrap(entry.Error, "failed to read bad tipset from datastore")
+		}
+		var bts BadTipSet
+		if err := json.Unmarshal(entry.Value, &bts); err != nil {
+			return errors.Wrap(err, "failed to unmarshal bad tipset")
+		}
+		cache.bad[bts.TipSetKey] = &bts
+	}
+	return nil
 }
 
 // AddChain adds the chain of tipsets to the badTipSetCache.  For now it just
 // does the simplest thing and adds all blocks of the chain to the cache.
+// The chain's tipsets are recorded with the reason that they descend from
+// the bad tipset badKey.
 // TODO: might want to cache a random subset once cache size is limited.
-func (cache *badTipSetCache) AddChain(chain []types.TipSet) {
+func (cache *badTipSetCache) AddChain(chain []types.TipSet, badKey string, source peer.ID) {
 	for _, ts := range chain {
-		cache.Add(ts.String())
+		cache.Add(ts.String(), "descends from bad tipset "+badKey, source)
 	}
 }
 
-// Add adds a single tipset key to the badTipSetCache.
-func (cache *badTipSetCache) Add(tsKey string) {
+// Add adds a single tipset key to the badTipSetCache.  If the key is already
+// present its original entry is kept.
+func (cache *badTipSetCache) Add(tsKey string, reason string, source peer.ID) {
 	cache.mu.Lock()
 	defer cache.mu.Unlock()
-	cache.bad[tsKey] = struct{}{}
+	if _, ok := cache.bad[tsKey]; ok {
+		return
+	}
+	bts := &BadTipSet{
+		TipSetKey: tsKey,
+		Reason:    reason,
+		FirstSeen: time.Now(),
+		Source:    source,
+	}
+	cache.bad[tsKey] = bts
+
+	// A failure to persist only means the tipset may be revalidated after
+	// a restart, so it is not surfaced to the caller.
+	data, err := json.Marshal(bts)
+	if err != nil {
+		logSyncer.Warningf("failed to marshal bad tipset %s: %s", tsKey, err)
+		return
+	}
+	if err := cache.ds.Put(badTipSetDSKey(tsKey), data); err != nil {
+		logSyncer.Warningf("failed to persist bad tipset %s: %s", tsKey, err)
+	}
 }
 
 // Has checks for membership in the badTipSetCache.
@@ -37,3 +119,37 @@ func (cache *badTipSetCache) Has(tsKey string) bool {
 	_, ok := cache.bad[tsKey]
 	return ok
 }
+
+// Remove deletes the entry for a tipset key from the cache and the datastore.
+func (cache *badTipSetCache) Remove(tsKey string) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if _, ok := cache.bad[tsKey]; !ok {
+		return errors.Errorf("tipset %s is not in the bad tipset cache", tsKey)
+	}
+	if err := cache.ds.Delete(badTipSetDSKey(tsKey)); err != nil {
+		return errors.Wrap(err, "failed to delete bad tipset from datastore")
+	}
+	delete(cache.bad, tsKey)
+	return nil
+}
+
+// List returns copies of all entries in the cache ordered by the time they
+// were first seen.
+func (cache *badTipSetCache) List() []BadTipSet {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	out := make([]BadTipSet, 0, len(cache.bad))
+	for _, bts := range cache.bad {
+		out = append(out, *bts)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].FirstSeen.Before(out[j].FirstSeen) })
+	return out
+}
+
+// badTipSetDSKey returns the datastore key of a tipset key.  Tipset keys are
+// of the form "{ cid1 cid2 }" so the cids are joined to make a single path
+// component.
+func badTipSetDSKey(tsKey string) datastore.Key {
+	return badTipSetsKey.ChildString(strings.Join(strings.Fields(strings.Trim(tsKey, "{}")), "-"))
+}
@@ -0,0 +1,72 @@
+package chain
+
+import (
+	"testing"
+
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
+	"gx/ipfs/QmTu65MVbemtUxJEWgsTtzv9Zv9P8rvmqNA4eG9TrTRGYc/go-libp2p-peer"
+
+	"github.com/filecoin-project/go-filecoin/repo"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestBadTipSetCachePersists(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ds := repo.NewInMemoryRepo().ChainDatastore()
+	newCid := types.NewCidForTestGetter()
+	tsKey := types.NewSortedCidSet(newCid(), newCid()).String()
+
+	cache := newBadTipSetCache(ds)
+	cache.Add(tsKey, "bad ticket", peer.ID("source"))
+	assert.True(cache.Has(tsKey))
+
+	loaded := newBadTipSetCache(ds)
+	require.NoError(loaded.load())
+	require.True(loaded.Has(tsKey))
+
+	entries := loaded.List()
+	require.Equal(1, len(entries))
+	assert.Equal(tsKey, entries[0].TipSetKey)
+	assert.Equal("bad ticket", entries[0].Reason)
+	assert.Equal(peer.ID("source"), entries[0].Source)
+	assert.False(entries[0].FirstSeen.IsZero())
+}
+
+func TestBadTipSetCacheRemove(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ds := repo.NewInMemoryRepo().ChainDatastore()
+	newCid := types.NewCidForTestGetter()
+	tsKey := types.NewSortedCidSet(newCid()).String()
+
+	cache := newBadTipSetCache(ds)
+	cache.Add(tsKey, "bad ticket", "")
+	require.NoError(cache.Remove(tsKey))
+	assert.False(cache.Has(tsKey))
+	assert.Error(cache.Remove(tsKey))
+
+	loaded := newBadTipSetCache(ds)
+	require.NoError(loaded.load())
+	assert.False(loaded.Has(tsKey))
+}
+
+func TestBadTipSetCacheKeepsFirstEntry(t *testing.T) {
+	assert := assert.New(t)
+
+	ds := repo.NewInMemoryRepo().ChainDatastore()
+	newCid := types.NewCidForTestGetter()
+	tsKey := types.NewSortedCidSet(newCid()).String()
+
+	cache := newBadTipSetCache(ds)
+	cache.Add(tsKey, "first", peer.ID("a"))
+	cache.Add(tsKey, "second", peer.ID("b"))
+
+	entries := cache.List()
+	assert.Equal(1, len(entries))
+	assert.Equal("first", entries[0].Reason)
+	assert.Equal(peer.ID("a"), entries[0].Source)
+}
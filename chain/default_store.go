@@ -201,7 +201,7 @@ func (store *DefaultStore) loadStateRoot(ts types.TipSet) (cid.Cid, error) {
 
 // putBlk persists a block to disk.
 func (store *DefaultStore) putBlk(ctx context.Context, block *types.Block) error {
-	if _, err := store.privateStore.Put(ctx, block); err != nil {
+	if _, err := types.PutBlock(ctx, store.privateStore, block); err != nil {
 		return errors.Wrap(err, "failed to put block")
 	}
 	return nil
@@ -271,11 +271,11 @@ func (store *DefaultStore) GetBlocks(ctx context.Context, ids types.SortedCidSet
 
 // GetBlock retrieves a block by cid.
 func (store *DefaultStore) GetBlock(ctx context.Context, c cid.Cid) (*types.Block, error) {
-	var blk types.Block
-	if err := store.privateStore.Get(ctx, c, &blk); err != nil {
+	blk, err := types.GetBlock(ctx, store.privateStore, c)
+	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get block %s", c.String())
 	}
-	return &blk, nil
+	return blk, nil
 }
 
 // HasAllBlocks indicates whether the blocks are in the store.
@@ -375,11 +375,17 @@ func (store *DefaultStore) Head() types.TipSet {
 
 // LatestState returns the state associated with the latest chain head.
 func (store *DefaultStore) LatestState(ctx context.Context) (state.Tree, error) {
-	h := store.Head()
-	if h == nil {
-		return nil, errors.New("Unset head")
-	}
-	tsas, err := store.GetTipSetAndState(ctx, h.String())
+	return store.StateAt(ctx, TipSetRef{})
+}
+
+// GetTipSetAndStateAt returns the tipset and state referenced by ref.
+func (store *DefaultStore) GetTipSetAndStateAt(ctx context.Context, ref TipSetRef) (*TipSetAndState, error) {
+	return getTipSetAndStateAt(ctx, store, ref)
+}
+
+// StateAt returns the state after the tipset referenced by ref.
+func (store *DefaultStore) StateAt(ctx context.Context, ref TipSetRef) (state.Tree, error) {
+	tsas, err := store.GetTipSetAndStateAt(ctx, ref)
 	if err != nil {
 		return nil, err
 	}
@@ -314,6 +314,49 @@ func TestLatestState(t *testing.T) {
 	assert.Equal(genStateRoot, c)
 }
 
+// Tipsets can be retrieved by key or by height below the head.
+func TestGetTipSetAndStateAt(t *testing.T) {
+	ctx := context.Background()
+	initStoreTest(ctx, require.New(t))
+	require := require.New(t)
+	assert := assert.New(t)
+	chainStore := newChainStore()
+
+	requirePutTestChain(require, chainStore)
+	assertSetHead(assert, chainStore, link4)
+
+	height := func(h uint64) chain.TipSetRef { return chain.TipSetRef{Height: &h} }
+
+	got, err := chainStore.GetTipSetAndStateAt(ctx, chain.TipSetRef{})
+	require.NoError(err)
+	assert.Equal(link4, got.TipSet)
+
+	got, err = chainStore.GetTipSetAndStateAt(ctx, chain.TipSetRef{Key: link2.ToSortedCidSet()})
+	require.NoError(err)
+	assert.Equal(link2, got.TipSet)
+	assert.Equal(link2State, got.TipSetStateRoot)
+
+	got, err = chainStore.GetTipSetAndStateAt(ctx, height(1))
+	require.NoError(err)
+	assert.Equal(link1, got.TipSet)
+
+	got, err = chainStore.GetTipSetAndStateAt(ctx, height(0))
+	require.NoError(err)
+	assert.Equal(genTS, got.TipSet)
+
+	// Heights of null blocks resolve to the closest tipset below.
+	got, err = chainStore.GetTipSetAndStateAt(ctx, height(5))
+	require.NoError(err)
+	assert.Equal(link3, got.TipSet)
+
+	_, err = chainStore.GetTipSetAndStateAt(ctx, height(7))
+	assert.Error(err)
+
+	h := uint64(1)
+	_, err = chainStore.GetTipSetAndStateAt(ctx, chain.TipSetRef{Key: link2.ToSortedCidSet(), Height: &h})
+	assert.Error(err)
+}
+
 func assertEmptyCh(assert *assert.Assertions, ch <-chan interface{}) {
 	select {
 	case <-ch:
@@ -7,18 +7,30 @@ import (
 
 	"gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmTu65MVbemtUxJEWgsTtzv9Zv9P8rvmqNA4eG9TrTRGYc/go-libp2p-peer"
 	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 	logging "gx/ipfs/QmbkT7eMTyXfpeyB3ZMxxcxg7XH8t6uXp49jqzz4HB7BGF/go-log"
 
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/repo"
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
+	vmerrors "github.com/filecoin-project/go-filecoin/vm/errors"
 )
 
 // The amount of time the syncer will wait while fetching the blocks of a
 // tipset over the network.
 var blkWaitTime = time.Second // TODO set this parameter in an informed way too
+
+// The maximum number of chains the syncer will fetch concurrently on behalf
+// of a single peer.  New chains from a peer at this limit are dropped.
+var maxInFlightPerPeer = 2
+
+// The number of invalid chains a peer may send before the syncer ignores
+// all further chains from it.
+var maxBadChainsPerPeer = 5
+
 var (
 	// ErrChainHasBadTipSet is returned when the syncer traverses a chain with a cached bad tipset.
 	ErrChainHasBadTipSet = errors.New("input chain contains a cached bad tipset")
@@ -26,6 +38,10 @@ var (
 	ErrNewChainTooLong = errors.New("input chain forked from best chain too far in the past")
 	// ErrUnexpectedStoreState indicates that the syncer's chain store is violating expected invariants.
 	ErrUnexpectedStoreState = errors.New("the chain store is in an unexpected state")
+	// ErrTooManyInFlight is returned when a peer already has the maximum number of chains being fetched.
+	ErrTooManyInFlight = errors.New("too many chains from this peer are already being fetched")
+	// ErrPeerSentBadChains is returned when a peer has sent too many invalid chains to be trusted.
+	ErrPeerSentBadChains = errors.New("peer has sent too many bad chains")
 )
 
 var logSyncer = logging.Logger("chain.syncer")
@@ -44,37 +60,64 @@ var logSyncer = logging.Logger("chain.syncer")
 // tipset in the incoming chain, and assumptions regarding the existence of
 // grandparent state in the store.
 type DefaultSyncer struct {
-	// This mutex ensures at most one call to HandleNewBlocks executes at
-	// any time.  This is important because at least two sections of the
+	// This mutex ensures at most one chain is validated and added to the
+	// store at any time.  It is NOT held while chains are collected from
+	// the network.  This is important because at least two sections of the
 	// code otherwise have races:
 	// 1. syncOne assumes that chainStore.Head() does not change when
 	// comparing tipset weights and updating the store
-	// 2. HandleNewBlocks assumes that calls to widen and then syncOne
+	// 2. syncChain assumes that calls to widen and then syncOne
 	// are not run concurrently with other calls to widen to ensure
 	// that the syncer always finds the heaviest existing tipset.
 	mu sync.Mutex
+	// fetchMu protects inFlight, syncs, lastSync and currentHeight.
+	fetchMu sync.Mutex
+	// inFlight counts the chains currently being collected for each peer.
+	inFlight map[peer.ID]int
+	// syncs are the syncs in progress, oldest first.  Each sync has its
+	// own status so concurrent syncs don't overwrite each other's.
+	syncs []*ChainSync
+	// lastSync is the most recently finished sync.
+	lastSync ChainSync
+	// currentHeight is the height of the last tipset added to the store.
+	currentHeight uint64
 	// cstOnline is the online storage for fetching blocks.  It should be connected to the network with bitswap.
 	cstOnline *hamt.CborIpldStore
 	// cstOffline is the node's shared offline storage.
 	cstOffline *hamt.CborIpldStore
 	// badTipSetCache is used to filter out collections of invalid blocks.
 	badTipSets *badTipSetCache
+	// badPeers scores peers by the number of invalid chains they sent.
+	badPeers   *peerScores
 	consensus  consensus.Protocol
 	chainStore Store
+	// finality returns the current finality policy.  It is called on
+	// each sync so that policy changes take effect without a restart.
+	finality func() FinalityPolicy
 }
 
 var _ Syncer = (*DefaultSyncer)(nil)
 
-// NewDefaultSyncer constructs a DefaultSyncer ready for use.
-func NewDefaultSyncer(online, offline *hamt.CborIpldStore, c consensus.Protocol, s Store) Syncer {
+// NewDefaultSyncer constructs a DefaultSyncer ready for use.  The syncer's
+// bad tipset cache is persisted to and loaded from ds.  If finality is nil
+// the syncer accepts forks of any depth.
+func NewDefaultSyncer(online, offline *hamt.CborIpldStore, c consensus.Protocol, s Store, ds repo.Datastore, finality func() FinalityPolicy) Syncer {
+	if finality == nil {
+		finality = func() FinalityPolicy { return FinalityPolicy{} }
+	}
+	badTipSets := newBadTipSetCache(ds)
+	if err := badTipSets.load(); err != nil {
+		logSyncer.Warningf("failed to load bad tipset cache: %s", err)
+	}
 	return &DefaultSyncer{
 		cstOnline:  online,
 		cstOffline: offline,
-		badTipSets: &badTipSetCache{
-			bad: make(map[string]struct{}),
-		},
+		badTipSets: badTipSets,
+		badPeers:   newPeerScores(),
 		consensus:  c,
 		chainStore: s,
+		finality:   finality,
+		inFlight:   make(map[peer.ID]int),
 	}
 }
 
@@ -98,13 +141,13 @@ func (syncer *DefaultSyncer) getBlksMaybeFromNet(ctx context.Context, blkCids []
 			continue
 		}
 		// try the node's local offline storage
-		err = syncer.cstOffline.Get(ctx, blkCid, &blk)
+		blk, err = types.GetBlock(ctx, syncer.cstOffline, blkCid)
 		if err == nil {
 			blks = append(blks, blk)
 			continue
 		}
 		// try the network
-		if err = syncer.cstOnline.Get(ctx, blkCid, &blk); err != nil {
+		if blk, err = types.GetBlock(ctx, syncer.cstOnline, blkCid); err != nil {
 			return nil, err
 		}
 		blks = append(blks, blk)
@@ -118,11 +161,11 @@ func (syncer *DefaultSyncer) getBlksMaybeFromNet(ctx context.Context, blkCids []
 // cbor store that is networked under the hood. collectChain errors if any
 // set of cids in the chain resolves to blocks that do not form a tipset, if
 // the chain is too long, or if any tipset has already been recorded as the
-// head of an invalid chain.
+// head of an invalid chain.  Invalid tipsets are attributed to the peer from.
 //
 // collectChain is the entrypoint to the code that interacts with the network.
 // It does NOT add tipsets to the store.
-func (syncer *DefaultSyncer) collectChain(ctx context.Context, blkCids []cid.Cid) ([]types.TipSet, types.TipSet, error) {
+func (syncer *DefaultSyncer) collectChain(ctx context.Context, from peer.ID, blkCids []cid.Cid) ([]types.TipSet, types.TipSet, error) {
 	var chain []types.TipSet
 	defer logSyncer.Info("chain synced")
 	for {
@@ -133,6 +176,7 @@ func (syncer *DefaultSyncer) collectChain(ctx context.Context, blkCids []cid.Cid
 		logSyncer.Debugf("CollectChain next link: %s", tsKey)
 
 		if syncer.badTipSets.Has(tsKey) {
+			syncer.badPeers.RecordBadChain(from)
 			return nil, nil, ErrChainHasBadTipSet
 		}
 
@@ -143,8 +187,9 @@ func (syncer *DefaultSyncer) collectChain(ctx context.Context, blkCids []cid.Cid
 
 		ts, err := syncer.consensus.NewValidTipSet(ctx, blks)
 		if err != nil {
-			syncer.badTipSets.Add(tsKey)
-			syncer.badTipSets.AddChain(chain)
+			syncer.badTipSets.Add(tsKey, err.Error(), from)
+			syncer.badTipSets.AddChain(chain, tsKey, from)
+			syncer.badPeers.RecordBadChain(from)
 			return nil, nil, err
 		}
 
@@ -314,37 +359,81 @@ func (syncer *DefaultSyncer) widen(ctx context.Context, ts types.TipSet) (types.
 }
 
 // HandleNewBlocks extends the Syncer's chain store by the given blocks if they
-// represent a valid extension. It limits the length of new chains it will
-// attempt to validate and caches invalid blocks it has encountered to
-// help prevent DOS.
+// represent a valid extension.  It is equivalent to HandleNewBlocksFromPeer
+// for blocks with no source peer, which are not subject to the in-flight limit.
 func (syncer *DefaultSyncer) HandleNewBlocks(ctx context.Context, blkCids []cid.Cid) error {
-	// ********** WARNING **********
-	//
-	// This concurrency model is flawed.  The mutex is held during a possibly
-	// long call to the network.  TODO: re-evaluate / re-design the concurrency
-	// model to allow for collectChain to be called outside the lock.
-	// FYI the two biggest concurrency concerns at present would be addressed
-	// by locking after collectChain completes, so my hunch is this can be
-	// fixed by simply moving the lock call below collectChain.
+	return syncer.HandleNewBlocksFromPeer(ctx, "", blkCids)
+}
 
-	syncer.mu.Lock()
-	defer syncer.mu.Unlock()
+// HandleNewBlocksFromPeer extends the Syncer's chain store by the given blocks
+// if they represent a valid extension. It limits the length of new chains it
+// will attempt to validate and caches invalid blocks it has encountered to
+// help prevent DOS.  It also limits the number of chains each peer may have
+// collected at once.
+//
+// Chains are collected from the network concurrently and without holding
+// the syncer's lock, so a slow peer only delays the chains it sent.  The
+// lock is taken once the chain is collected to validate it and update the
+// store.
+func (syncer *DefaultSyncer) HandleNewBlocksFromPeer(ctx context.Context, from peer.ID, blkCids []cid.Cid) error {
 	// If the store already has all these blocks the syncer is finished.
 	if syncer.chainStore.HasAllBlocks(ctx, blkCids) {
 		return nil
 	}
 
+	if from != "" && syncer.badPeers.Score(from) >= maxBadChainsPerPeer {
+		return ErrPeerSentBadChains
+	}
+
+	cs, err := syncer.beginFetch(from, blkCids)
+	if err != nil {
+		return err
+	}
+
 	// Walk the chain given by the input blocks back to a known tipset in
 	// the store. This is the only code that may go to the network to
 	// resolve cids to blocks.
-	chain, parent, err := syncer.collectChain(ctx, blkCids)
+	chain, parent, err := syncer.collectChain(ctx, from, blkCids)
+	syncer.endFetch(from)
 	if err != nil {
+		syncer.finish(cs, err)
+		return err
+	}
+
+	syncer.mu.Lock()
+	defer syncer.mu.Unlock()
+	if len(chain) > 0 {
+		targetHeight, err := chain[len(chain)-1].Height()
+		if err != nil {
+			syncer.finish(cs, err)
+			return err
+		}
+		syncer.setValidating(cs, targetHeight)
+	}
+	err = syncer.syncChain(ctx, from, parent, chain)
+	syncer.finish(cs, err)
+	return err
+}
+
+// syncChain adds the tipsets of the collected chain to the store, checking
+// for new heaviest tipsets.  Tipsets that were added to the store by another
+// sync while this chain was being collected are skipped.  Chains violating
+// the syncer's finality policy are refused before any state is computed.
+// A tipset of the chain that is invalid under consensus is cached as bad,
+// with the tipsets after it, with peer from, who sent the chain, as their
+// source.
+//
+// Precondition: the caller of syncChain must hold the syncer's lock (syncer.mu).
+func (syncer *DefaultSyncer) syncChain(ctx context.Context, from peer.ID, parent types.TipSet, chain []types.TipSet) error {
+	if err := checkFinality(ctx, syncer.chainStore, syncer.finality(), parent, chain); err != nil {
 		return err
 	}
 
-	// Try adding the tipsets of the chain to the store, checking for new
-	// heaviest tipsets.
 	for i, ts := range chain {
+		if syncer.chainStore.HasTipSetAndState(ctx, ts.String()) {
+			parent = ts
+			continue
+		}
 		// TODO: this "i==0" leaks EC specifics into syncer abstraction
 		// for the sake of efficiency, consider plugging up this leak.
 		if i == 0 {
@@ -354,16 +443,143 @@ func (syncer *DefaultSyncer) HandleNewBlocks(ctx context.Context, blkCids []cid.
 			}
 			if wts != nil {
 				logSyncer.Debug("attempt to sync after widen")
+				// The widened tipset is assembled by this node, so it
+				// is not cached as bad if it fails.
 				err = syncer.syncOne(ctx, parent, wts)
 				if err != nil {
 					return err
 				}
 			}
 		}
-		if err = syncer.syncOne(ctx, parent, ts); err != nil {
+		if err := syncer.syncOne(ctx, parent, ts); err != nil {
+			if isInvalidTipSet(ctx, err) {
+				syncer.badTipSets.Add(ts.String(), err.Error(), from)
+				syncer.badTipSets.AddChain(chain[i+1:], ts.String(), from)
+				syncer.badPeers.RecordBadChain(from)
+			}
+			return err
+		}
+		h, err := ts.Height()
+		if err != nil {
 			return err
 		}
+		syncer.setCurrentHeight(h)
 		parent = ts
 	}
 	return nil
 }
+
+// isInvalidTipSet returns true if err, returned by syncOne, shows the tipset
+// is invalid under consensus.  Faults and errors after ctx is done say
+// nothing about the tipset, which must not be cached as bad for them: the
+// cache is persisted, so it would stay banned.
+func isInvalidTipSet(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || vmerrors.IsFault(err) {
+		return false
+	}
+	return consensus.IsInvalidTipSet(err)
+}
+
+// beginFetch records that a chain is being collected on behalf of the
+// given peer and returns the status of its sync.  It errors if the peer
+// already has too many chains in flight.
+func (syncer *DefaultSyncer) beginFetch(from peer.ID, blkCids []cid.Cid) (*ChainSync, error) {
+	syncer.fetchMu.Lock()
+	defer syncer.fetchMu.Unlock()
+	if from != "" && syncer.inFlight[from] >= maxInFlightPerPeer {
+		return nil, ErrTooManyInFlight
+	}
+	syncer.inFlight[from]++
+	cs := &ChainSync{
+		Peer:   from.Pretty(),
+		Stage:  SyncStageFetching,
+		Target: types.NewSortedCidSet(blkCids...).String(),
+	}
+	syncer.syncs = append(syncer.syncs, cs)
+	return cs, nil
+}
+
+// endFetch records that collection of a chain for the given peer finished.
+func (syncer *DefaultSyncer) endFetch(from peer.ID) {
+	syncer.fetchMu.Lock()
+	defer syncer.fetchMu.Unlock()
+	syncer.inFlight[from]--
+	if syncer.inFlight[from] <= 0 {
+		delete(syncer.inFlight, from)
+	}
+}
+
+func (syncer *DefaultSyncer) setValidating(cs *ChainSync, targetHeight uint64) {
+	syncer.fetchMu.Lock()
+	defer syncer.fetchMu.Unlock()
+	cs.Stage = SyncStageValidating
+	cs.TargetHeight = targetHeight
+}
+
+func (syncer *DefaultSyncer) setCurrentHeight(h uint64) {
+	syncer.fetchMu.Lock()
+	defer syncer.fetchMu.Unlock()
+	syncer.currentHeight = h
+}
+
+// finish records the outcome of the sync cs, failed if err is set.
+func (syncer *DefaultSyncer) finish(cs *ChainSync, err error) {
+	syncer.fetchMu.Lock()
+	defer syncer.fetchMu.Unlock()
+	if err != nil {
+		cs.Stage = SyncStageFailed
+		cs.Err = err.Error()
+	} else {
+		cs.Stage = SyncStageComplete
+	}
+	for i, other := range syncer.syncs {
+		if other == cs {
+			syncer.syncs = append(syncer.syncs[:i], syncer.syncs[i+1:]...)
+			break
+		}
+	}
+	syncer.lastSync = *cs
+}
+
+// Status returns a snapshot of the syncer's progress.  Its stage and target
+// are those of the most recently started sync still in progress, or of the
+// last finished sync when none is.
+func (syncer *DefaultSyncer) Status() SyncStatus {
+	syncer.fetchMu.Lock()
+	defer syncer.fetchMu.Unlock()
+
+	latest := syncer.lastSync
+	if len(syncer.syncs) > 0 {
+		latest = *syncer.syncs[len(syncer.syncs)-1]
+	}
+	status := SyncStatus{
+		Stage:         latest.Stage,
+		Target:        latest.Target,
+		TargetHeight:  latest.TargetHeight,
+		CurrentHeight: syncer.currentHeight,
+		Err:           latest.Err,
+	}
+	for _, cs := range syncer.syncs {
+		if cs.Stage == SyncStageFetching {
+			status.InFlight++
+		}
+		status.Syncs = append(status.Syncs, *cs)
+	}
+	return status
+}
+
+// BadTipSets returns the tipsets the syncer has recorded as invalid.
+func (syncer *DefaultSyncer) BadTipSets() []BadTipSet {
+	return syncer.badTipSets.List()
+}
+
+// RemoveBadTipSet removes a tipset from the syncer's bad tipset cache so
+// that it will be validated again the next time it is received.
+func (syncer *DefaultSyncer) RemoveBadTipSet(tsKey string) error {
+	return syncer.badTipSets.Remove(tsKey)
+}
+
+// PeerScores returns the number of invalid chains each peer has sent.
+func (syncer *DefaultSyncer) PeerScores() map[peer.ID]int {
+	return syncer.badPeers.All()
+}
@@ -3,11 +3,14 @@ package chain_test
 import (
 	"context"
 	"github.com/filecoin-project/go-filecoin/chain"
+	"sync"
 	"testing"
 
 	"gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 	bstore "gx/ipfs/QmRu7tiRnFk9mMPpVECQTBQJqXtmG132jJxA1w9A7TtpBz/go-ipfs-blockstore"
+	"gx/ipfs/QmTu65MVbemtUxJEWgsTtzv9Zv9P8rvmqNA4eG9TrTRGYc/go-libp2p-peer"
+	block "gx/ipfs/QmWoXtvgC8inqFkAATB7cp2Dax7XBi9VDvSg9RCCZufmRk/go-block-format"
 
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/address"
@@ -178,6 +181,22 @@ func initSyncTestDefault(require *require.Assertions) (chain.Syncer, chain.Store
 	return initSyncTest(require, con, consensus.InitGenesis, cst, bs, r)
 }
 
+// initSyncTestWithFinality is initSyncTestDefault with a syncer that
+// enforces the given finality policy.
+func initSyncTestWithFinality(require *require.Assertions, policy chain.FinalityPolicy) (chain.Syncer, chain.Store, *hamt.CborIpldStore) {
+	processor := testhelpers.NewTestProcessor()
+	powerTable := &testhelpers.TestView{}
+	r := repo.NewInMemoryRepo()
+	bs := bstore.NewBlockstore(r.Datastore())
+	cst := hamt.NewCborStore()
+	verifier := proofs.NewFakeVerifier(true, nil)
+	con := consensus.NewExpected(cst, bs, processor, powerTable, genCid, verifier)
+	requireSetTestChain(require, con, false)
+	_, chainStore, cst, r := initSyncTest(require, con, consensus.InitGenesis, cst, bs, r)
+	syncer := chain.NewDefaultSyncer(cst, cst, con, chainStore, r.ChainDatastore(), func() chain.FinalityPolicy { return policy })
+	return syncer, chainStore, cst
+}
+
 // initSyncTestWithPowerTable creates and returns the datastructures (chain store, syncer, etc)
 // needed to run tests.  It also sets the global test variables appropriately.
 func initSyncTestWithPowerTable(require *require.Assertions, powerTable consensus.PowerTableView) (chain.Syncer, chain.Store, *hamt.CborIpldStore, consensus.Protocol) {
@@ -203,7 +222,7 @@ func initSyncTest(require *require.Assertions, con consensus.Protocol, genFunc f
 	chainDS := r.ChainDatastore()
 	chainStore := chain.NewDefaultStore(chainDS, cst, calcGenBlk.Cid())
 
-	syncer := chain.NewDefaultSyncer(cst, cst, con, chainStore) // note we use same cst for on and offline for tests
+	syncer := chain.NewDefaultSyncer(cst, cst, con, chainStore, chainDS, nil) // note we use same cst for on and offline for tests
 
 	// Initialize stores to contain genesis block and state
 	calcGenTS := testhelpers.RequireNewTipSet(require, calcGenBlk)
@@ -412,6 +431,132 @@ func TestSyncChainHead(t *testing.T) {
 	assertHead(assert, chainStore, link4)
 }
 
+// Syncer syncs chains received from peers and reports its progress.
+func TestSyncChainHeadFromPeerStatus(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	syncer, chainStore, cst, _ := initSyncTestDefault(require)
+	ctx := context.Background()
+
+	assert.Equal(chain.SyncStageIdle, syncer.Status().Stage)
+
+	_ = requirePutBlocks(require, cst, link1.ToSlice()...)
+	_ = requirePutBlocks(require, cst, link2.ToSlice()...)
+	_ = requirePutBlocks(require, cst, link3.ToSlice()...)
+	cids4 := requirePutBlocks(require, cst, link4.ToSlice()...)
+
+	err := syncer.HandleNewBlocksFromPeer(ctx, peer.ID("peer"), cids4)
+	assert.NoError(err)
+	assertTsAdded(assert, chainStore, link4)
+	assertHead(assert, chainStore, link4)
+
+	h, err := link4.Height()
+	require.NoError(err)
+	status := syncer.Status()
+	assert.Equal(chain.SyncStageComplete, status.Stage)
+	assert.Equal(link4.String(), status.Target)
+	assert.Equal(h, status.TargetHeight)
+	assert.Equal(h, status.CurrentHeight)
+	assert.Equal(0, status.InFlight)
+}
+
+// Syncer reports failed syncs in its status.
+func TestSyncStatusFailed(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	syncer, _, cst, _ := initSyncTestDefault(require)
+	ctx := context.Background()
+
+	_ = requirePutBlocks(require, cst, link1.ToSlice()...)
+	_ = requirePutBlocks(require, cst, link2.ToSlice()...)
+	badCids := []cid.Cid{link1blk1.Cid(), link2blk1.Cid()}
+	err := syncer.HandleNewBlocksFromPeer(ctx, peer.ID("peer"), badCids)
+	assert.Error(err)
+
+	status := syncer.Status()
+	assert.Equal(chain.SyncStageFailed, status.Stage)
+	assert.Equal(err.Error(), status.Err)
+	assert.Equal(0, status.InFlight)
+}
+
+// gatedBlocks serves blocks for a cbor store, holding every request until
+// release is closed.  It stands in for a slow network.
+type gatedBlocks struct {
+	blocks interface {
+		GetBlock(context.Context, cid.Cid) (block.Block, error)
+		AddBlock(block.Block) error
+	}
+	requested     chan struct{}
+	requestedOnce sync.Once
+	release       chan struct{}
+}
+
+func (gb *gatedBlocks) GetBlock(ctx context.Context, c cid.Cid) (block.Block, error) {
+	gb.requestedOnce.Do(func() { close(gb.requested) })
+	select {
+	case <-gb.release:
+		return gb.blocks.GetBlock(ctx, c)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (gb *gatedBlocks) AddBlock(b block.Block) error {
+	return gb.blocks.AddBlock(b)
+}
+
+// Syncer keeps the status of each sync while the fetches of two peers
+// overlap.
+func TestSyncStatusOverlappingFetches(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	_, chainStore, cst, con := initSyncTestWithPowerTable(require, &testhelpers.TestView{})
+	ctx := context.Background()
+
+	// The blocks of link4 are only on the slow network.
+	network := hamt.NewCborStore()
+	gate := &gatedBlocks{blocks: network.Blocks, requested: make(chan struct{}), release: make(chan struct{})}
+	online := &hamt.CborIpldStore{Blocks: gate}
+	syncer := chain.NewDefaultSyncer(online, cst, con, chainStore, repo.NewInMemoryRepo().ChainDatastore(), nil)
+
+	_ = requirePutBlocks(require, cst, link1.ToSlice()...)
+	cids2 := requirePutBlocks(require, cst, link2.ToSlice()...)
+	_ = requirePutBlocks(require, cst, link3.ToSlice()...)
+	cids4 := requirePutBlocks(require, network, link4.ToSlice()...)
+
+	slowDone := make(chan error)
+	go func() {
+		slowDone <- syncer.HandleNewBlocksFromPeer(ctx, peer.ID("slow"), cids4)
+	}()
+	<-gate.requested
+
+	// The fast peer's chain is synced while the slow peer's is fetched.
+	require.NoError(syncer.HandleNewBlocksFromPeer(ctx, peer.ID("fast"), cids2))
+	assertHead(assert, chainStore, link2)
+
+	status := syncer.Status()
+	assert.Equal(chain.SyncStageFetching, status.Stage)
+	assert.Equal(link4.String(), status.Target)
+	assert.Equal(1, status.InFlight)
+	require.Len(status.Syncs, 1)
+	assert.Equal(peer.ID("slow").Pretty(), status.Syncs[0].Peer)
+	assert.Equal(chain.SyncStageFetching, status.Syncs[0].Stage)
+	assert.Equal(link4.String(), status.Syncs[0].Target)
+
+	close(gate.release)
+	require.NoError(<-slowDone)
+	assertHead(assert, chainStore, link4)
+
+	h, err := link4.Height()
+	require.NoError(err)
+	status = syncer.Status()
+	assert.Equal(chain.SyncStageComplete, status.Stage)
+	assert.Equal(link4.String(), status.Target)
+	assert.Equal(h, status.TargetHeight)
+	assert.Equal(h, status.CurrentHeight)
+	assert.Empty(status.Syncs)
+}
+
 // Syncer determines the heavier fork.
 func TestSyncIgnoreLightFork(t *testing.T) {
 	assert := assert.New(t)
@@ -443,13 +588,10 @@ func TestSyncIgnoreLightFork(t *testing.T) {
 	assertHead(assert, chainStore, link4)
 }
 
-// Correctly sync a heavier fork
-func TestHeavierFork(t *testing.T) {
-	assert := assert.New(t)
-	require := require.New(t)
-	syncer, chainStore, cst, _ := initSyncTestDefault(require)
-	ctx := context.Background()
-
+// requireMkHeavierFork returns a three tipset fork of the test chain
+// that is heavier than link4.  The fork splits off after link1, its first
+// tipset builds on link2blk1 alone.
+func requireMkHeavierFork(require *require.Assertions) (types.TipSet, types.TipSet, types.TipSet) {
 	forkbase := testhelpers.RequireNewTipSet(require, link2blk1)
 	forklink1blk1 := chain.RequireMkFakeChild(require,
 		chain.FakeChildParams{Parent: forkbase, GenesisCid: genCid, StateRoot: genStateRoot})
@@ -474,6 +616,18 @@ func TestHeavierFork(t *testing.T) {
 		chain.FakeChildParams{Parent: forklink2, GenesisCid: genCid, StateRoot: genStateRoot, Nonce: uint64(1)})
 	forklink3 := testhelpers.RequireNewTipSet(require, forklink3blk1, forklink3blk2)
 
+	return forklink1, forklink2, forklink3
+}
+
+// Correctly sync a heavier fork
+func TestHeavierFork(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	syncer, chainStore, cst, _ := initSyncTestDefault(require)
+	ctx := context.Background()
+
+	forklink1, forklink2, forklink3 := requireMkHeavierFork(require)
+
 	_ = requirePutBlocks(require, cst, link1.ToSlice()...)
 	_ = requirePutBlocks(require, cst, link2.ToSlice()...)
 	_ = requirePutBlocks(require, cst, link3.ToSlice()...)
@@ -511,6 +665,162 @@ func TestBlocksNotATipSet(t *testing.T) {
 	assertNoAdd(assert, chainStore, badCids)
 }
 
+// Syncer persists bad tipsets and scores the peers that send them.
+func TestBadTipSetsPersistAndScorePeers(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	syncer, _, cst, r := initSyncTestDefault(require)
+	ctx := context.Background()
+
+	_ = requirePutBlocks(require, cst, link1.ToSlice()...)
+	_ = requirePutBlocks(require, cst, link2.ToSlice()...)
+	badCids := []cid.Cid{link1blk1.Cid(), link2blk1.Cid()}
+	badKey := types.NewSortedCidSet(badCids...).String()
+	badPeer := peer.ID("bad")
+
+	err := syncer.HandleNewBlocksFromPeer(ctx, badPeer, badCids)
+	assert.Error(err)
+	assert.Equal(1, syncer.PeerScores()[badPeer])
+
+	bad := syncer.BadTipSets()
+	require.Equal(1, len(bad))
+	assert.Equal(badKey, bad[0].TipSetKey)
+	assert.Equal(badPeer, bad[0].Source)
+	assert.Equal(err.Error(), bad[0].Reason)
+
+	// Known bad chains are rejected without fetching and still count
+	// against the peer.
+	for i := 1; i < 5; i++ {
+		err = syncer.HandleNewBlocksFromPeer(ctx, badPeer, badCids)
+		assert.Equal(chain.ErrChainHasBadTipSet, err)
+	}
+	assert.Equal(5, syncer.PeerScores()[badPeer])
+	cids1 := requirePutBlocks(require, cst, link1.ToSlice()...)
+	assert.Equal(chain.ErrPeerSentBadChains, syncer.HandleNewBlocksFromPeer(ctx, badPeer, cids1))
+
+	// The bad tipset survives a restart.
+	loadSyncer, _ := loadSyncerFromRepo(require, r)
+	require.Equal(1, len(loadSyncer.BadTipSets()))
+	assert.Equal(chain.ErrChainHasBadTipSet, loadSyncer.HandleNewBlocks(ctx, badCids))
+
+	require.NoError(loadSyncer.RemoveBadTipSet(badKey))
+	assert.Equal(0, len(loadSyncer.BadTipSets()))
+}
+
+// Syncer caches tipsets failing their state transition, and the tipsets
+// after them, as bad.
+func TestBadStateTransitionCachesBadTipSets(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	syncer, chainStore, cst, _ := initSyncTestDefault(require)
+	ctx := context.Background()
+
+	// The state root of badblk is not the one computed from link1's.
+	badblk := chain.RequireMkFakeChild(require,
+		chain.FakeChildParams{Parent: link1, GenesisCid: genCid, StateRoot: genCid})
+	badlink := testhelpers.RequireNewTipSet(require, badblk)
+	childblk := chain.RequireMkFakeChild(require,
+		chain.FakeChildParams{Parent: badlink, GenesisCid: genCid, StateRoot: genStateRoot})
+	childlink := testhelpers.RequireNewTipSet(require, childblk)
+
+	_ = requirePutBlocks(require, cst, link1.ToSlice()...)
+	_ = requirePutBlocks(require, cst, badlink.ToSlice()...)
+	childCids := requirePutBlocks(require, cst, childlink.ToSlice()...)
+	badPeer := peer.ID("bad")
+
+	err := syncer.HandleNewBlocksFromPeer(ctx, badPeer, childCids)
+	assert.True(consensus.IsInvalidTipSet(err))
+	assert.Equal(consensus.ErrStateRootMismatch.Error(), err.Error())
+	assertTsAdded(assert, chainStore, link1)
+	assertNoAdd(assert, chainStore, childCids)
+	assert.Equal(1, syncer.PeerScores()[badPeer])
+
+	bad := make(map[string]chain.BadTipSet)
+	for _, bts := range syncer.BadTipSets() {
+		bad[bts.TipSetKey] = bts
+	}
+	require.Equal(2, len(bad))
+	assert.Equal(err.Error(), bad[badlink.String()].Reason)
+	assert.Equal(badPeer, bad[badlink.String()].Source)
+	assert.Equal("descends from bad tipset "+badlink.String(), bad[childlink.String()].Reason)
+	assert.Equal(badPeer, bad[childlink.String()].Source)
+
+	assert.Equal(chain.ErrChainHasBadTipSet, syncer.HandleNewBlocksFromPeer(ctx, badPeer, childCids))
+}
+
+// Syncer refuses forks deeper than the maximum reorg depth.
+func TestSyncRefusesDeepReorg(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	// The fork's last common tipset with link4 is link1, 5 blocks below it.
+	syncer, chainStore, cst := initSyncTestWithFinality(require, chain.FinalityPolicy{MaxReorgDepth: 4})
+	forklink1, forklink2, forklink3 := requireMkHeavierFork(require)
+
+	_ = requirePutBlocks(require, cst, link1.ToSlice()...)
+	_ = requirePutBlocks(require, cst, link2.ToSlice()...)
+	_ = requirePutBlocks(require, cst, link3.ToSlice()...)
+	cids4 := requirePutBlocks(require, cst, link4.ToSlice()...)
+	_ = requirePutBlocks(require, cst, forklink1.ToSlice()...)
+	_ = requirePutBlocks(require, cst, forklink2.ToSlice()...)
+	forkHead := requirePutBlocks(require, cst, forklink3.ToSlice()...)
+
+	require.NoError(syncer.HandleNewBlocks(ctx, cids4))
+	assertHead(assert, chainStore, link4)
+
+	assert.Equal(chain.ErrReorgTooDeep, syncer.HandleNewBlocks(ctx, forkHead))
+	assertNoAdd(assert, chainStore, forkHead)
+	assertHead(assert, chainStore, link4)
+
+	// The same fork is accepted when it is within the limit.  Setting up
+	// the test again resets the test chain so the fork is made again.
+	syncer, chainStore, cst = initSyncTestWithFinality(require, chain.FinalityPolicy{MaxReorgDepth: 5})
+	forklink1, forklink2, forklink3 = requireMkHeavierFork(require)
+
+	_ = requirePutBlocks(require, cst, link1.ToSlice()...)
+	_ = requirePutBlocks(require, cst, link2.ToSlice()...)
+	_ = requirePutBlocks(require, cst, link3.ToSlice()...)
+	cids4 = requirePutBlocks(require, cst, link4.ToSlice()...)
+	_ = requirePutBlocks(require, cst, forklink1.ToSlice()...)
+	_ = requirePutBlocks(require, cst, forklink2.ToSlice()...)
+	forkHead = requirePutBlocks(require, cst, forklink3.ToSlice()...)
+
+	require.NoError(syncer.HandleNewBlocks(ctx, cids4))
+	assert.NoError(syncer.HandleNewBlocks(ctx, forkHead))
+	assertHead(assert, chainStore, forklink3)
+}
+
+// Syncer refuses forks that do not include the checkpoint.
+func TestSyncRefusesForkCrossingCheckpoint(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	h, err := link3.Height()
+	require.NoError(err)
+	syncer, chainStore, cst := initSyncTestWithFinality(require, chain.FinalityPolicy{
+		Checkpoint:       link3.ToSortedCidSet(),
+		CheckpointHeight: h,
+	})
+	forklink1, forklink2, forklink3 := requireMkHeavierFork(require)
+
+	_ = requirePutBlocks(require, cst, link1.ToSlice()...)
+	_ = requirePutBlocks(require, cst, link2.ToSlice()...)
+	_ = requirePutBlocks(require, cst, link3.ToSlice()...)
+	cids4 := requirePutBlocks(require, cst, link4.ToSlice()...)
+	_ = requirePutBlocks(require, cst, forklink1.ToSlice()...)
+	_ = requirePutBlocks(require, cst, forklink2.ToSlice()...)
+	forkHead := requirePutBlocks(require, cst, forklink3.ToSlice()...)
+
+	require.NoError(syncer.HandleNewBlocks(ctx, cids4))
+	assertHead(assert, chainStore, link4)
+
+	assert.Equal(chain.ErrForkCrossesCheckpoint, syncer.HandleNewBlocks(ctx, forkHead))
+	assertNoAdd(assert, chainStore, forkHead)
+	assertHead(assert, chainStore, link4)
+}
+
 /* particularly tricky edge cases relating to subtle Expected Consensus requirements */
 
 // Syncer is capable of recovering from a fork reorg after Load.
@@ -809,7 +1119,7 @@ func TestTipSetWeightDeep(t *testing.T) {
 	// Now sync the chainStore with consensus using a MarketView.
 	verifier = proofs.NewFakeVerifier(true, nil)
 	con = consensus.NewExpected(cst, bs, testhelpers.NewTestProcessor(), &consensus.MarketView{}, calcGenBlk.Cid(), verifier)
-	syncer := chain.NewDefaultSyncer(cst, cst, con, chainStore)
+	syncer := chain.NewDefaultSyncer(cst, cst, con, chainStore, r.ChainDatastore(), nil)
 	baseTS := chainStore.Head() // this is the last block of the bootstrapping chain creating miners
 	require.Equal(1, len(baseTS))
 	bootstrapStateRoot := baseTS.ToSlice()[0].StateRoot
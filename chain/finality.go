@@ -0,0 +1,150 @@
+package chain
+
+import (
+	"context"
+
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+var (
+	// ErrReorgTooDeep is returned when a new chain forks from the head further back than the finality policy allows.
+	ErrReorgTooDeep = errors.New("input chain forks from the head deeper than the maximum reorg depth")
+	// ErrForkCrossesCheckpoint is returned when a new chain does not include the configured checkpoint.
+	ErrForkCrossesCheckpoint = errors.New("input chain forks from the chain before the checkpoint")
+)
+
+// FinalityPolicy limits the forks the syncer will switch to.  The zero value
+// places no limits.
+type FinalityPolicy struct {
+	// MaxReorgDepth is the maximum number of blocks below the head at
+	// which a new chain may fork.  Zero disables the limit.
+	MaxReorgDepth uint64
+	// Checkpoint is the key of a tipset every chain must include.  An
+	// empty set disables the checkpoint.
+	Checkpoint types.SortedCidSet
+	// CheckpointHeight is the height of the Checkpoint tipset.
+	CheckpointHeight uint64
+}
+
+// checkFinality errors if syncing chain on top of parent would violate the
+// policy.  chain is ordered from oldest to newest, and parent is the tipset
+// in the store that chain[0] extends.
+func checkFinality(ctx context.Context, store Store, policy FinalityPolicy, parent types.TipSet, chain []types.TipSet) error {
+	if len(chain) == 0 {
+		return nil
+	}
+	parentHeight, err := parent.Height()
+	if err != nil {
+		return err
+	}
+	headHeight, err := store.Head().Height()
+	if err != nil {
+		return err
+	}
+
+	if !policy.Checkpoint.Empty() {
+		if err := checkCheckpoint(policy, parent, chain); err != nil {
+			return err
+		}
+		// A fork made entirely of tipsets below the checkpoint can only
+		// replace the checkpoint once the head is past it.
+		if parentHeight < policy.CheckpointHeight && headHeight >= policy.CheckpointHeight {
+			return ErrForkCrossesCheckpoint
+		}
+	}
+
+	if policy.MaxReorgDepth > 0 && headHeight > parentHeight+policy.MaxReorgDepth {
+		ancestorHeight, err := commonAncestorHeight(ctx, store, parent, store.Head(), headHeight-policy.MaxReorgDepth)
+		if err != nil {
+			return err
+		}
+		if headHeight-ancestorHeight > policy.MaxReorgDepth {
+			return ErrReorgTooDeep
+		}
+	}
+	return nil
+}
+
+// checkCheckpoint errors if the chain holds a tipset at the checkpoint
+// height other than the checkpoint, or skips over the checkpoint height
+// with null blocks.
+func checkCheckpoint(policy FinalityPolicy, parent types.TipSet, chain []types.TipSet) error {
+	prevHeight, err := parent.Height()
+	if err != nil {
+		return err
+	}
+	cpKey := policy.Checkpoint.String()
+	for _, ts := range chain {
+		h, err := ts.Height()
+		if err != nil {
+			return err
+		}
+		if h == policy.CheckpointHeight && ts.String() != cpKey {
+			return ErrForkCrossesCheckpoint
+		}
+		if prevHeight < policy.CheckpointHeight && h > policy.CheckpointHeight {
+			return ErrForkCrossesCheckpoint
+		}
+		prevHeight = h
+	}
+	return nil
+}
+
+// commonAncestorHeight walks back from the tipsets a and b in the store until
+// it finds a tipset both chains contain, and returns its height.  It stops
+// walking once both chains are below minHeight and returns the current
+// height of the walk, which callers can treat as an upper bound.
+func commonAncestorHeight(ctx context.Context, store Store, a, b types.TipSet, minHeight uint64) (uint64, error) {
+	aHeight, err := a.Height()
+	if err != nil {
+		return 0, err
+	}
+	bHeight, err := b.Height()
+	if err != nil {
+		return 0, err
+	}
+	for !a.Equals(b) {
+		if aHeight < minHeight && bHeight < minHeight {
+			break
+		}
+		// Step back the higher of the two tipsets, or both if they
+		// are at the same height.
+		if aHeight >= bHeight {
+			if a, err = parentTipSet(ctx, store, a); err != nil {
+				return 0, err
+			}
+			if aHeight, err = a.Height(); err != nil {
+				return 0, err
+			}
+		} else {
+			if b, err = parentTipSet(ctx, store, b); err != nil {
+				return 0, err
+			}
+			if bHeight, err = b.Height(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if aHeight < bHeight {
+		return aHeight, nil
+	}
+	return bHeight, nil
+}
+
+// parentTipSet returns the parent of ts from the store.
+func parentTipSet(ctx context.Context, store ReadStore, ts types.TipSet) (types.TipSet, error) {
+	parents, err := ts.Parents()
+	if err != nil {
+		return nil, err
+	}
+	if parents.Empty() {
+		return nil, errors.Wrap(ErrUnexpectedStoreState, "walked past genesis")
+	}
+	tsas, err := store.GetTipSetAndState(ctx, parents.String())
+	if err != nil {
+		return nil, err
+	}
+	return tsas.TipSet, nil
+}
@@ -0,0 +1,49 @@
+package chain
+
+import (
+	"sync"
+
+	"gx/ipfs/QmTu65MVbemtUxJEWgsTtzv9Zv9P8rvmqNA4eG9TrTRGYc/go-libp2p-peer"
+)
+
+// peerScores counts the invalid chains each peer has sent the syncer.  A
+// higher score is worse.  Readers and writers grab a lock.
+type peerScores struct {
+	mu     sync.Mutex
+	scores map[peer.ID]int
+}
+
+func newPeerScores() *peerScores {
+	return &peerScores{
+		scores: make(map[peer.ID]int),
+	}
+}
+
+// RecordBadChain increments the score of the given peer.  Chains with no
+// source peer are not scored.
+func (ps *peerScores) RecordBadChain(p peer.ID) {
+	if p == "" {
+		return
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.scores[p]++
+}
+
+// Score returns the number of bad chains the given peer has sent.
+func (ps *peerScores) Score(p peer.ID) int {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.scores[p]
+}
+
+// All returns a copy of the scores of all peers that have sent bad chains.
+func (ps *peerScores) All() map[peer.ID]int {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	out := make(map[peer.ID]int, len(ps.scores))
+	for p, s := range ps.scores {
+		out[p] = s
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package chain
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// ReorgPath returns the tipsets that moving the head of the chain in store
+// from oldHead to newHead drops and adds.  They are the tipsets of each chain
+// above the last tipset both contain: dropped is newest first, the order in
+// which to undo them, and added is oldest first, the order in which to apply
+// them.  If newHead extends oldHead nothing is dropped.
+func ReorgPath(ctx context.Context, store ReadStore, oldHead, newHead types.TipSet) (dropped, added []types.TipSet, err error) {
+	oldHeight, err := oldHead.Height()
+	if err != nil {
+		return nil, nil, err
+	}
+	newHeight, err := newHead.Height()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for !oldHead.Equals(newHead) {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		// Step back the higher of the two tipsets, or both if they are at
+		// the same height.
+		stepOld, stepNew := oldHeight >= newHeight, newHeight >= oldHeight
+		if stepOld {
+			dropped = append(dropped, oldHead)
+			if oldHead, err = parentTipSet(ctx, store, oldHead); err != nil {
+				return nil, nil, err
+			}
+			if oldHeight, err = oldHead.Height(); err != nil {
+				return nil, nil, err
+			}
+		}
+		if stepNew {
+			added = append(added, newHead)
+			if newHead, err = parentTipSet(ctx, store, newHead); err != nil {
+				return nil, nil, err
+			}
+			if newHeight, err = newHead.Height(); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	for i, j := 0, len(added)-1; i < j; i, j = i+1, j-1 {
+		added[i], added[j] = added[j], added[i]
+	}
+	return dropped, added, nil
+}
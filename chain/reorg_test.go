@@ -0,0 +1,61 @@
+package chain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/testhelpers"
+	"github.com/filecoin-project/go-filecoin/types"
+
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
+)
+
+func TestReorgPath(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	syncer, chainStore, cst, _ := initSyncTestDefault(require)
+	ctx := context.Background()
+
+	forklink1, forklink2, forklink3 := requireMkHeavierFork(require)
+
+	_ = requirePutBlocks(require, cst, link1.ToSlice()...)
+	_ = requirePutBlocks(require, cst, link2.ToSlice()...)
+	_ = requirePutBlocks(require, cst, link3.ToSlice()...)
+	cids4 := requirePutBlocks(require, cst, link4.ToSlice()...)
+	_ = requirePutBlocks(require, cst, forklink1.ToSlice()...)
+	_ = requirePutBlocks(require, cst, forklink2.ToSlice()...)
+	forkHead := requirePutBlocks(require, cst, forklink3.ToSlice()...)
+
+	require.NoError(syncer.HandleNewBlocks(ctx, cids4))
+	require.NoError(syncer.HandleNewBlocks(ctx, forkHead))
+
+	t.Run("extending the head drops nothing", func(t *testing.T) {
+		dropped, added, err := chain.ReorgPath(ctx, chainStore, link1, link4)
+		require.NoError(err)
+		assert.Empty(dropped)
+		assert.Equal([]types.TipSet{link2, link3, link4}, added)
+	})
+
+	t.Run("same head", func(t *testing.T) {
+		dropped, added, err := chain.ReorgPath(ctx, chainStore, link4, link4)
+		require.NoError(err)
+		assert.Empty(dropped)
+		assert.Empty(added)
+	})
+
+	t.Run("switching to a fork drops back to the common ancestor", func(t *testing.T) {
+		forkbase := testhelpers.RequireNewTipSet(require, link2blk1)
+
+		dropped, added, err := chain.ReorgPath(ctx, chainStore, link4, forklink3)
+		require.NoError(err)
+		assert.Equal([]types.TipSet{link4, link3, link2}, dropped)
+		assert.Equal([]types.TipSet{forkbase, forklink1, forklink2, forklink3}, added)
+
+		dropped, added, err = chain.ReorgPath(ctx, chainStore, forklink3, link4)
+		require.NoError(err)
+		assert.Equal([]types.TipSet{forklink3, forklink2, forklink1, forkbase}, dropped)
+		assert.Equal([]types.TipSet{link2, link3, link4}, added)
+	})
+}
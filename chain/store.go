@@ -39,6 +39,10 @@ type ReadStore interface {
 	Head() types.TipSet
 	// LatestState returns the latest state of the head
 	LatestState(ctx context.Context) (state.Tree, error)
+	// GetTipSetAndStateAt returns the tipset and state referenced by ref.
+	GetTipSetAndStateAt(ctx context.Context, ref TipSetRef) (*TipSetAndState, error)
+	// StateAt returns the state after the tipset referenced by ref.
+	StateAt(ctx context.Context, ref TipSetRef) (state.Tree, error)
 
 	BlockHistory(ctx context.Context, tips types.TipSet) <-chan interface{}
 	GenesisCid() cid.Cid
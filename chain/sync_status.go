@@ -0,0 +1,77 @@
+package chain
+
+// SyncStage describes which phase of syncing a chain the syncer is in.
+type SyncStage int
+
+const (
+	// SyncStageIdle indicates the syncer has not yet synced any chain.
+	SyncStageIdle = SyncStage(iota)
+	// SyncStageFetching indicates the syncer is resolving the blocks of a
+	// new chain, possibly over the network.
+	SyncStageFetching
+	// SyncStageValidating indicates the syncer is running state transitions
+	// on a fetched chain and adding it to the store.
+	SyncStageValidating
+	// SyncStageComplete indicates the last chain the syncer worked on was
+	// processed successfully.
+	SyncStageComplete
+	// SyncStageFailed indicates the last chain the syncer worked on could
+	// not be synced.
+	SyncStageFailed
+)
+
+// String returns a human readable name for the stage.
+func (s SyncStage) String() string {
+	switch s {
+	case SyncStageIdle:
+		return "idle"
+	case SyncStageFetching:
+		return "fetching"
+	case SyncStageValidating:
+		return "validating"
+	case SyncStageComplete:
+		return "complete"
+	case SyncStageFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// ChainSync is the progress of the sync of one chain.
+type ChainSync struct {
+	// Peer is the peer the chain came from, empty for chains from this
+	// node.
+	Peer string
+	// Stage is the phase the sync is in.
+	Stage SyncStage
+	// Target is the key of the tipset the sync is moving towards.
+	Target string
+	// TargetHeight is the height of Target, it is only known once the
+	// target's blocks have been fetched.
+	TargetHeight uint64
+	// Err holds the error message of the sync if it failed.
+	Err string
+}
+
+// SyncStatus is a snapshot of the syncer's progress towards the most
+// recently targeted chain head.
+type SyncStatus struct {
+	// Stage is the phase the most recent sync is in.
+	Stage SyncStage
+	// Target is the key of the tipset the most recent sync is moving
+	// towards.
+	Target string
+	// TargetHeight is the height of Target, it is only known once the
+	// target's blocks have been fetched.
+	TargetHeight uint64
+	// CurrentHeight is the height of the most recent tipset validated
+	// and added to the store.
+	CurrentHeight uint64
+	// InFlight is the number of chains currently being fetched.
+	InFlight int
+	// Err holds the error message of the most recent sync if it failed.
+	Err string
+	// Syncs are the syncs in progress, oldest first.
+	Syncs []ChainSync
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmTu65MVbemtUxJEWgsTtzv9Zv9P8rvmqNA4eG9TrTRGYc/go-libp2p-peer"
 )
 
 // Syncer handles new blocks, either from the network or the local node's
@@ -18,5 +19,19 @@ import (
 // example a syncer might decide to cut off traversal of an unknown fork
 // after too many blocks.
 type Syncer interface {
+	// HandleNewBlocks syncs the chain headed by the input blocks.  It is
+	// used for blocks that do not come from a known peer, for example
+	// blocks mined by this node.
 	HandleNewBlocks(ctx context.Context, blkCids []cid.Cid) error
+	// HandleNewBlocksFromPeer syncs the chain headed by the input blocks,
+	// attributing the work of fetching the chain to the given peer.
+	HandleNewBlocksFromPeer(ctx context.Context, from peer.ID, blkCids []cid.Cid) error
+	// Status returns a snapshot of the syncer's progress.
+	Status() SyncStatus
+	// BadTipSets returns the tipsets the syncer has recorded as invalid.
+	BadTipSets() []BadTipSet
+	// RemoveBadTipSet forgets that the tipset with the given key is invalid.
+	RemoveBadTipSet(tsKey string) error
+	// PeerScores returns the number of invalid chains each peer has sent.
+	PeerScores() map[peer.ID]int
 }
@@ -0,0 +1,69 @@
+package chain
+
+import (
+	"context"
+
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// TipSetRef identifies a tipset in the chain store either by its key or by
+// its height in the chain headed by the current head.  The zero value refers
+// to the head.
+type TipSetRef struct {
+	// Key is the key of the tipset, it is ignored if empty.
+	Key types.SortedCidSet
+	// Height is the height of the tipset, it is ignored if nil.  If the
+	// chain has no tipset at this height because of null blocks, the
+	// closest tipset below it is used.
+	Height *uint64
+}
+
+// IsHead is true if the ref refers to the head.
+func (ref TipSetRef) IsHead() bool {
+	return ref.Key.Empty() && ref.Height == nil
+}
+
+// getTipSetAndStateAt resolves ref to a tipset and its state in store.
+func getTipSetAndStateAt(ctx context.Context, store ReadStore, ref TipSetRef) (*TipSetAndState, error) {
+	if !ref.Key.Empty() && ref.Height != nil {
+		return nil, errors.New("a tipset may be referenced by key or by height but not both")
+	}
+	if !ref.Key.Empty() {
+		return store.GetTipSetAndState(ctx, ref.Key.String())
+	}
+
+	head := store.Head()
+	if head == nil {
+		return nil, errors.New("Unset head")
+	}
+	tsas, err := store.GetTipSetAndState(ctx, head.String())
+	if err != nil {
+		return nil, err
+	}
+	if ref.Height == nil {
+		return tsas, nil
+	}
+
+	h, err := head.Height()
+	if err != nil {
+		return nil, err
+	}
+	if *ref.Height > h {
+		return nil, errors.Errorf("height %d is above the head at height %d", *ref.Height, h)
+	}
+	for h > *ref.Height {
+		ts, err := parentTipSet(ctx, store, tsas.TipSet)
+		if err != nil {
+			return nil, err
+		}
+		if tsas, err = store.GetTipSetAndState(ctx, ts.String()); err != nil {
+			return nil, err
+		}
+		if h, err = ts.Height(); err != nil {
+			return nil, err
+		}
+	}
+	return tsas, nil
+}
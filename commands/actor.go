@@ -4,9 +4,13 @@ import (
 	"encoding/json"
 	"io"
 
+	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/api"
+	"github.com/filecoin-project/go-filecoin/exec"
 
 	"gx/ipfs/QmQtQrtNioesAWtrx8csBvfY37gTe94d6wQ3VikZUjxD39/go-ipfs-cmds"
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 	"gx/ipfs/Qmde5VP1qUkyQXKCfmEUA7bP64V2HAptbJ7phuPp7jXWwg/go-ipfs-cmdkit"
 )
 
@@ -15,13 +19,69 @@ var actorCmd = &cmds.Command{
 		Tagline: "Interact with actors. Actors are built-in smart contracts.",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"ls": actorLsCmd,
+		"describe": actorDescribeCmd,
+		"ls":       actorLsCmd,
+	},
+}
+
+var actorDescribeCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Describe the methods of an actor as JSON schema",
+		ShortDescription: `
+Prints the method table of the actor at the given address, or of actors with
+the given code cid, as JSON. Each method has the JSON schema of the array of its
+parameters, as accepted by message send --params, and of its return values.
+The errors the actor's methods return are listed by exit code.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("actor", true, false, "Address or code cid of the actor to describe"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		var desc *exec.ActorDescription
+		if addr, err := address.NewFromString(req.Arguments[0]); err == nil {
+			desc, err = GetPorcelainAPI(env).ActorDescribe(req.Context, addr)
+			if err != nil {
+				return err
+			}
+		} else {
+			code, err := cid.Parse(req.Arguments[0])
+			if err != nil {
+				return errors.New("argument must be an actor address or code cid")
+			}
+			desc, err = GetPorcelainAPI(env).ActorDescribeCode(req.Context, code)
+			if err != nil {
+				return err
+			}
+		}
+
+		return re.Emit(desc)
+	},
+	Type: &exec.ActorDescription{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, desc *exec.ActorDescription) error {
+			marshaled, err := json.MarshalIndent(desc, "", "  ")
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(append(marshaled, '\n'))
+			return err
+		}),
 	},
 }
 
 var actorLsCmd = &cmds.Command{
+	Options: []cmdkit.Option{
+		tipsetOption,
+		heightOption,
+	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
-		actors, err := GetAPI(env).Actor().Ls(req.Context)
+		at, err := parseTipSetRefOptions(req)
+		if err != nil {
+			return err
+		}
+
+		actors, err := GetAPI(env).Actor().Ls(req.Context, at)
 		if err != nil {
 			return err
 		}
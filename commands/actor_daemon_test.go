@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/api"
+	"github.com/filecoin-project/go-filecoin/exec"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	"github.com/filecoin-project/go-filecoin/types"
 
 	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
 	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
@@ -48,4 +51,24 @@ func TestActorDaemon(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("actor describe prints the method table of an actor", func(t *testing.T) {
+		require := require.New(t)
+		assert := assert.New(t)
+
+		d := th.NewDaemon(t).Start()
+		defer d.ShutdownSuccess()
+
+		byAddr := d.RunSuccess("actor", "describe", address.PaymentBrokerAddress.String()).ReadStdout()
+		byCode := d.RunSuccess("actor", "describe", types.PaymentBrokerActorCodeCid.String()).ReadStdout()
+		assert.Equal(byAddr, byCode)
+
+		var desc exec.ActorDescription
+		require.NoError(json.Unmarshal([]byte(byAddr), &desc))
+		assert.Equal("PaymentBrokerActor", desc.Name)
+		assert.NotEmpty(desc.Methods)
+		assert.NotEmpty(desc.Errors)
+
+		d.RunFail("must be an actor address or code cid", "actor", "describe", "xyz")
+	})
 }
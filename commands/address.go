@@ -117,13 +117,22 @@ var balanceCmd = &cmds.Command{
 	Arguments: []cmdkit.Argument{
 		cmdkit.StringArg("address", true, false, "Address to get balance for"),
 	},
+	Options: []cmdkit.Option{
+		tipsetOption,
+		heightOption,
+	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		addr, err := address.NewFromString(req.Arguments[0])
 		if err != nil {
 			return err
 		}
 
-		balance, err := GetAPI(env).Address().Balance(req.Context, addr)
+		at, err := parseTipSetRefOptions(req)
+		if err != nil {
+			return err
+		}
+
+		balance, err := GetAPI(env).Address().Balance(req.Context, at, addr)
 		if err != nil {
 			return err
 		}
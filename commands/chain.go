@@ -2,15 +2,23 @@
 package commands
 
 import (
+	"encoding/hex"
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"gx/ipfs/QmQtQrtNioesAWtrx8csBvfY37gTe94d6wQ3VikZUjxD39/go-ipfs-cmds"
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 	"gx/ipfs/Qmde5VP1qUkyQXKCfmEUA7bP64V2HAptbJ7phuPp7jXWwg/go-ipfs-cmdkit"
 
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/plumbing/evt"
+	"github.com/filecoin-project/go-filecoin/plumbing/stdiff"
+	"github.com/filecoin-project/go-filecoin/porcelain"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
@@ -19,8 +27,14 @@ var chainCmd = &cmds.Command{
 		Tagline: "Inspect the filecoin blockchain",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"head": chainHeadCmd,
-		"ls":   chainLsCmd,
+		"bad":          chainBadCmd,
+		"checkpoint":   chainCheckpointCmd,
+		"events":       chainEventsCmd,
+		"head":         chainHeadCmd,
+		"ls":           chainLsCmd,
+		"state-diff":   chainStateDiffCmd,
+		"sync-status":  chainSyncStatusCmd,
+		"verify-state": chainVerifyStateCmd,
 	},
 }
 
@@ -102,3 +116,361 @@ var chainLsCmd = &cmds.Command{
 		}),
 	},
 }
+
+var chainEventsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Follow the events emitted by actors in new blocks",
+		ShortDescription: `Streams the events actors emit while processing the messages of each tipset that joins the chain, until interrupted.
+Events whose messages failed are not emitted. The data of an event is cbor encoded.
+When a reorg drops tipsets from the chain their events are streamed again marked as reverted.
+Events are only recorded from the network upgrade that enables them.`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("topic", "Only show events with this topic, e.g. paymentbroker/channel-created"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		topic, _ := req.Options["topic"].(string)
+		for raw := range GetPorcelainAPI(env).ChainEvents(req.Context, topic) {
+			switch v := raw.(type) {
+			case error:
+				return v
+			case *evt.ChainEvent:
+				if err := re.Emit(v); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unexpected type")
+			}
+		}
+		return nil
+	},
+	Type: evt.ChainEvent{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, e *evt.ChainEvent) error {
+			status := "applied"
+			if e.Reverted {
+				status = "reverted"
+			}
+			_, err := fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", status, e.Height, e.Block, e.Message, e.Emitter, e.Topic, hex.EncodeToString(e.Data))
+			return err
+		}),
+	},
+}
+
+var chainSyncStatusCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline:          "Show the progress of the chain syncer",
+		ShortDescription: `Shows the stage, target tipset and heights of the most recent chain sync, the number of chains being fetched, and each sync in progress.`,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		return re.Emit(GetPorcelainAPI(env).ChainSyncStatus())
+	},
+	Type: chain.SyncStatus{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, status *chain.SyncStatus) error {
+			fmt.Fprintf(w, "stage:          %s\n", status.Stage)         // nolint: errcheck
+			fmt.Fprintf(w, "target:         %s\n", status.Target)        // nolint: errcheck
+			fmt.Fprintf(w, "target height:  %d\n", status.TargetHeight)  // nolint: errcheck
+			fmt.Fprintf(w, "current height: %d\n", status.CurrentHeight) // nolint: errcheck
+			fmt.Fprintf(w, "in flight:      %d\n", status.InFlight)      // nolint: errcheck
+			if status.Err != "" {
+				fmt.Fprintf(w, "error:          %s\n", status.Err) // nolint: errcheck
+			}
+			for _, cs := range status.Syncs {
+				fmt.Fprintf(w, "syncing %s from peer %q: %s\n", cs.Target, cs.Peer, cs.Stage) // nolint: errcheck
+			}
+			return nil
+		}),
+	},
+}
+
+var chainBadCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Manage tipsets the node has found to be invalid",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"ls":    chainBadLsCmd,
+		"peers": chainBadPeersCmd,
+		"rm":    chainBadRmCmd,
+	},
+}
+
+var chainBadLsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline:          "List invalid tipsets",
+		ShortDescription: `Lists the tipsets the node has rejected, with the time each was first seen, the peer that sent it and the validation error.`,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		return re.Emit(GetPorcelainAPI(env).ChainBadTipSets())
+	},
+	Type: []chain.BadTipSet{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, bad *[]chain.BadTipSet) error {
+			for _, bts := range *bad {
+				source := bts.Source.Pretty()
+				if bts.Source == "" {
+					source = "local"
+				}
+				_, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", bts.TipSetKey, bts.FirstSeen.Format(time.RFC3339), source, bts.Reason)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
+var chainBadRmCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline:          "Remove a tipset from the invalid tipset list",
+		ShortDescription: `Removes the tipset made of the given block CIDs so it is validated again the next time it is received.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("cids", true, true, "The CIDs of the blocks in the tipset"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		ids := types.SortedCidSet{}
+		for _, arg := range req.Arguments {
+			c, err := cid.Decode(arg)
+			if err != nil {
+				return err
+			}
+			ids.Add(c)
+		}
+		return GetPorcelainAPI(env).ChainRemoveBadTipSet(ids)
+	},
+}
+
+// chainBadPeer is the output of chain bad peers for a single peer.
+type chainBadPeer struct {
+	Peer      string
+	BadChains int
+}
+
+var chainBadPeersCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline:          "List peers that have sent invalid chains",
+		ShortDescription: `Lists each peer that has sent invalid chains along with the number of invalid chains it sent.`,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		var out []chainBadPeer
+		for p, n := range GetPorcelainAPI(env).ChainBadPeers() {
+			out = append(out, chainBadPeer{Peer: p.Pretty(), BadChains: n})
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].BadChains > out[j].BadChains })
+		return re.Emit(out)
+	},
+	Type: []chainBadPeer{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, peers *[]chainBadPeer) error {
+			for _, p := range *peers {
+				if _, err := fmt.Fprintf(w, "%s\t%d\n", p.Peer, p.BadChains); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
+var chainCheckpointCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Manage the tipset the chain must always include",
+		ShortDescription: `
+A checkpoint is a tipset the node treats as final: the node refuses any fork
+that does not include it. The maximum depth of forks the node accepts is set
+separately by the chain.maxReorgDepth config value.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"get": chainCheckpointGetCmd,
+		"set": chainCheckpointSetCmd,
+	},
+}
+
+var chainCheckpointSetCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Set the checkpoint to the tipset made of the given block CIDs",
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("cids", true, true, "The CIDs of the blocks in the tipset"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		ids := types.SortedCidSet{}
+		for _, arg := range req.Arguments {
+			c, err := cid.Decode(arg)
+			if err != nil {
+				return err
+			}
+			ids.Add(c)
+		}
+		return GetPorcelainAPI(env).ChainCheckpointSet(req.Context, ids)
+	},
+}
+
+var chainCheckpointGetCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Get the checkpoint tipset and its height",
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		checkpoint, err := GetPorcelainAPI(env).ChainCheckpointGet()
+		if err != nil {
+			return err
+		}
+		return re.Emit(checkpoint)
+	},
+	Type: porcelain.ChainCheckpoint{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, checkpoint *porcelain.ChainCheckpoint) error {
+			if checkpoint.TipSet.Empty() {
+				_, err := fmt.Fprintln(w, "no checkpoint set")
+				return err
+			}
+			_, err := fmt.Fprintf(w, "%s\t%d\n", checkpoint.TipSet.String(), checkpoint.Height)
+			return err
+		}),
+	},
+}
+
+var chainVerifyStateCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Check the invariants of the state after a tipset",
+		ShortDescription: `
+Walks the state after the head, or the tipset given with --tipset or --height,
+and prints the invariants it violates, if any: that all actors hold the supply
+of genesis, that the power of miners sums to the storage committed in the
+storage market, that the payment broker holds what is left in its channels and
+that only accounts have nonces. That the nonce of each account grows by the
+number of its messages applied needs the messages, so only the checks enabled
+by chain.checkInvariants make it, as they process each tipset.
+`,
+	},
+	Options: []cmdkit.Option{
+		tipsetOption,
+		heightOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		at, err := parseTipSetRefOptions(req)
+		if err != nil {
+			return err
+		}
+		violations, err := GetPorcelainAPI(env).ChainVerifyState(req.Context, at)
+		if err != nil {
+			return err
+		}
+		return re.Emit(violations)
+	},
+	Type: []*consensus.Violation{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, violations *[]*consensus.Violation) error {
+			if len(*violations) == 0 {
+				_, err := fmt.Fprintln(w, "no invariants violated")
+				return err
+			}
+			for _, v := range *violations {
+				if _, err := fmt.Fprintln(w, v); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
+var chainStateDiffCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show the actors whose state differs between two tipsets",
+		ShortDescription: `
+Compares the states after the tipsets tsA and tsB and prints each actor that
+was added, removed or changed. Tipsets are given as comma separated lists of
+block CIDs and must be in the node's chain store. The state of changed miner
+and payment broker actors is decoded.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("tsA", true, false, "The CIDs of the blocks in the first tipset"),
+		cmdkit.StringArg("tsB", true, false, "The CIDs of the blocks in the second tipset"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		tsA, err := parseTipSetKey(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		tsB, err := parseTipSetKey(req.Arguments[1])
+		if err != nil {
+			return err
+		}
+		diffs, err := GetPorcelainAPI(env).ChainStateDiff(req.Context, tsA, tsB)
+		if err != nil {
+			return err
+		}
+		return re.Emit(diffs)
+	},
+	Type: []*stdiff.ActorDiff{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, diffs *[]*stdiff.ActorDiff) error {
+			for _, d := range *diffs {
+				if err := writeActorDiff(w, d); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
+func writeActorDiff(w io.Writer, d *stdiff.ActorDiff) error {
+	switch {
+	case d.Added():
+		_, err := fmt.Fprintf(w, "%s\tadded\tbalance=%s nonce=%d head=%s\n", d.Address, d.After.Balance, d.After.Nonce, d.After.Head)
+		return err
+	case d.Removed():
+		_, err := fmt.Fprintf(w, "%s\tremoved\tbalance=%s nonce=%d head=%s\n", d.Address, d.Before.Balance, d.Before.Nonce, d.Before.Head)
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "%s\tchanged\tbalance=%s->%s nonce=%d->%d head=%s->%s\n", d.Address,
+		d.Before.Balance, d.After.Balance, d.Before.Nonce, d.After.Nonce, d.Before.Head, d.After.Head); err != nil {
+		return err
+	}
+	if d.MinerBefore != nil && d.MinerAfter != nil {
+		if _, err := fmt.Fprintf(w, "\tminer\tpower=%s->%s pledge=%s->%s collateral=%s->%s sectors=%d->%d\n",
+			d.MinerBefore.Power, d.MinerAfter.Power,
+			d.MinerBefore.PledgeSectors, d.MinerAfter.PledgeSectors,
+			d.MinerBefore.Collateral, d.MinerAfter.Collateral,
+			len(d.MinerBefore.SectorCommitments), len(d.MinerAfter.SectorCommitments)); err != nil {
+			return err
+		}
+	}
+	for _, ch := range d.Channels {
+		var err error
+		switch {
+		case ch.Before == nil:
+			_, err = fmt.Fprintf(w, "\tchannel %s/%s\tcreated amount=%s\n", ch.Payer, ch.ChannelID, ch.After.Amount)
+		case ch.After == nil:
+			_, err = fmt.Fprintf(w, "\tchannel %s/%s\tclosed\n", ch.Payer, ch.ChannelID)
+		default:
+			_, err = fmt.Fprintf(w, "\tchannel %s/%s\tamount=%s->%s redeemed=%s->%s eol=%s->%s\n", ch.Payer, ch.ChannelID,
+				ch.Before.Amount, ch.After.Amount, ch.Before.AmountRedeemed, ch.After.AmountRedeemed, ch.Before.Eol, ch.After.Eol)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseTipSetKey parses a comma separated list of block CIDs into a tipset
+// key.
+func parseTipSetKey(s string) (types.SortedCidSet, error) {
+	ids := types.SortedCidSet{}
+	for _, part := range strings.Split(s, ",") {
+		c, err := cid.Decode(strings.TrimSpace(part))
+		if err != nil {
+			return types.SortedCidSet{}, err
+		}
+		ids.Add(c)
+	}
+	return ids, nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 
+	"github.com/filecoin-project/go-filecoin/chain"
 	"github.com/filecoin-project/go-filecoin/fixtures"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -53,6 +54,75 @@ func TestChainDaemon(t *testing.T) {
 		assert.True(c.Equals(bs[0][0].Cid()))
 	})
 
+	t.Run("chain state-diff shows the actors changed by a mined block", func(t *testing.T) {
+		t.Parallel()
+		assert := assert.New(t)
+		require := require.New(t)
+
+		d := th.NewDaemon(t, th.WithMiner(fixtures.TestMiners[0])).Start()
+		defer d.ShutdownSuccess()
+
+		mined := d.RunSuccess("mining", "once", "--enc", "text").ReadStdoutTrimNewlines()
+
+		var genesis []types.Block
+		lines := bytes.Split([]byte(d.RunSuccess("chain", "ls", "--enc", "json").ReadStdoutTrimNewlines()), []byte{'\n'})
+		require.NoError(json.Unmarshal(lines[len(lines)-1], &genesis))
+		require.Len(genesis, 1)
+
+		same := d.RunSuccess("chain", "state-diff", mined, mined).ReadStdoutTrimNewlines()
+		assert.Empty(same)
+
+		diff := d.RunSuccess("chain", "state-diff", genesis[0].Cid().String(), mined).ReadStdoutTrimNewlines()
+		assert.Contains(diff, "changed")
+
+		d.RunFail("failed to get state of tipset", "chain", "state-diff", types.SomeCid().String(), mined)
+	})
+
+	t.Run("chain verify-state finds no violations in mined state", func(t *testing.T) {
+		t.Parallel()
+		assert := assert.New(t)
+
+		d := th.NewDaemon(t, th.WithMiner(fixtures.TestMiners[0])).Start()
+		defer d.ShutdownSuccess()
+
+		d.RunSuccess("mining", "once")
+
+		assert.Equal("no invariants violated", d.RunSuccess("chain", "verify-state").ReadStdoutTrimNewlines())
+		assert.Equal("no invariants violated", d.RunSuccess("chain", "verify-state", "--height", "0").ReadStdoutTrimNewlines())
+	})
+
+	t.Run("chain sync-status reports a completed sync after mining", func(t *testing.T) {
+		t.Parallel()
+		assert := assert.New(t)
+		require := require.New(t)
+
+		d := th.NewDaemon(t, th.WithMiner(fixtures.TestMiners[0])).Start()
+		defer d.ShutdownSuccess()
+
+		d.RunSuccess("mining", "once")
+
+		op := d.RunSuccess("chain", "sync-status", "--enc", "json")
+		var status chain.SyncStatus
+		require.NoError(json.Unmarshal([]byte(op.ReadStdoutTrimNewlines()), &status))
+
+		assert.Equal(chain.SyncStageComplete, status.Stage)
+		assert.Equal(uint64(1), status.CurrentHeight)
+		assert.Equal(0, status.InFlight)
+	})
+
+	t.Run("chain bad ls is empty on a healthy node", func(t *testing.T) {
+		t.Parallel()
+		assert := assert.New(t)
+
+		d := th.NewDaemon(t).Start()
+		defer d.ShutdownSuccess()
+
+		op := d.RunSuccess("chain", "bad", "ls")
+		assert.Equal("", op.ReadStdoutTrimNewlines())
+
+		d.RunFail("not in the bad tipset cache", "chain", "bad", "rm", types.SomeCid().String())
+	})
+
 	t.Run("chain head with chain of size 1 returns genesis block", func(t *testing.T) {
 		t.Parallel()
 		assert := assert.New(t)
@@ -19,6 +19,7 @@ import (
 	"gx/ipfs/Qmde5VP1qUkyQXKCfmEUA7bP64V2HAptbJ7phuPp7jXWwg/go-ipfs-cmdkit"
 
 	"github.com/filecoin-project/go-filecoin/api/impl"
+	"github.com/filecoin-project/go-filecoin/chain"
 	"github.com/filecoin-project/go-filecoin/repo"
 	"github.com/filecoin-project/go-filecoin/types"
 )
@@ -322,28 +323,60 @@ var limitOption = cmdkit.Uint64Option("limit", "Maximum number of GasUnits this
 var previewOption = cmdkit.BoolOption("preview", "Preview the Gas cost of this command without actually executing it")
 
 func parseGasOptions(req *cmds.Request) (types.AttoFIL, types.GasUnits, bool, error) {
-	priceOption := req.Options["price"]
-	if priceOption == nil {
+	if req.Options["price"] == nil {
 		return types.AttoFIL{}, types.NewGasUnits(0), false, errors.New("price option is required")
 	}
-
-	price, ok := types.NewAttoFILFromFILString(priceOption.(string))
-	if !ok {
-		return types.AttoFIL{}, types.NewGasUnits(0), false, errors.New("invalid gas price (specify FIL as a decimal number)")
+	if req.Options["limit"] == nil {
+		return types.AttoFIL{}, types.NewGasUnits(0), false, errors.New("limit option is required")
 	}
+	return parseOptionalGasOptions(req)
+}
 
-	limitOption := req.Options["limit"]
-	if limitOption == nil {
-		return types.AttoFIL{}, types.NewGasUnits(0), false, errors.New("limit option is required")
+// parseOptionalGasOptions is like parseGasOptions but returns the zero
+// types.AttoFIL{} price and a zero limit when they are not given, for
+// porcelain.MessageSendWithDefaultAddress to fill in.
+func parseOptionalGasOptions(req *cmds.Request) (types.AttoFIL, types.GasUnits, bool, error) {
+	preview, _ := req.Options["preview"].(bool)
+
+	var price types.AttoFIL
+	if priceOption := req.Options["price"]; priceOption != nil {
+		p, ok := types.NewAttoFILFromFILString(priceOption.(string))
+		if !ok {
+			return types.AttoFIL{}, types.NewGasUnits(0), false, errors.New("invalid gas price (specify FIL as a decimal number)")
+		}
+		price = *p
 	}
 
-	gasLimitInt, ok := limitOption.(uint64)
-	if !ok {
-		msg := fmt.Sprintf("invalid gas limit: %s", limitOption)
-		return types.AttoFIL{}, types.NewGasUnits(0), false, errors.New(msg)
+	gasLimit := types.NewGasUnits(0)
+	if limitOption := req.Options["limit"]; limitOption != nil {
+		gasLimitInt, ok := limitOption.(uint64)
+		if !ok {
+			msg := fmt.Sprintf("invalid gas limit: %s", limitOption)
+			return types.AttoFIL{}, types.NewGasUnits(0), false, errors.New(msg)
+		}
+		gasLimit = types.NewGasUnits(gasLimitInt)
 	}
 
-	preview, _ := req.Options["preview"].(bool)
+	return price, gasLimit, preview, nil
+}
 
-	return *price, types.NewGasUnits(gasLimitInt), preview, nil
+var tipsetOption = cmdkit.StringOption("tipset", "Query the state after the tipset with these comma separated block CIDs instead of the head")
+var heightOption = cmdkit.Uint64Option("height", "Query the state after the tipset at this height instead of the head")
+
+func parseTipSetRefOptions(req *cmds.Request) (chain.TipSetRef, error) {
+	var ref chain.TipSetRef
+	if tipset, ok := req.Options["tipset"].(string); ok {
+		key, err := parseTipSetKey(tipset)
+		if err != nil {
+			return chain.TipSetRef{}, errors.Wrap(err, "invalid tipset")
+		}
+		ref.Key = key
+	}
+	if height, ok := req.Options["height"].(uint64); ok {
+		if !ref.Key.Empty() {
+			return chain.TipSetRef{}, errors.New("only one of tipset and height may be given")
+		}
+		ref.Height = &height
+	}
+	return ref, nil
 }
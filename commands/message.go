@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 
 	cmds "gx/ipfs/QmQtQrtNioesAWtrx8csBvfY37gTe94d6wQ3VikZUjxD39/go-ipfs-cmds"
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
@@ -15,7 +16,9 @@ import (
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/plumbing/mthdsig"
+	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
 )
 
 var msgCmd = &cmds.Command{
@@ -24,8 +27,13 @@ var msgCmd = &cmds.Command{
 		Tagline: "Manage messages",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"send": msgSendCmd,
-		"wait": msgWaitCmd,
+		"gas-price": msgGasPriceCmd,
+		"proof":     msgProofCmd,
+		"replace":   msgReplaceCmd,
+		"send":      msgSendCmd,
+		"simulate":  msgSimulateCmd,
+		"trace":     msgTraceCmd,
+		"wait":      msgWaitCmd,
 	},
 }
 
@@ -38,6 +46,15 @@ type msgSendResult struct {
 var msgSendCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Send a message", // This feels too generic...
+		ShortDescription: `
+Sends a message. If --price is omitted the price suggested by message
+gas-price is used, if --limit is omitted the gas limit is estimated by applying
+the message on top of the sender's pending messages.
+
+The parameters of the method are given with --params as a JSON array, which is
+validated against the schema of the method's parameters, see actor describe.
+For example: --params '["t1...", 7]'.
+`,
 	},
 	Arguments: []cmdkit.Argument{
 		cmdkit.StringArg("target", true, false, "Address of the actor to send the message to"),
@@ -46,10 +63,11 @@ var msgSendCmd = &cmds.Command{
 	Options: []cmdkit.Option{
 		cmdkit.IntOption("value", "Value to send with message, in AttoFIL"),
 		cmdkit.StringOption("from", "Address to send message from"),
+		cmdkit.StringOption("params", "JSON array of the parameters of the method"),
 		priceOption,
 		limitOption,
 		previewOption,
-		// TODO: (per dignifiedquire) add an option to set the nonce and method explicitly
+		// TODO: (per dignifiedquire) add an option to set the nonce explicitly
 	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		target, err := address.NewFromString(req.Arguments[0])
@@ -72,14 +90,19 @@ var msgSendCmd = &cmds.Command{
 			}
 		}
 
-		gasPrice, gasLimit, preview, err := parseGasOptions(req)
+		gasPrice, gasLimit, preview, err := parseOptionalGasOptions(req)
 		if err != nil {
 			return err
 		}
 
-		method, ok := req.Options["method"].(string)
-		if !ok {
-			method = ""
+		method := ""
+		if len(req.Arguments) > 1 {
+			method = req.Arguments[1]
+		}
+
+		params, err := parseJSONParams(req, target, method, env)
+		if err != nil {
+			return err
 		}
 
 		if preview {
@@ -88,6 +111,7 @@ var msgSendCmd = &cmds.Command{
 				fromAddr,
 				target,
 				method,
+				params...,
 			)
 			if err != nil {
 				return err
@@ -107,6 +131,7 @@ var msgSendCmd = &cmds.Command{
 			gasPrice,
 			gasLimit,
 			method,
+			params...,
 		)
 		if err != nil {
 			return err
@@ -131,6 +156,133 @@ var msgSendCmd = &cmds.Command{
 	},
 }
 
+// parseJSONParams decodes the params option of req, a JSON array of the
+// parameters of method of the actor at target, validating them against the
+// method's signature.
+func parseJSONParams(req *cmds.Request, target address.Address, method string, env cmds.Environment) ([]interface{}, error) {
+	paramsJSON, _ := req.Options["params"].(string)
+	if method == "" {
+		if paramsJSON != "" {
+			return nil, errors.New("params given without a method")
+		}
+		return nil, nil
+	}
+	if paramsJSON == "" {
+		paramsJSON = "[]"
+	}
+
+	sig, err := GetPorcelainAPI(env).ActorGetSignature(req.Context, target, method)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get method signature")
+	}
+
+	params, err := abi.DecodeJSONValues(sig.Params, []byte(paramsJSON))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid params for %s", method)
+	}
+	return params, nil
+}
+
+var msgReplaceCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Replace a pending message with a higher gas price",
+		ShortDescription: `
+Re-signs the pending message with the given cid with a new gas price and sends
+it in its place. The message pool only accepts the replacement if its gas price
+is sufficiently higher than the original's, see mpool.replaceByFeePercent in the
+config. Prints the cid of the replacement.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("cid", true, false, "The cid of the pending message to replace"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("gas-price", "New price (FIL e.g. 0.00013) to pay for each GasUnits consumed mining this message"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		msgCid, err := cid.Parse(req.Arguments[0])
+		if err != nil {
+			return errors.Wrap(err, "invalid message cid")
+		}
+
+		priceOption, ok := req.Options["gas-price"].(string)
+		if !ok {
+			return errors.New("gas-price option is required")
+		}
+		gasPrice, ok := types.NewAttoFILFromFILString(priceOption)
+		if !ok {
+			return errors.New("invalid gas price (specify FIL as a decimal number)")
+		}
+
+		c, err := GetPorcelainAPI(env).MessageReplace(req.Context, msgCid, *gasPrice)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(c)
+	},
+	Type: cid.Cid{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, c cid.Cid) error {
+			return PrintString(w, c)
+		}),
+	},
+}
+
+// msgGasPriceResult is the result of a message gas-price call.
+type msgGasPriceResult struct {
+	// Samples is the number of recent messages the percentiles are taken from.
+	Samples int
+	P25     types.AttoFIL
+	P50     types.AttoFIL
+	P75     types.AttoFIL
+	// Suggested is the price messages sent without --price pay.
+	Suggested types.AttoFIL
+}
+
+var msgGasPriceCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show the gas prices of recently included messages",
+		ShortDescription: `
+Shows the 25th, 50th and 75th percentiles of the gas prices of the messages
+included in recent blocks, and the price suggested for messages sent without
+--price: the median, but at least mpool.minGasPrice. If recent blocks include
+no messages the suggested price is mpool.minGasPrice.
+`,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		stats, err := GetPorcelainAPI(env).MessageGasPriceStats(req.Context)
+		if err != nil {
+			return err
+		}
+		suggested, err := GetPorcelainAPI(env).SuggestGasPrice(req.Context)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(&msgGasPriceResult{
+			Samples:   stats.Samples,
+			P25:       stats.P25,
+			P50:       stats.P50,
+			P75:       stats.P75,
+			Suggested: suggested,
+		})
+	},
+	Type: msgGasPriceResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *msgGasPriceResult) error {
+			fmt.Fprintf(w, "samples:   %d\n", res.Samples) // nolint: errcheck
+			if res.Samples > 0 {
+				fmt.Fprintf(w, "p25:       %s\n", res.P25.String()) // nolint: errcheck
+				fmt.Fprintf(w, "p50:       %s\n", res.P50.String()) // nolint: errcheck
+				fmt.Fprintf(w, "p75:       %s\n", res.P75.String()) // nolint: errcheck
+			}
+			_, err := fmt.Fprintf(w, "suggested: %s\n", res.Suggested.String())
+			return err
+		}),
+	},
+}
+
 // WaitResult is the result of a message wait call.
 type WaitResult struct {
 	Message   *types.SignedMessage
@@ -211,7 +363,7 @@ var msgWaitCmd = &cmds.Command{
 					return errors.Wrap(err, "unable to deserialize return value")
 				}
 
-				marshaled = append(marshaled, []byte(val.Val.(Stringer).String())...)
+				marshaled = append(marshaled, []byte(val.String())...)
 			}
 
 			_, err = w.Write(marshaled)
@@ -220,6 +372,270 @@ var msgWaitCmd = &cmds.Command{
 	},
 }
 
+type msgSimulateResult struct {
+	Receipt *types.MessageReceipt
+	GasUsed types.GasUnits
+	// Return are the decoded return values.
+	Return []string
+	Error  string `json:",omitempty"`
+	// Changes are the actors the message would change.
+	Changes []*state.ActorDiff
+}
+
+var msgSimulateCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Simulate a message without sending it",
+		ShortDescription: `
+Applies an unsigned message to the state after the head, or after the tipset
+given by --tipset or --height, as if it were in the next block. Shows the
+receipt, the gas used, and how the message would change the balances, nonces
+and state of actors. Methods that change state may be called with any value.
+Nothing is sent and no state is written.
+
+The parameters of the method are given with --params as a JSON array, as for
+message send. For example: --params '["t1...", 7]'.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("target", true, false, "Address of the actor to send the message to"),
+		cmdkit.StringArg("method", false, false, "The method to invoke on the target actor"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send message from"),
+		cmdkit.StringOption("value", "Value to send with the message, in FIL"),
+		cmdkit.StringOption("params", "JSON array of the parameters of the method"),
+		priceOption,
+		tipsetOption,
+		heightOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		target, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return errors.Wrap(err, "invalid target address")
+		}
+
+		fromOpt, ok := req.Options["from"].(string)
+		if !ok {
+			return errors.New("a from address is required")
+		}
+		from, err := address.NewFromString(fromOpt)
+		if err != nil {
+			return errors.Wrap(err, "invalid from address")
+		}
+
+		value := types.NewZeroAttoFIL()
+		if v, ok := req.Options["value"].(string); ok {
+			if value, ok = types.NewAttoFILFromFILString(v); !ok {
+				return errors.New("invalid value")
+			}
+		}
+		gasPrice := types.NewGasPrice(0)
+		if p, ok := req.Options["price"].(string); ok {
+			price, ok := types.NewAttoFILFromFILString(p)
+			if !ok {
+				return errors.New("invalid gas price")
+			}
+			gasPrice = *price
+		}
+
+		at, err := parseTipSetRefOptions(req)
+		if err != nil {
+			return err
+		}
+
+		var method string
+		if len(req.Arguments) > 1 {
+			method = req.Arguments[1]
+		}
+		params, err := parseJSONParams(req, target, method, env)
+		if err != nil {
+			return err
+		}
+		var sig *exec.FunctionSignature
+		if method != "" {
+			sig, err = GetPorcelainAPI(env).ActorGetSignature(req.Context, target, method)
+			if err != nil {
+				return errors.Wrap(err, "couldn't get signature of method")
+			}
+		}
+
+		res, err := GetPorcelainAPI(env).MessageSimulate(req.Context, at, from, target, value, gasPrice, method, params...)
+		if err != nil {
+			return err
+		}
+
+		out := &msgSimulateResult{
+			Receipt: res.Receipt,
+			GasUsed: res.GasUsed,
+			Changes: res.Changes,
+		}
+		if res.ExecutionError != nil {
+			out.Error = res.ExecutionError.Error()
+		}
+		for i, r := range res.Receipt.Return {
+			if sig == nil || i >= len(sig.Return) {
+				break
+			}
+			val, err := abi.Deserialize(r, sig.Return[i])
+			if err != nil {
+				return errors.Wrap(err, "unable to deserialize return value")
+			}
+			out.Return = append(out.Return, val.String())
+		}
+		return re.Emit(out)
+	},
+	Type: msgSimulateResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *msgSimulateResult) error {
+			fmt.Fprintf(w, "exit code: %d\n", res.Receipt.ExitCode)                              // nolint: errcheck
+			fmt.Fprintf(w, "gas:       %d units, %s FIL\n", res.GasUsed, res.Receipt.GasAttoFIL) // nolint: errcheck
+			for _, r := range res.Return {
+				fmt.Fprintf(w, "return:    %s\n", r) // nolint: errcheck
+			}
+			if res.Error != "" {
+				fmt.Fprintf(w, "error:     %s\n", res.Error) // nolint: errcheck
+			}
+			fmt.Fprintln(w, "changes:") // nolint: errcheck
+			for _, c := range res.Changes {
+				fmt.Fprintf(w, "  %s %s\n", c.Address, describeActorChange(c)) // nolint: errcheck
+			}
+			return nil
+		}),
+	},
+}
+
+// describeActorChange summarizes how an actor changed.
+func describeActorChange(c *state.ActorDiff) string {
+	if c.Removed() {
+		return "removed"
+	}
+	if c.Added() {
+		return fmt.Sprintf("added, code %s, balance %s", c.After.Code, c.After.Balance)
+	}
+
+	var changes []string
+	before, after := c.Before, c.After
+	if !before.Code.Equals(after.Code) {
+		changes = append(changes, fmt.Sprintf("code %s -> %s", before.Code, after.Code))
+	}
+	if !before.Balance.Equal(after.Balance) {
+		changes = append(changes, fmt.Sprintf("balance %s -> %s", before.Balance, after.Balance))
+	}
+	if before.Nonce != after.Nonce {
+		changes = append(changes, fmt.Sprintf("nonce %d -> %d", before.Nonce, after.Nonce))
+	}
+	if !before.Head.Equals(after.Head) {
+		changes = append(changes, "state changed")
+	}
+	return strings.Join(changes, ", ")
+}
+
+// MessageProofResult is the result of the message proof command.
+type MessageProofResult struct {
+	Block cid.Cid             `json:"block"`
+	Proof *types.MessageProof `json:"proof"`
+}
+
+var msgProofCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Prove that a message is on chain",
+		ShortDescription: `
+Finds a message on chain and prints the cid of the block including it with the
+proof of its inclusion and of its receipt, as JSON. The proof holds the block
+header and the paths to the message and receipt in the block's collections, so
+it can be checked knowing only the block cid.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("cid", true, false, "The cid of the message to prove"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		msgCid, err := cid.Parse(req.Arguments[0])
+		if err != nil {
+			return errors.Wrap(err, "invalid message cid")
+		}
+
+		blkCid, proof, err := GetPorcelainAPI(env).MessageProof(req.Context, msgCid)
+		if err != nil {
+			return err
+		}
+		return re.Emit(&MessageProofResult{Block: blkCid, Proof: proof})
+	},
+	Type: &MessageProofResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *MessageProofResult) error {
+			marshaled, err := json.MarshalIndent(res, "", "  ")
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(append(marshaled, '\n'))
+			return err
+		}),
+	},
+}
+
+var msgTraceCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Trace the execution of a message on chain",
+		ShortDescription: `
+Replays a message on the state its tipset was applied to and prints the messages
+it sent to other actors as a tree. Each call shows its parameters, the value
+sent, the gas charged, its exit code and return values, and why it failed if it
+did. Nothing is written to the chain.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("cid", true, false, "The cid of the message to trace"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		msgCid, err := cid.Parse(req.Arguments[0])
+		if err != nil {
+			return errors.Wrap(err, "invalid message cid")
+		}
+
+		trace, err := GetPorcelainAPI(env).MessageTrace(req.Context, msgCid)
+		if err != nil {
+			return err
+		}
+		return re.Emit(trace)
+	},
+	Type: vm.Trace{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, trace *vm.Trace) error {
+			writeTrace(w, trace, "")
+			return nil
+		}),
+	},
+}
+
+// writeTrace writes trace and the traces of the messages it sent, indented
+// below it.
+func writeTrace(w io.Writer, trace *vm.Trace, indent string) {
+	method := trace.Method
+	if method == "" {
+		method = "transfer"
+	}
+	params := make([]string, len(trace.Params))
+	for i, p := range trace.Params {
+		params[i] = fmt.Sprint(p)
+	}
+	fmt.Fprintf(w, "%s%s -> %s %s(%s) value=%s gas=%d exit=%d\n", indent, trace.From, trace.To, method, strings.Join(params, ", "), trace.Value, trace.GasUsed, trace.ExitCode) // nolint: errcheck
+
+	indent += "  "
+	for _, charge := range trace.Charges {
+		fmt.Fprintf(w, "%scharge %d\n", indent, charge) // nolint: errcheck
+	}
+	for _, send := range trace.Sends {
+		writeTrace(w, send, indent)
+	}
+	for _, r := range trace.Return {
+		fmt.Fprintf(w, "%sreturn %v\n", indent, r) // nolint: errcheck
+	}
+	if trace.Error != "" {
+		fmt.Fprintf(w, "%serror %s\n", indent, trace.Error) // nolint: errcheck
+	}
+}
+
 func appendJSON(val interface{}, out []byte) ([]byte, error) {
 	m, err := json.MarshalIndent(val, "", "\t")
 	if err != nil {
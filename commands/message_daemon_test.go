@@ -9,7 +9,9 @@ import (
 
 	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
 	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 
+	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/fixtures"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -51,6 +53,160 @@ func TestMessageSend(t *testing.T) {
 		"--price", "0", "--limit", "300",
 		"--value=10", fixtures.TestAddresses[1],
 	)
+
+	t.Log("[success] without price and limit")
+	d.RunSuccess("message", "send",
+		"--from", fixtures.TestAddresses[0],
+		"--value=10", fixtures.TestAddresses[1],
+	)
+
+	t.Log("[success] with params")
+	d.RunSuccess("message", "send",
+		"--from", fixtures.TestAddresses[0],
+		"--price", "0", "--limit", "300",
+		"--params", `["`+fixtures.TestAddresses[0]+`"]`,
+		address.PaymentBrokerAddress.String(), "ls",
+	)
+
+	t.Log("[failure] with params not matching the method")
+	d.RunFail("invalid params for ls",
+		"message", "send",
+		"--from", fixtures.TestAddresses[0],
+		"--price", "0", "--limit", "300",
+		"--params", `[7]`,
+		address.PaymentBrokerAddress.String(), "ls",
+	)
+}
+
+func TestMessageGasPrice(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	d := th.NewDaemon(t).Start()
+	defer d.ShutdownSuccess()
+
+	// Without recent messages the minimum price is suggested.
+	d.RunSuccess("config", "mpool.minGasPrice", `"0.001"`)
+	out := d.RunSuccess("message", "gas-price").ReadStdout()
+	assert.Contains(out, "samples:   0")
+	assert.NotContains(out, "p50")
+	assert.Contains(out, "suggested: 0.001")
+}
+
+func TestMessageSimulate(t *testing.T) {
+	t.Parallel()
+
+	d := th.NewDaemon(
+		t,
+		th.WithMiner(fixtures.TestMiners[0]),
+		th.KeyFile(fixtures.KeyFilePaths()[0]),
+	).Start()
+	defer d.ShutdownSuccess()
+
+	balance := d.RunSuccess("wallet", "balance", fixtures.TestAddresses[0]).ReadStdout()
+
+	t.Run("transfer", func(t *testing.T) {
+		assert := assert.New(t)
+
+		out := d.RunSuccess("message", "simulate",
+			"--from", fixtures.TestAddresses[0],
+			"--value", "10",
+			fixtures.TestAddresses[1],
+		).ReadStdout()
+		assert.Contains(out, "exit code: 0")
+		assert.Contains(out, fixtures.TestAddresses[0]+" balance")
+		assert.Contains(out, "nonce 0 -> 1")
+		assert.Contains(out, fixtures.TestAddresses[1]+" balance")
+	})
+
+	t.Run("state changing method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		out := d.RunSuccess("message", "simulate",
+			"--from", fixtures.TestAddresses[0],
+			"--value", "10",
+			"--params", `["`+fixtures.TestAddresses[1]+`", 100]`,
+			address.PaymentBrokerAddress.String(), "createChannel",
+		).ReadStdout()
+		assert.Contains(out, "exit code: 0")
+		assert.Contains(out, "return:    0")
+		assert.Contains(out, address.PaymentBrokerAddress.String()+" balance")
+		assert.Contains(out, "state changed")
+	})
+
+	t.Run("bad parameters", func(t *testing.T) {
+		d.RunFail("invalid params for createChannel", "message", "simulate",
+			"--from", fixtures.TestAddresses[0],
+			"--params", `["`+fixtures.TestAddresses[1]+`"]`,
+			address.PaymentBrokerAddress.String(), "createChannel",
+		)
+	})
+
+	// Nothing was sent.
+	assert.Equal(t, balance, d.RunSuccess("wallet", "balance", fixtures.TestAddresses[0]).ReadStdout())
+	assert.Empty(t, d.RunSuccess("mpool", "ls").ReadStdoutTrimNewlines())
+}
+
+func TestMessageTrace(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	d := th.NewDaemon(
+		t,
+		th.WithMiner(fixtures.TestMiners[0]),
+		th.KeyFile(fixtures.KeyFilePaths()[0]),
+	).Start()
+	defer d.ShutdownSuccess()
+
+	msg := d.RunSuccess(
+		"message", "send",
+		"--from", fixtures.TestAddresses[0],
+		"--price", "0", "--limit", "300",
+		"--value=10",
+		fixtures.TestAddresses[1],
+	)
+	msgcid := strings.Trim(msg.ReadStdout(), "\n")
+
+	d.RunFail("not found on chain", "message", "trace", msgcid)
+
+	d.RunSuccess("mining", "once")
+
+	out := d.RunSuccess("message", "trace", msgcid).ReadStdout()
+	assert.Contains(out, fixtures.TestAddresses[0]+" -> "+fixtures.TestAddresses[1]+" transfer() value=10 gas=0 exit=0")
+	assert.NotContains(out, "error")
+}
+
+func TestMessageProof(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	d := th.NewDaemon(
+		t,
+		th.WithMiner(fixtures.TestMiners[0]),
+		th.KeyFile(fixtures.KeyFilePaths()[0]),
+	).Start()
+	defer d.ShutdownSuccess()
+
+	msg := d.RunSuccess(
+		"message", "send",
+		"--from", fixtures.TestAddresses[0],
+		"--price", "0", "--limit", "300",
+		"--value=10",
+		fixtures.TestAddresses[1],
+	)
+	msgcid, err := cid.Decode(strings.Trim(msg.ReadStdout(), "\n"))
+	require.NoError(err)
+
+	d.RunFail("not found on chain", "message", "proof", msgcid.String())
+
+	d.RunSuccess("mining", "once")
+
+	var res MessageProofResult
+	require.NoError(json.Unmarshal([]byte(d.RunSuccess("message", "proof", msgcid.String()).ReadStdout()), &res))
+
+	receipt, err := res.Proof.Verify(res.Block, msgcid)
+	require.NoError(err)
+	assert.Equal(t, uint8(0), receipt.ExitCode)
 }
 
 func TestMessageWait(t *testing.T) {
@@ -97,6 +253,28 @@ func TestMessageWait(t *testing.T) {
 	})
 }
 
+func TestMessageReplace(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	d := th.NewDaemon(t, th.KeyFile(fixtures.KeyFilePaths()[0])).Start()
+	defer d.ShutdownSuccess()
+
+	origCid := d.RunSuccess("message", "send",
+		"--from", fixtures.TestAddresses[0],
+		"--price", "0.001", "--limit", "300",
+		"--value=10", fixtures.TestAddresses[1],
+	).ReadStdoutTrimNewlines()
+
+	d.RunFail("gas price must be at least", "message", "replace", origCid, "--gas-price", "0.00105")
+
+	newCid := d.RunSuccess("message", "replace", origCid, "--gas-price", "0.002").ReadStdoutTrimNewlines()
+	assert.NotEqual(origCid, newCid)
+
+	out := d.RunSuccess("mpool", "ls").ReadStdoutTrimNewlines()
+	assert.Equal(newCid, out)
+}
+
 func TestMessageSendBlockGasLimit(t *testing.T) {
 	t.Parallel()
 
@@ -479,11 +479,15 @@ Values will be output as a ratio where the first number is the miner power and s
 		if err != nil {
 			return err
 		}
-		power, err := GetAPI(env).Miner().GetPower(req.Context, minerAddr)
+		at, err := parseTipSetRefOptions(req)
 		if err != nil {
 			return err
 		}
-		total, err := GetAPI(env).Miner().GetTotalPower(req.Context)
+		power, err := GetAPI(env).Miner().GetPower(req.Context, at, minerAddr)
+		if err != nil {
+			return err
+		}
+		total, err := GetAPI(env).Miner().GetTotalPower(req.Context, at)
 		if err != nil {
 			return err
 		}
@@ -494,6 +498,10 @@ Values will be output as a ratio where the first number is the miner power and s
 	Arguments: []cmdkit.Argument{
 		cmdkit.StringArg("miner", true, false, "The address of the miner"),
 	},
+	Options: []cmdkit.Option{
+		tipsetOption,
+		heightOption,
+	},
 	Encoders: cmds.EncoderMap{
 		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, a string) error {
 			_, err := fmt.Fprintln(w, a)
@@ -3,10 +3,14 @@ package commands
 import (
 	"fmt"
 	"io"
+	"sort"
 
 	"gx/ipfs/QmQtQrtNioesAWtrx8csBvfY37gTe94d6wQ3VikZUjxD39/go-ipfs-cmds"
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 	cmdkit "gx/ipfs/Qmde5VP1qUkyQXKCfmEUA7bP64V2HAptbJ7phuPp7jXWwg/go-ipfs-cmdkit"
+
+	"github.com/filecoin-project/go-filecoin/api"
+	"github.com/filecoin-project/go-filecoin/mining"
 )
 
 var miningCmd = &cmds.Command{
@@ -14,9 +18,11 @@ var miningCmd = &cmds.Command{
 		Tagline: "Manage all mining operations for a node",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"once":  miningOnceCmd,
-		"start": miningStartCmd,
-		"stop":  miningStopCmd,
+		"once":    miningOnceCmd,
+		"preview": miningPreviewCmd,
+		"start":   miningStartCmd,
+		"status":  miningStatusCmd,
+		"stop":    miningStopCmd,
 	},
 }
 
@@ -37,6 +43,49 @@ var miningOnceCmd = &cmds.Command{
 	},
 }
 
+var miningPreviewCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Build a block on the current head without publishing it",
+		ShortDescription: `
+Selects messages from the pool and applies them as if the node's miner had won
+the next epoch. Shows the messages selected, the gas they used, those that
+failed grouped by reason, and the resulting state root. The block is neither
+published nor are failed messages removed from the pool.
+`,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		preview, err := GetAPI(env).Mining().Preview(req.Context)
+		if err != nil {
+			return err
+		}
+		return re.Emit(preview)
+	},
+	Type: mining.BlockPreview{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, preview *mining.BlockPreview) error {
+			fmt.Fprintf(w, "base:       %s\n", preview.Base)                                                       // nolint: errcheck
+			fmt.Fprintf(w, "height:     %d\n", preview.Height)                                                     // nolint: errcheck
+			fmt.Fprintf(w, "messages:   %d selected, %d included\n", len(preview.Selected), len(preview.Included)) // nolint: errcheck
+			fmt.Fprintf(w, "gas:        %d units, %s FIL in fees\n", preview.GasUsed, preview.GasFees)             // nolint: errcheck
+			fmt.Fprintf(w, "state root: %s\n", preview.StateRoot)                                                  // nolint: errcheck
+			if len(preview.Failures) == 0 {
+				return nil
+			}
+
+			reasons := make([]string, 0, len(preview.FailuresByReason))
+			for reason := range preview.FailuresByReason {
+				reasons = append(reasons, reason)
+			}
+			sort.Strings(reasons)
+			fmt.Fprintln(w, "failures:") // nolint: errcheck
+			for _, reason := range reasons {
+				fmt.Fprintf(w, "  %d %s\n", preview.FailuresByReason[reason], reason) // nolint: errcheck
+			}
+			return nil
+		}),
+	},
+}
+
 var miningStartCmd = &cmds.Command{
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		if err := GetAPI(env).Mining().Start(req.Context); err != nil {
@@ -48,6 +97,42 @@ var miningStartCmd = &cmds.Command{
 	Encoders: stringEncoderMap,
 }
 
+var miningStatusCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show whether the node is mining and statistics of the miners it mines for",
+		ShortDescription: `
+Shows for each miner the node mines for its share of the storage power, the
+tipset the node last mined on for it, and how many tickets it tried, blocks it
+won, mined and how many of those were orphaned since the node started.
+`,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		status, err := GetAPI(env).Mining().Status(req.Context)
+		if err != nil {
+			return err
+		}
+		return re.Emit(status)
+	},
+	Type: api.MiningStatus{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, status *api.MiningStatus) error {
+			active := "inactive"
+			if status.Active {
+				active = "active"
+			}
+			fmt.Fprintf(w, "mining: %s\n", active) // nolint: errcheck
+			for _, m := range status.Miners {
+				fmt.Fprintf(w, "%s owner=%s signer=%s\n", m.Address, m.Owner, m.BlockSigner)                                    // nolint: errcheck
+				fmt.Fprintf(w, "  power:     %d/%d (expected win rate %.2f%%)\n", m.Power, m.TotalPower, 100*m.ExpectedWinRate) // nolint: errcheck
+				fmt.Fprintf(w, "  last base: %s\n", m.LastBase)                                                                 // nolint: errcheck
+				fmt.Fprintf(w, "  tickets:   %d tried, %d won\n", m.TicketsTried, m.BlocksWon)                                  // nolint: errcheck
+				fmt.Fprintf(w, "  blocks:    %d mined, %d orphaned\n", m.BlocksMined, m.BlocksOrphaned)                         // nolint: errcheck
+			}
+			return nil
+		}),
+	},
+}
+
 var miningStopCmd = &cmds.Command{
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		if err := GetAPI(env).Mining().Stop(req.Context); err != nil {
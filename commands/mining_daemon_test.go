@@ -36,3 +36,76 @@ func TestMiningGenBlock(t *testing.T) {
 
 	assert.True(sum.Add(beforeBalance, big.NewInt(1000)).Cmp(afterBalance) == 0)
 }
+
+func TestWalletBalanceAtHistoricalTipSet(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	d := th.NewDaemon(t, th.WithMiner(fixtures.TestMiners[0]), th.KeyFile(fixtures.KeyFilePaths()[0])).Start()
+	defer d.ShutdownSuccess()
+
+	addr := fixtures.TestMiners[0]
+
+	beforeBalance := parseInt(assert, d.RunSuccess("wallet", "balance", addr).ReadStdout())
+
+	mined := d.RunSuccess("mining", "once", "--enc", "text").ReadStdoutTrimNewlines()
+	d.RunSuccess("mining", "once")
+
+	atGenesis := parseInt(assert, d.RunSuccess("wallet", "balance", addr, "--height", "0").ReadStdout())
+	assert.Equal(beforeBalance, atGenesis)
+
+	atMined := parseInt(assert, d.RunSuccess("wallet", "balance", addr, "--tipset", mined).ReadStdout())
+	assert.Equal(new(big.Int).Add(beforeBalance, big.NewInt(1000)), atMined)
+
+	d.RunFail("only one of tipset and height", "wallet", "balance", addr, "--tipset", mined, "--height", "1")
+}
+
+func TestMiningStatus(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	d := th.NewDaemon(
+		t,
+		th.WithMiner(fixtures.TestMiners[0]),
+		th.KeyFile(fixtures.KeyFilePaths()[0]),
+		th.KeyFile(fixtures.KeyFilePaths()[1]),
+	).Start()
+	defer d.ShutdownSuccess()
+
+	out := d.RunSuccess("mining", "status").ReadStdout()
+	assert.Contains(out, "mining: inactive")
+	assert.Contains(out, fixtures.TestMiners[0]+" owner="+fixtures.TestAddresses[0])
+	assert.Contains(out, "tickets:   0 tried, 0 won")
+	assert.Contains(out, "expected win rate")
+
+	d.RunSuccess("config", "mining.miners", `[{"address": "`+fixtures.TestMiners[1]+`", "blockSignerAddress": "`+fixtures.TestAddresses[1]+`"}]`)
+
+	out = d.RunSuccess("mining", "status").ReadStdout()
+	assert.Contains(out, fixtures.TestMiners[0]+" owner="+fixtures.TestAddresses[0])
+	assert.Contains(out, fixtures.TestMiners[1]+" owner="+fixtures.TestAddresses[1]+" signer="+fixtures.TestAddresses[1])
+}
+
+func TestMiningPreview(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	d := th.NewDaemon(t, th.WithMiner(fixtures.TestMiners[0]), th.KeyFile(fixtures.KeyFilePaths()[0])).Start()
+	defer d.ShutdownSuccess()
+
+	d.RunSuccess("message", "send",
+		"--from", fixtures.TestAddresses[0],
+		"--price", "0", "--limit", "300",
+		"--value=10", fixtures.TestAddresses[1],
+	)
+
+	head := d.RunSuccess("chain", "head").ReadStdout()
+
+	out := d.RunSuccess("mining", "preview").ReadStdout()
+	assert.Contains(out, "height:     1")
+	assert.Contains(out, "messages:   1 selected, 1 included")
+	assert.Contains(out, "state root:")
+	assert.NotContains(out, "failures:")
+
+	// Nothing was published and the message is still pending.
+	assert.Equal(head, d.RunSuccess("chain", "head").ReadStdout())
+	assert.NotEmpty(d.RunSuccess("mpool", "ls").ReadStdoutTrimNewlines())
+}
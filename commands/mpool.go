@@ -9,6 +9,7 @@ import (
 	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 	"gx/ipfs/Qmde5VP1qUkyQXKCfmEUA7bP64V2HAptbJ7phuPp7jXWwg/go-ipfs-cmdkit"
 
+	"github.com/filecoin-project/go-filecoin/plumbing/msg"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
@@ -17,8 +18,9 @@ var mpoolCmd = &cmds.Command{
 		Tagline: "Manage the message pool",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"ls": mpoolLsCmd,
-		"rm": mpoolRemoveCmd,
+		"ls":     mpoolLsCmd,
+		"rm":     mpoolRemoveCmd,
+		"status": mpoolStatusCmd,
 	},
 }
 
@@ -72,3 +74,52 @@ var mpoolRemoveCmd = &cmds.Command{
 		return nil
 	},
 }
+
+var mpoolStatusCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show the status of messages sent by this node",
+		ShortDescription: `
+Lists the messages sent by this node that are still in the message pool, oldest
+first, with how many tipsets they have been pending and how many times they have
+been broadcast. Messages pending for more than mpool.stuckAfterTipSets tipsets
+are marked as stuck.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("stuck", "Only show stuck messages"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		statuses, err := GetAPI(env).Mpool().Status(req.Context)
+		if err != nil {
+			return err
+		}
+
+		if stuckOnly, _ := req.Options["stuck"].(bool); stuckOnly {
+			var stuck []*msg.SentMessage
+			for _, st := range statuses {
+				if st.Stuck {
+					stuck = append(stuck, st)
+				}
+			}
+			statuses = stuck
+		}
+
+		return re.Emit(statuses)
+	},
+	Type: []*msg.SentMessage{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, statuses *[]*msg.SentMessage) error {
+			for _, st := range *statuses {
+				stuck := ""
+				if st.Stuck {
+					stuck = " stuck"
+				}
+				_, err := fmt.Fprintf(w, "%s pending=%d broadcasts=%d%s\n", st.Cid, st.PendingTipSets, st.Broadcasts, stuck)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
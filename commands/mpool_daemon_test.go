@@ -92,6 +92,22 @@ func TestMpoolRm(t *testing.T) {
 	})
 }
 
+func TestMpoolStatus(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	d := th.NewDaemon(t, th.KeyFile(fixtures.KeyFilePaths()[0])).Start()
+	defer d.ShutdownSuccess()
+
+	msgCid := sendMessage(d, fixtures.TestAddresses[0], fixtures.TestAddresses[2]).ReadStdoutTrimNewlines()
+
+	out := d.RunSuccess("mpool", "status").ReadStdoutTrimNewlines()
+	assert.Equal(msgCid+" pending=0 broadcasts=1", out)
+
+	out = d.RunSuccess("mpool", "status", "--stuck").ReadStdoutTrimNewlines()
+	assert.Equal("", out)
+}
+
 func sendMessage(d *th.TestDaemon, from string, to string) *th.Output {
 	return d.RunSuccess("message", "send",
 		"--from", from,
@@ -130,6 +130,8 @@ var lsCmd = &cmds.Command{
 	Options: []cmdkit.Option{
 		cmdkit.StringOption("from", "Address for which message is sent"),
 		cmdkit.StringOption("payer", "Address for which to retrieve channels (defaults to from if omitted)"),
+		tipsetOption,
+		heightOption,
 	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		fromAddr, err := optionalAddr(req.Options["from"])
@@ -143,7 +145,12 @@ var lsCmd = &cmds.Command{
 			return err
 		}
 
-		channels, err := GetAPI(env).Paych().Ls(req.Context, fromAddr, payerAddr)
+		at, err := parseTipSetRefOptions(req)
+		if err != nil {
+			return err
+		}
+
+		channels, err := GetAPI(env).Paych().Ls(req.Context, at, fromAddr, payerAddr)
 		if err != nil {
 			return err
 		}
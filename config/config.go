@@ -13,17 +13,20 @@ import (
 
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/upgrade"
 )
 
 // Config is an in memory representation of the filecoin configuration file
 type Config struct {
-	API       *APIConfig       `json:"api"`
-	Bootstrap *BootstrapConfig `json:"bootstrap"`
-	Datastore *DatastoreConfig `json:"datastore"`
-	Swarm     *SwarmConfig     `json:"swarm"`
-	Mining    *MiningConfig    `json:"mining"`
-	Wallet    *WalletConfig    `json:"wallet"`
-	Heartbeat *HeartbeatConfig `json:"heartbeat"`
+	API       *APIConfig         `json:"api"`
+	Bootstrap *BootstrapConfig   `json:"bootstrap"`
+	Datastore *DatastoreConfig   `json:"datastore"`
+	Swarm     *SwarmConfig       `json:"swarm"`
+	Mining    *MiningConfig      `json:"mining"`
+	Wallet    *WalletConfig      `json:"wallet"`
+	Heartbeat *HeartbeatConfig   `json:"heartbeat"`
+	Chain     *ChainConfig       `json:"chain"`
+	Mpool     *MessagePoolConfig `json:"mpool"`
 }
 
 // APIConfig holds all configuration options related to the api.
@@ -100,15 +103,25 @@ func newDefaultBootstrapConfig() *BootstrapConfig {
 
 // MiningConfig holds all configuration options related to mining.
 type MiningConfig struct {
-	MinerAddress            address.Address `json:"minerAddress"`
-	BlockSignerAddress      address.Address `json:"blockSignerAddress"`
-	AutoSealIntervalSeconds uint            `json:"autoSealIntervalSeconds"`
-	StoragePrice            *types.AttoFIL  `json:"storagePrice"`
+	MinerAddress       address.Address `json:"minerAddress"`
+	BlockSignerAddress address.Address `json:"blockSignerAddress"`
+	// Miners are further miner actors the node mines for alongside
+	// MinerAddress, each with its own sectors and storage deals.
+	Miners                  []MinerConfig  `json:"miners"`
+	AutoSealIntervalSeconds uint           `json:"autoSealIntervalSeconds"`
+	StoragePrice            *types.AttoFIL `json:"storagePrice"`
+}
+
+// MinerConfig identifies a miner actor the node mines for.
+type MinerConfig struct {
+	Address            address.Address `json:"address"`
+	BlockSignerAddress address.Address `json:"blockSignerAddress"`
 }
 
 func newDefaultMiningConfig() *MiningConfig {
 	return &MiningConfig{
 		MinerAddress:            address.Address{},
+		Miners:                  []MinerConfig{},
 		AutoSealIntervalSeconds: 120,
 		StoragePrice:            types.NewZeroAttoFIL(),
 	}
@@ -148,6 +161,73 @@ func newDefaultHeartbeatConfig() *HeartbeatConfig {
 	}
 }
 
+// ChainConfig holds all configuration options related to the chain and its
+// finality.
+type ChainConfig struct {
+	// MaxReorgDepth is the maximum number of blocks below the head at which
+	// the node will accept a fork.  Zero means forks of any depth are accepted.
+	MaxReorgDepth uint64 `json:"maxReorgDepth"`
+	// Checkpoint holds the block cids of a tipset that the node's chain must
+	// always include.  Forks that do not include it are refused.
+	Checkpoint types.SortedCidSet `json:"checkpoint"`
+	// CheckpointHeight is the height of the Checkpoint tipset.
+	CheckpointHeight uint64 `json:"checkpointHeight"`
+	// CheckInvariants makes the node check the invariants of the state of
+	// each tipset it processes and log those violated.  It is slow and
+	// meant for debugging.
+	CheckInvariants bool `json:"checkInvariants"`
+	// Upgrades, if set, replace the network's schedule of upgrades, see
+	// upgrade.SetSchedule.  It is for private networks, every node of which
+	// must have the same upgrades.
+	Upgrades upgrade.Schedule `json:"upgrades,omitempty"`
+}
+
+func newDefaultChainConfig() *ChainConfig {
+	return &ChainConfig{
+		MaxReorgDepth: 0,
+	}
+}
+
+// MessagePoolConfig holds all configuration options related to the message pool.
+type MessagePoolConfig struct {
+	// MaxPoolSize is the maximum number of messages the pool holds.  When it
+	// is full a new message evicts the message with the lowest gas price, if
+	// the new message pays more.
+	MaxPoolSize uint `json:"maxPoolSize"`
+	// MaxPerSender is the maximum number of messages the pool holds from a
+	// single sender.
+	MaxPerSender uint `json:"maxPerSender"`
+	// MaxNonceGap is how far ahead of its sender's current nonce a message's
+	// nonce may be.
+	MaxNonceGap uint64 `json:"maxNonceGap"`
+	// MaxMessageAgeSeconds is how long a message may stay in the pool
+	// without being included in a block.
+	MaxMessageAgeSeconds uint `json:"maxMessageAgeSeconds"`
+	// ReplaceByFeePercent is how much higher, in percent, the gas price of
+	// a message must be than that of the pending message with the same
+	// sender and nonce to replace it.
+	ReplaceByFeePercent uint `json:"replaceByFeePercent"`
+	// StuckAfterTipSets is after how many tipsets a message this node sent
+	// that is still in the pool is reported as stuck.
+	StuckAfterTipSets uint64 `json:"stuckAfterTipSets"`
+	// MinGasPrice is the least gas price suggested for messages sent without
+	// one, and the price suggested when recent blocks include no messages to
+	// take the median price from.
+	MinGasPrice *types.AttoFIL `json:"minGasPrice"`
+}
+
+func newDefaultMessagePoolConfig() *MessagePoolConfig {
+	return &MessagePoolConfig{
+		MaxPoolSize:          10000,
+		MaxPerSender:         256,
+		MaxNonceGap:          100,
+		MaxMessageAgeSeconds: 6 * 60 * 60,
+		ReplaceByFeePercent:  10,
+		StuckAfterTipSets:    10,
+		MinGasPrice:          types.NewZeroAttoFIL(),
+	}
+}
+
 // NewDefaultConfig returns a config object with all the fields filled out to
 // their default values
 func NewDefaultConfig() *Config {
@@ -159,6 +239,8 @@ func NewDefaultConfig() *Config {
 		Mining:    newDefaultMiningConfig(),
 		Wallet:    newDefaultWalletConfig(),
 		Heartbeat: newDefaultHeartbeatConfig(),
+		Chain:     newDefaultChainConfig(),
+		Mpool:     newDefaultMessagePoolConfig(),
 	}
 }
 
@@ -66,6 +66,7 @@ func TestWriteFile(t *testing.T) {
 	"mining": {
 		"minerAddress": "",
 		"blockSignerAddress": "",
+		"miners": [],
 		"autoSealIntervalSeconds": 120,
 		"storagePrice": "0"
 	},
@@ -77,6 +78,21 @@ func TestWriteFile(t *testing.T) {
 		"beatPeriod": "3s",
 		"reconnectPeriod": "10s",
 		"nickname": ""
+	},
+	"chain": {
+		"maxReorgDepth": 0,
+		"checkpoint": null,
+		"checkpointHeight": 0,
+		"checkInvariants": false
+	},
+	"mpool": {
+		"maxPoolSize": 10000,
+		"maxPerSender": 256,
+		"maxNonceGap": 100,
+		"maxMessageAgeSeconds": 21600,
+		"replaceByFeePercent": 10,
+		"stuckAfterTipSets": 10,
+		"minGasPrice": "0"
 	}
 }`,
 		string(content),
@@ -0,0 +1,45 @@
+package consensus
+
+import (
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmRu7tiRnFk9mMPpVECQTBQJqXtmG132jJxA1w9A7TtpBz/go-ipfs-blockstore"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+	cbor "gx/ipfs/QmcZLyosDwMKdB6NLRsiss9HXzDPhVhhRtPy67JFKTDQDX/go-ipld-cbor"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// StoreEvents stores the events of results in bs, under the events roots of
+// their receipts, so that they can be loaded with LoadEvents.
+func StoreEvents(bs blockstore.Blockstore, results []*ApplicationResult) error {
+	for _, r := range results {
+		if len(r.Events) == 0 {
+			continue
+		}
+		obj, err := cbor.WrapObject(r.Events, types.DefaultHashFunction, -1)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode events")
+		}
+		if err := bs.Put(obj); err != nil {
+			return errors.Wrap(err, "failed to store events")
+		}
+	}
+	return nil
+}
+
+// LoadEvents loads the events stored in bs under root, the events root of a
+// receipt. A receipt without events root has no events.
+func LoadEvents(bs blockstore.Blockstore, root cid.Cid) ([]*types.Event, error) {
+	if !root.Defined() {
+		return nil, nil
+	}
+	blk, err := bs.Get(root)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load events %s", root)
+	}
+	var events []*types.Event
+	if err := cbor.DecodeInto(blk.RawData(), &events); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode events %s", root)
+	}
+	return events, nil
+}
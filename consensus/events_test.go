@@ -0,0 +1,41 @@
+package consensus_test
+
+import (
+	"testing"
+
+	"gx/ipfs/QmRu7tiRnFk9mMPpVECQTBQJqXtmG132jJxA1w9A7TtpBz/go-ipfs-blockstore"
+	"gx/ipfs/QmUadX5EcvrBmxAV9sE7wUWtWSqxns5K84qKJBixmcT1w9/go-datastore"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	. "github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/types"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
+)
+
+func TestStoreAndLoadEvents(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+	events := []*types.Event{
+		{Emitter: address.TestAddress, Topic: "a", Data: []byte{1}},
+		{Emitter: address.TestAddress2, Topic: "b", Data: []byte{2, 3}},
+	}
+	root, err := types.EventsRoot(events)
+	require.NoError(err)
+
+	results := []*ApplicationResult{
+		{Receipt: &types.MessageReceipt{}},
+		{Receipt: &types.MessageReceipt{EventsRoot: root}, Events: events},
+	}
+	require.NoError(StoreEvents(bs, results))
+
+	loaded, err := LoadEvents(bs, root)
+	require.NoError(err)
+	assert.Equal(events, loaded)
+
+	none, err := LoadEvents(bs, results[0].Receipt.EventsRoot)
+	require.NoError(err)
+	assert.Empty(none)
+}
@@ -21,7 +21,9 @@ import (
 	"github.com/filecoin-project/go-filecoin/proofs"
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/upgrade"
 	"github.com/filecoin-project/go-filecoin/vm"
+	vmerrors "github.com/filecoin-project/go-filecoin/vm/errors"
 )
 
 var (
@@ -44,13 +46,57 @@ var (
 	ErrUnorderedTipSets = errors.New("trying to order two identical tipsets")
 )
 
+// invalidTipSetError wraps the errors of RunStateTransition that show the
+// tipset is invalid, as opposed to that it could not be validated.
+type invalidTipSetError struct {
+	err error
+}
+
+func (e *invalidTipSetError) Error() string {
+	return e.err.Error()
+}
+
+// Cause returns the underlying error.
+func (e *invalidTipSetError) Cause() error {
+	return e.err
+}
+
+func invalidTipSet(err error) error {
+	return &invalidTipSetError{err: err}
+}
+
+// IsInvalidTipSet returns true if err, returned by RunStateTransition, shows
+// the tipset is invalid under consensus: it was not mined according to the EC
+// rules, a message in it cannot be applied, or the receipts or state root in
+// its blocks do not match the computed ones.  Other errors, such as failing to
+// read the store, say nothing about the tipset.
+func IsInvalidTipSet(err error) bool {
+	_, ok := err.(*invalidTipSetError)
+	return ok
+}
+
 // TODO none of these parameters are chosen correctly
 // with respect to analysis under a security model:
 // https://github.com/filecoin-project/go-filecoin/issues/1846
 
-// ECV is the constant V defined in the EC spec.
+// ECV is the constant V defined in the EC spec, as of upgrade.Version0.
 const ECV uint64 = 10
 
+// ecvs are the values of the constant V introduced by each network version.
+var ecvs = map[upgrade.Version]uint64{
+	upgrade.Version0: ECV,
+}
+
+// ECVAt returns the constant V in effect at block height h: that of the latest
+// network version at h to introduce one.
+func ECVAt(h uint64) uint64 {
+	for v := upgrade.VersionAt(types.NewBlockHeight(h)); ; v-- {
+		if ecv, ok := ecvs[v]; ok {
+			return ecv
+		}
+	}
+}
+
 // ECPrM is the power ratio magnitude defined in the EC spec.
 const ECPrM uint64 = 100
 
@@ -134,6 +180,21 @@ func (c *Expected) validateBlockStructure(ctx context.Context, b *types.Block) e
 		return fmt.Errorf("block has nil StateRoot")
 	}
 
+	msgs, err := types.MessagesCollection(b.Messages)
+	if err != nil {
+		return errors.Wrap(err, "error validating block messages")
+	}
+	if !msgs.Root.Equals(b.MessagesRoot) {
+		return fmt.Errorf("block messages root %s does not match its messages %s", b.MessagesRoot, msgs.Root)
+	}
+	receipts, err := types.ReceiptsCollection(b.MessageReceipts)
+	if err != nil {
+		return errors.Wrap(err, "error validating block receipts")
+	}
+	if !receipts.Root.Equals(b.ReceiptsRoot) {
+		return fmt.Errorf("block receipts root %s does not match its receipts %s", b.ReceiptsRoot, receipts.Root)
+	}
+
 	return nil
 }
 
@@ -160,8 +221,12 @@ func (c *Expected) Weight(ctx context.Context, ts types.TipSet, pSt state.Tree)
 	if err != nil {
 		return uint64(0), err
 	}
+	h, err := ts.Height()
+	if err != nil {
+		return uint64(0), err
+	}
 	floatTotalBytes := new(big.Float).SetInt64(int64(totalBytes))
-	floatECV := new(big.Float).SetInt64(int64(ECV))
+	floatECV := new(big.Float).SetInt64(int64(ECVAt(h)))
 	floatECPrM := new(big.Float).SetInt64(int64(ECPrM))
 	for _, blk := range ts.ToSlice() {
 		minerBytes, err := c.PwrTableView.Miner(ctx, pSt, c.bstore, blk.Miner)
@@ -230,7 +295,8 @@ func (c *Expected) IsHeavier(ctx context.Context, a, b types.TipSet, aSt, bSt st
 // RunStateTransition is the chain transition function that goes from a
 // starting state and a tipset to a new state.  It errors if the tipset was not
 // mined according to the EC rules, or if running the messages in the tipset
-// results in an error.
+// results in an error.  Use IsInvalidTipSet to tell errors showing the tipset
+// is invalid from the others.
 func (c *Expected) RunStateTransition(ctx context.Context, ts types.TipSet, ancestors []types.TipSet, pSt state.Tree) (state.Tree, error) {
 	err := c.validateMining(ctx, pSt, ts, ancestors[0])
 	if err != nil {
@@ -250,7 +316,19 @@ func (c *Expected) RunStateTransition(ctx context.Context, ts types.TipSet, ance
 		}
 	}
 
+	parentHeight, err := ancestors[0].Height()
+	if err != nil {
+		return nil, err
+	}
+	h, err := ts.Height()
+	if err != nil {
+		return nil, err
+	}
+
 	vms := vm.NewStorageMap(c.bstore)
+	if err := RunUpgrades(ctx, pSt, vms, parentHeight, h); err != nil {
+		return nil, err
+	}
 	st, err := c.runMessages(ctx, pSt, vms, ts, ancestors)
 	if err != nil {
 		return nil, err
@@ -288,13 +366,13 @@ func (c *Expected) validateMining(ctx context.Context, st state.Tree, ts types.T
 			return errors.Wrap(err, "could not test the proof's validity")
 		}
 		if !isValid {
-			return errors.New("invalid proof")
+			return invalidTipSet(errors.New("invalid proof"))
 		}
 
 		computedTicket := CreateTicket(blk.Proof, blk.Miner)
 
 		if !bytes.Equal(blk.Ticket, computedTicket) {
-			return errors.New("ticket incorrectly computed")
+			return invalidTipSet(errors.New("ticket incorrectly computed"))
 		}
 
 		// TODO: Also need to validate BlockSig
@@ -306,7 +384,7 @@ func (c *Expected) validateMining(ctx context.Context, st state.Tree, ts types.T
 		}
 
 		if !result {
-			return errors.New("not a winning ticket")
+			return invalidTipSet(errors.New("not a winning ticket"))
 		}
 	}
 	return nil
@@ -398,12 +476,31 @@ func (c *Expected) runMessages(ctx context.Context, st state.Tree, vms vm.Storag
 		}
 
 		receipts, err := c.processor.ProcessBlock(ctx, cpySt, vms, blk, ancestors)
+		if vmerrors.IsApplyErrorPermanent(err) || vmerrors.IsApplyErrorTemporary(err) {
+			return nil, invalidTipSet(errors.Wrap(err, "error validating block state"))
+		}
 		if err != nil {
 			return nil, errors.Wrap(err, "error validating block state")
 		}
-		// TODO: check that receipts actually match
 		if len(receipts) != len(blk.MessageReceipts) {
-			return nil, fmt.Errorf("found invalid message receipts: %v %v", receipts, blk.MessageReceipts)
+			return nil, invalidTipSet(fmt.Errorf("found invalid message receipts: %v %v", receipts, blk.MessageReceipts))
+		}
+		computed := make([]*types.MessageReceipt, len(receipts))
+		for i, r := range receipts {
+			if !r.Receipt.EventsRoot.Equals(blk.MessageReceipts[i].EventsRoot) {
+				return nil, invalidTipSet(fmt.Errorf("found invalid events root in receipt %d: %s %s", i, r.Receipt.EventsRoot, blk.MessageReceipts[i].EventsRoot))
+			}
+			computed[i] = r.Receipt
+		}
+		computedReceipts, err := types.ReceiptsCollection(computed)
+		if err != nil {
+			return nil, errors.Wrap(err, "error validating block receipts")
+		}
+		if !computedReceipts.Root.Equals(blk.ReceiptsRoot) {
+			return nil, invalidTipSet(fmt.Errorf("found invalid receipts root: %s %s", computedReceipts.Root, blk.ReceiptsRoot))
+		}
+		if err := StoreEvents(c.bstore, receipts); err != nil {
+			return nil, errors.Wrap(err, "error validating block state")
 		}
 
 		outCid, err := cpySt.Flush(ctx)
@@ -411,7 +508,7 @@ func (c *Expected) runMessages(ctx context.Context, st state.Tree, vms vm.Storag
 			return nil, errors.Wrap(err, "error validating block state")
 		}
 		if !outCid.Equals(blk.StateRoot) {
-			return nil, ErrStateRootMismatch
+			return nil, invalidTipSet(ErrStateRootMismatch)
 		}
 	}
 	if len(ts) == 1 { // block validation state == aggregate parent state
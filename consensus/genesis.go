@@ -2,8 +2,10 @@ package consensus
 
 import (
 	"context"
+	"fmt"
 
 	"gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 	"gx/ipfs/QmRu7tiRnFk9mMPpVECQTBQJqXtmG132jJxA1w9A7TtpBz/go-ipfs-blockstore"
 
 	"github.com/filecoin-project/go-filecoin/actor"
@@ -37,6 +39,16 @@ type Config struct {
 	accounts map[address.Address]*types.AttoFIL
 	nonces   map[address.Address]uint64
 	actors   map[address.Address]*actor.Actor
+	inits    []actorInit
+}
+
+// actorInit describes an actor genesis creates by initializing the state of
+// its code.
+type actorInit struct {
+	addr            address.Address
+	code            cid.Cid
+	balance         *types.AttoFIL
+	initializerData interface{}
 }
 
 // GenOption is a configuration option for the GenesisInitFunction.
@@ -59,8 +71,8 @@ func ActorNonce(addr address.Address, nonce uint64) GenOption {
 }
 
 // AddActor returns a config option that sets an arbitrary actor. You
-// will need to set the mapping from the actor's codecid to implementation
-// in builtin.Actors if it is not there already.
+// will need to register the implementation of the actor's code with
+// builtin.Register if it is not there already.
 func AddActor(addr address.Address, actor *actor.Actor) GenOption {
 	return func(gc *Config) error {
 		gc.actors[addr] = actor
@@ -68,6 +80,24 @@ func AddActor(addr address.Address, actor *actor.Actor) GenOption {
 	}
 }
 
+// InitActor returns a config option that creates an actor at addr running the
+// registered code, with the given balance, and initializes its state from
+// initializerData the way the code's InitializeState does.
+func InitActor(addr address.Address, code cid.Cid, balance *types.AttoFIL, initializerData interface{}) GenOption {
+	return func(gc *Config) error {
+		if _, ok := builtin.Actors[code]; !ok {
+			return fmt.Errorf("no actor registered for code %s", code)
+		}
+		gc.inits = append(gc.inits, actorInit{
+			addr:            addr,
+			code:            code,
+			balance:         balance,
+			initializerData: initializerData,
+		})
+		return nil
+	}
+}
+
 // NewEmptyConfig inits and returns an empty config
 func NewEmptyConfig() *Config {
 	return &Config{
@@ -121,6 +151,11 @@ func MakeGenesisFunc(opts ...GenOption) func(cst *hamt.CborIpldStore, bs blockst
 				return nil, err
 			}
 		}
+		for _, ai := range genCfg.inits {
+			if err := SetupActor(ctx, st, storageMap, ai.addr, ai.code, ai.balance, ai.initializerData); err != nil {
+				return nil, err
+			}
+		}
 
 		c, err := st.Flush(ctx)
 		if err != nil {
@@ -150,6 +185,22 @@ func InitGenesis(cst *hamt.CborIpldStore, bs blockstore.Blockstore) (*types.Bloc
 	return MakeGenesisFunc()(cst, bs)
 }
 
+// SetupActor creates an actor at addr running code with the given balance and
+// initializes its state with the InitializeState of the implementation
+// registered for code.
+func SetupActor(ctx context.Context, st state.Tree, storageMap vm.StorageMap, addr address.Address, code cid.Cid, balance *types.AttoFIL, initializerData interface{}) error {
+	impl, err := st.GetBuiltinActorCode(code)
+	if err != nil {
+		return err
+	}
+
+	act := actor.NewActor(code, balance)
+	if err := impl.InitializeState(storageMap.NewStorage(addr, act), initializerData); err != nil {
+		return err
+	}
+	return st.SetActor(ctx, addr, act)
+}
+
 // SetupDefaultActors inits the builtin actors that are required to run filecoin.
 func SetupDefaultActors(ctx context.Context, st state.Tree, storageMap vm.StorageMap) error {
 	for addr, val := range defaultAccounts {
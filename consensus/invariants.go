@@ -0,0 +1,188 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+// The invariants CheckInvariants checks.
+const (
+	// SupplyInvariant is that the balances of all actors sum to the supply
+	// of the network. Block rewards are paid by the network actor, so FIL is
+	// never minted and the supply is that of genesis.
+	SupplyInvariant = "supply"
+	// PowerInvariant is that the power of all miners sums to the storage
+	// committed in the storage market.
+	PowerInvariant = "power"
+	// PaymentBrokerInvariant is that the balance of the payment broker is
+	// the amount left in its channels.
+	PaymentBrokerInvariant = "paymentbroker"
+	// NonceInvariant is that only accounts, which are the only actors that
+	// send messages, have a nonce, and that the nonce of an account grows by
+	// one for each of its messages that is applied. The latter needs the
+	// messages, so CheckInvariants checks only the former; CheckNonces checks
+	// the latter.
+	NonceInvariant = "nonce"
+)
+
+// Violation describes an invariant a state does not hold.
+type Violation struct {
+	Invariant string `json:"invariant"`
+	Message   string `json:"message"`
+}
+
+func (v *Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Invariant, v.Message)
+}
+
+// TotalBalance returns the sum of the balances of all actors in st.
+func TotalBalance(ctx context.Context, st state.Tree) (*types.AttoFIL, error) {
+	// Walk a flushed tree so the walk sees all of its actors.
+	if _, err := st.Flush(ctx); err != nil {
+		return nil, err
+	}
+	total := types.NewZeroAttoFIL()
+	err := st.ForEachActor(ctx, func(addr address.Address, act *actor.Actor) error {
+		total = total.Add(act.Balance)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return total, nil
+}
+
+// CheckInvariants walks st and returns the violations of the invariants
+// above. supply is the total FIL of the network, usually the TotalBalance of
+// the genesis state. It returns an error only if the state cannot be read.
+func CheckInvariants(ctx context.Context, st state.Tree, vms vm.StorageMap, supply *types.AttoFIL) ([]*Violation, error) {
+	var violations []*Violation
+	violate := func(invariant, format string, args ...interface{}) {
+		violations = append(violations, &Violation{Invariant: invariant, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if _, err := st.Flush(ctx); err != nil {
+		return nil, err
+	}
+	total := types.NewZeroAttoFIL()
+	power := big.NewInt(0)
+	err := st.ForEachActor(ctx, func(addr address.Address, act *actor.Actor) error {
+		total = total.Add(act.Balance)
+
+		if act.Nonce != 0 && !act.Code.Equals(types.AccountActorCodeCid) {
+			violate(NonceInvariant, "actor %s is not an account but has nonce %d", addr, act.Nonce)
+		}
+
+		if act.Code.Equals(types.MinerActorCodeCid) || act.Code.Equals(types.BootstrapMinerActorCodeCid) {
+			var mst miner.State
+			if err := readActorState(vms, addr, act, &mst); err != nil {
+				return errors.Wrapf(err, "failed to read state of miner %s", addr)
+			}
+			if mst.Power != nil {
+				power.Add(power, mst.Power)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !total.Equal(supply) {
+		violate(SupplyInvariant, "actors hold %s FIL, the supply is %s FIL", total, supply)
+	}
+
+	smAct, err := st.GetActor(ctx, address.StorageMarketAddress)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get storage market")
+	}
+	var smState storagemarket.State
+	if err := readActorState(vms, address.StorageMarketAddress, smAct, &smState); err != nil {
+		return nil, errors.Wrap(err, "failed to read state of storage market")
+	}
+	if smState.TotalCommittedStorage == nil || smState.TotalCommittedStorage.Cmp(power) != 0 {
+		violate(PowerInvariant, "miners have %s power, the storage market has %s committed", power, smState.TotalCommittedStorage)
+	}
+
+	pbAct, err := st.GetActor(ctx, address.PaymentBrokerAddress)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get payment broker")
+	}
+	unredeemed, err := paymentbroker.TotalUnredeemed(ctx, vms.NewStorage(address.PaymentBrokerAddress, pbAct))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read payment channels")
+	}
+	if !unredeemed.Equal(pbAct.Balance) {
+		violate(PaymentBrokerInvariant, "payment broker holds %s FIL, its channels %s FIL", pbAct.Balance, unredeemed)
+	}
+
+	return violations, nil
+}
+
+// SenderNonces returns the nonces in st of the senders of msgs, to pass to
+// CheckNonces once msgs are applied. Senders without an actor have nonce 0.
+func SenderNonces(ctx context.Context, st state.Tree, msgs []*types.SignedMessage) (map[address.Address]uint64, error) {
+	nonces := make(map[address.Address]uint64)
+	for _, msg := range msgs {
+		if _, ok := nonces[msg.From]; ok {
+			continue
+		}
+		act, err := st.GetActor(ctx, msg.From)
+		if err != nil && !state.IsActorNotFoundError(err) {
+			return nil, errors.Wrapf(err, "failed to get actor %s", msg.From)
+		}
+		nonces[msg.From] = 0
+		if act != nil {
+			nonces[msg.From] = act.Nonce
+		}
+	}
+	return nonces, nil
+}
+
+// CheckNonces returns the violations of NonceInvariant by the senders of
+// applied, the messages applied to a state whose SenderNonces were before to
+// get st: the nonce of each sender in st must be its nonce in before plus the
+// number of its messages in applied.
+func CheckNonces(ctx context.Context, st state.Tree, before map[address.Address]uint64, applied []*types.SignedMessage) ([]*Violation, error) {
+	sent := make(map[address.Address]uint64)
+	for _, msg := range applied {
+		sent[msg.From]++
+	}
+
+	var violations []*Violation
+	for addr, n := range sent {
+		act, err := st.GetActor(ctx, addr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get actor %s", addr)
+		}
+		if want := before[addr] + n; act.Nonce != want {
+			violations = append(violations, &Violation{
+				Invariant: NonceInvariant,
+				Message:   fmt.Sprintf("account %s sent %d messages from nonce %d but has nonce %d", addr, n, before[addr], act.Nonce),
+			})
+		}
+	}
+	sort.Slice(violations, func(i, j int) bool { return violations[i].Message < violations[j].Message })
+	return violations, nil
+}
+
+func readActorState(vms vm.StorageMap, addr address.Address, act *actor.Actor, out interface{}) error {
+	chunk, err := vms.NewStorage(addr, act).Get(act.Head)
+	if err != nil {
+		return err
+	}
+	return actor.UnmarshalStorage(chunk, out)
+}
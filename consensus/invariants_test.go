@@ -0,0 +1,153 @@
+package consensus_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
+	"gx/ipfs/QmRu7tiRnFk9mMPpVECQTBQJqXtmG132jJxA1w9A7TtpBz/go-ipfs-blockstore"
+	"gx/ipfs/QmUadX5EcvrBmxAV9sE7wUWtWSqxns5K84qKJBixmcT1w9/go-datastore"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
+	"github.com/filecoin-project/go-filecoin/address"
+	. "github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+func TestCheckInvariants(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	// genesisState returns the state of a new genesis block and its supply.
+	genesisState := func(require *require.Assertions) (state.Tree, vm.StorageMap, *types.AttoFIL) {
+		cst := hamt.NewCborStore()
+		bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+		blk, err := InitGenesis(cst, bs)
+		require.NoError(err)
+		st, err := state.LoadStateTree(ctx, cst, blk.StateRoot, builtin.Actors)
+		require.NoError(err)
+		supply, err := TotalBalance(ctx, st)
+		require.NoError(err)
+		return st, vm.NewStorageMap(bs), supply
+	}
+
+	invariants := func(violations []*Violation) []string {
+		var out []string
+		for _, v := range violations {
+			out = append(out, v.Invariant)
+		}
+		return out
+	}
+
+	t.Run("genesis holds all invariants", func(t *testing.T) {
+		require := require.New(t)
+
+		st, vms, supply := genesisState(require)
+		assert.Equal(t, types.NewAttoFILFromFIL(10000110000), supply)
+
+		violations, err := CheckInvariants(ctx, st, vms, supply)
+		require.NoError(err)
+		assert.Empty(t, violations)
+	})
+
+	t.Run("FIL out of thin air violates the supply", func(t *testing.T) {
+		require := require.New(t)
+
+		st, vms, supply := genesisState(require)
+		act, err := st.GetActor(ctx, address.TestAddress)
+		require.NoError(err)
+		act.Balance = act.Balance.Add(types.NewAttoFILFromFIL(1))
+		require.NoError(st.SetActor(ctx, address.TestAddress, act))
+
+		violations, err := CheckInvariants(ctx, st, vms, supply)
+		require.NoError(err)
+		assert.Equal(t, []string{SupplyInvariant}, invariants(violations))
+	})
+
+	t.Run("FIL in the payment broker must be in channels", func(t *testing.T) {
+		require := require.New(t)
+
+		st, vms, supply := genesisState(require)
+		from, err := st.GetActor(ctx, address.TestAddress)
+		require.NoError(err)
+		to, err := st.GetActor(ctx, address.PaymentBrokerAddress)
+		require.NoError(err)
+		require.NoError(vm.Transfer(from, to, types.NewAttoFILFromFIL(1)))
+		require.NoError(st.SetActor(ctx, address.TestAddress, from))
+		require.NoError(st.SetActor(ctx, address.PaymentBrokerAddress, to))
+
+		violations, err := CheckInvariants(ctx, st, vms, supply)
+		require.NoError(err)
+		assert.Equal(t, []string{PaymentBrokerInvariant}, invariants(violations))
+	})
+
+	t.Run("committed storage must be the power of miners", func(t *testing.T) {
+		require := require.New(t)
+
+		st, vms, supply := genesisState(require)
+		migrate := RewriteActorState(address.StorageMarketAddress, func(storage exec.Storage) error {
+			head, err := storage.Put(&storagemarket.State{TotalCommittedStorage: big.NewInt(3)})
+			if err != nil {
+				return err
+			}
+			return storage.Commit(head, storage.Head())
+		})
+		require.NoError(migrate(ctx, st, vms))
+
+		violations, err := CheckInvariants(ctx, st, vms, supply)
+		require.NoError(err)
+		assert.Equal(t, []string{PowerInvariant}, invariants(violations))
+	})
+
+	t.Run("only accounts have nonces", func(t *testing.T) {
+		require := require.New(t)
+
+		st, vms, supply := genesisState(require)
+		act, err := st.GetActor(ctx, address.StorageMarketAddress)
+		require.NoError(err)
+		act.IncNonce()
+		require.NoError(st.SetActor(ctx, address.StorageMarketAddress, act))
+
+		violations, err := CheckInvariants(ctx, st, vms, supply)
+		require.NoError(err)
+		assert.Equal(t, []string{NonceInvariant}, invariants(violations))
+	})
+
+	t.Run("account nonces grow by the messages applied", func(t *testing.T) {
+		require := require.New(t)
+
+		st, _, _ := genesisState(require)
+		mockSigner := types.NewMockSigner(types.MustGenerateKeyInfo(1, types.GenerateKeyInfoSeed()))
+		sender := mockSigner.Addresses[0]
+		act, err := account.NewActor(types.NewAttoFILFromFIL(100))
+		require.NoError(err)
+		require.NoError(st.SetActor(ctx, sender, act))
+
+		newMsg := types.NewSignedMessageSequenceForTestGetter(mockSigner)
+		msgs := []*types.SignedMessage{newMsg(), newMsg()}
+		before, err := SenderNonces(ctx, st, msgs)
+		require.NoError(err)
+		assert.Equal(t, map[address.Address]uint64{sender: 0}, before)
+
+		act.IncNonce()
+		act.IncNonce()
+		require.NoError(st.SetActor(ctx, sender, act))
+
+		violations, err := CheckNonces(ctx, st, before, msgs)
+		require.NoError(err)
+		assert.Empty(t, violations)
+
+		violations, err = CheckNonces(ctx, st, before, msgs[:1])
+		require.NoError(err)
+		assert.Equal(t, []string{NonceInvariant}, invariants(violations))
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
 	"github.com/filecoin-project/go-filecoin/vm"
@@ -36,6 +37,11 @@ type BlockRewarder interface {
 type ApplicationResult struct {
 	Receipt        *types.MessageReceipt
 	ExecutionError error
+	// GasUsed is the gas charged for applying the message.
+	GasUsed types.GasUnits
+	// Events are the events emitted while applying the message, which the
+	// receipt commits to. A failed message emits none.
+	Events []*types.Event
 }
 
 // ProcessTipSetResponse records the results of successfully applied messages,
@@ -52,24 +58,40 @@ type ProcessTipSetResponse struct {
 type DefaultProcessor struct {
 	signedMessageValidator SignedMessageValidator
 	blockRewarder          BlockRewarder
+	// checkInvariants is whether ProcessTipSet checks the invariants of the
+	// states it computes, see CheckInvariants.
+	checkInvariants bool
 }
 
 var _ Processor = (*DefaultProcessor)(nil)
 
-// NewDefaultProcessor creates a default processor from the given state tree and vms.
-func NewDefaultProcessor() *DefaultProcessor {
-	return &DefaultProcessor{
-		signedMessageValidator: NewDefaultMessageValidator(),
-		blockRewarder:          NewDefaultBlockRewarder(),
+// ProcessorOption is the type of the processor's functional options.
+type ProcessorOption func(*DefaultProcessor)
+
+// WithInvariantChecks returns an option that makes ProcessTipSet check the
+// invariants of each state it computes and log those violated. Checking walks
+// the whole state tree, so it is meant for debugging.
+func WithInvariantChecks(check bool) ProcessorOption {
+	return func(p *DefaultProcessor) {
+		p.checkInvariants = check
 	}
 }
 
+// NewDefaultProcessor creates a default processor from the given state tree and vms.
+func NewDefaultProcessor(options ...ProcessorOption) *DefaultProcessor {
+	return NewConfiguredProcessor(NewDefaultMessageValidator(), NewDefaultBlockRewarder(), options...)
+}
+
 // NewConfiguredProcessor creates a default processor with custom validation and rewards.
-func NewConfiguredProcessor(validator SignedMessageValidator, rewarder BlockRewarder) *DefaultProcessor {
-	return &DefaultProcessor{
+func NewConfiguredProcessor(validator SignedMessageValidator, rewarder BlockRewarder, options ...ProcessorOption) *DefaultProcessor {
+	p := &DefaultProcessor{
 		signedMessageValidator: validator,
 		blockRewarder:          rewarder,
 	}
+	for _, option := range options {
+		option(p)
+	}
+	return p
 }
 
 // ProcessBlock is the entrypoint for validating the state transitions
@@ -129,7 +151,9 @@ func (p *DefaultProcessor) ProcessBlock(ctx context.Context, st state.Tree, vms
 // ProcessTipSet only returns errors in the case of faults.  Other errors
 // coming from calls to ApplyMessage can be traced to different blocks in the
 // TipSet containing conflicting messages and are ignored.  Blocks are applied
-// in the sorted order of their tickets.
+// in the sorted order of their tickets.  With WithInvariantChecks the
+// invariants the resulting state violates are logged, see CheckInvariants
+// and CheckNonces.
 func (p *DefaultProcessor) ProcessTipSet(ctx context.Context, st state.Tree, vms vm.StorageMap, ts types.TipSet, ancestors []types.TipSet) (*ProcessTipSetResponse, error) {
 	var res ProcessTipSetResponse
 	var emptyRes ProcessTipSetResponse
@@ -140,9 +164,27 @@ func (p *DefaultProcessor) ProcessTipSet(ctx context.Context, st state.Tree, vms
 	bh := types.NewBlockHeight(h)
 	msgFilter := make(map[string]struct{})
 
+	// The supply is that before the tipset, as no FIL is minted. The
+	// checks are for debugging, their errors don't fail the tipset.
 	tips := ts.ToSlice()
 	types.SortBlocks(tips)
 
+	var supply *types.AttoFIL
+	var nonces map[address.Address]uint64
+	var applied []*types.SignedMessage
+	if p.checkInvariants {
+		if supply, err = TotalBalance(ctx, st); err != nil {
+			log.Errorf("not checking invariants of tipset at height %d: failed to compute supply: %s", h, err)
+		}
+		var msgs []*types.SignedMessage
+		for _, blk := range tips {
+			msgs = append(msgs, blk.Messages...)
+		}
+		if nonces, err = SenderNonces(ctx, st, msgs); err != nil {
+			log.Errorf("not checking nonces of tipset at height %d: %s", h, err)
+		}
+	}
+
 	// TODO: this can be made slightly more efficient by reusing the validation
 	// transition of the first validated block (change would reach here and
 	// consensus functions).
@@ -167,6 +209,7 @@ func (p *DefaultProcessor) ProcessTipSet(ctx context.Context, st state.Tree, vms
 			return &emptyRes, err
 		}
 		res.Results = append(res.Results, amRes.Results...)
+		applied = append(applied, amRes.SuccessfulMessages...)
 		for _, msg := range amRes.SuccessfulMessages {
 			mCid, err := msg.Cid()
 			if err != nil {
@@ -190,6 +233,25 @@ func (p *DefaultProcessor) ProcessTipSet(ctx context.Context, st state.Tree, vms
 		}
 	}
 
+	if supply != nil {
+		violations, err := CheckInvariants(ctx, st, vms, supply)
+		if err != nil {
+			log.Errorf("failed to check invariants of tipset at height %d: %s", h, err)
+		}
+		for _, v := range violations {
+			log.Errorf("tipset at height %d violates invariant %s", h, v)
+		}
+	}
+	if nonces != nil {
+		violations, err := CheckNonces(ctx, st, nonces, applied)
+		if err != nil {
+			log.Errorf("failed to check nonces of tipset at height %d: %s", h, err)
+		}
+		for _, v := range violations {
+			log.Errorf("tipset at height %d violates invariant %s", h, v)
+		}
+	}
+
 	return &res, nil
 }
 
@@ -255,6 +317,12 @@ func (p *DefaultProcessor) ProcessTipSet(ctx context.Context, st state.Tree, vms
 //   - everything else: successfully applied (include, keep changes)
 //
 func (p *DefaultProcessor) ApplyMessage(ctx context.Context, st state.Tree, vms vm.StorageMap, msg *types.SignedMessage, minerAddr address.Address, bh *types.BlockHeight, gasTracker *vm.GasTracker, ancestors []types.TipSet) (*ApplicationResult, error) {
+	return p.applyMessage(ctx, st, vms, msg, minerAddr, bh, gasTracker, ancestors, nil)
+}
+
+// applyMessage is ApplyMessage recording the execution of the message into
+// trace if it is not nil.
+func (p *DefaultProcessor) applyMessage(ctx context.Context, st state.Tree, vms vm.StorageMap, msg *types.SignedMessage, minerAddr address.Address, bh *types.BlockHeight, gasTracker *vm.GasTracker, ancestors []types.TipSet, trace *vm.Trace) (*ApplicationResult, error) {
 
 	// used for log timer call below
 	msgCid, err := msg.Cid()
@@ -269,7 +337,8 @@ func (p *DefaultProcessor) ApplyMessage(ctx context.Context, st state.Tree, vms
 
 	cachedStateTree := state.NewCachedStateTree(st)
 
-	r, err := p.attemptApplyMessage(ctx, cachedStateTree, vms, msg, bh, gasTracker, ancestors)
+	events := &vm.EventLog{}
+	r, err := p.attemptApplyMessage(ctx, cachedStateTree, vms, msg, bh, gasTracker, ancestors, trace, events)
 	if err == nil {
 		err = cachedStateTree.Commit(ctx)
 		if err != nil {
@@ -315,7 +384,11 @@ func (p *DefaultProcessor) ApplyMessage(ctx context.Context, st state.Tree, vms
 		return nil, errors.FaultErrorWrap(err, "could not set from actor after inc nonce")
 	}
 
-	return &ApplicationResult{Receipt: r, ExecutionError: executionError}, nil
+	res := &ApplicationResult{Receipt: r, ExecutionError: executionError, GasUsed: gasTracker.GasConsumedByMessage()}
+	if executionError == nil {
+		res.Events = events.Events
+	}
+	return res, nil
 }
 
 var (
@@ -396,6 +469,12 @@ func PreviewQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, t
 	gasTracker := vm.NewGasTracker()
 	gasTracker.MsgGasLimit = types.BlockGasLimit
 
+	// Include the signature verification the message will be charged for
+	// when it is applied.
+	if err := gasTracker.Charge(vm.GasScheduleAt(optBh).SignatureVerification); err != nil {
+		return types.NewGasUnits(0), errors.ApplyErrorPermanentWrapf(err, "insufficient gas")
+	}
+
 	vmCtxParams := vm.NewContextParams{
 		To:          toActor,
 		Message:     msg,
@@ -410,12 +489,128 @@ func PreviewQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, t
 	return vmCtx.GasUnits(), err
 }
 
+// SimulationResult is the outcome of simulating a message.
+type SimulationResult struct {
+	// Receipt is the receipt the message would get. Its gas charge is at the
+	// given gas price.
+	Receipt *types.MessageReceipt
+	GasUsed types.GasUnits
+	// ExecutionError is set if the message would fail in the vm, in which
+	// case only the sender's nonce changes.
+	ExecutionError error
+	// Changes are the actors the message would change.
+	Changes []*state.ActorDiff
+	Trace   *vm.Trace
+	// Events are the events the message would emit.
+	Events []*types.Event
+}
+
+// SimulateMessage applies the unsigned message msg to a throwaway cache over
+// st and reports what it would do. Neither st nor the storage in vms is
+// changed. Unlike ApplyMessage it does not check the message's signature or
+// nonce, though it charges gas for verifying the signature. The sender is
+// debited the gas at gasPrice, as in ApplyMessage, but no miner is paid it,
+// so the changes include the sender's gas payment but no gas reward. The
+// sender must exist and be able to pay the message's value and gas. Block
+// height bh is optional; some methods will ignore it.
+func SimulateMessage(ctx context.Context, st state.Tree, vms vm.StorageMap, msg *types.Message, gasPrice types.AttoFIL, bh *types.BlockHeight, ancestors []types.TipSet) (*SimulationResult, error) {
+	cachedSt := state.NewCachedStateTree(st)
+
+	fromActor, err := cachedSt.GetActor(ctx, msg.From)
+	if err != nil {
+		return nil, errors.ApplyErrorPermanentWrapf(err, "failed to get From actor %s", msg.From)
+	}
+	toActor, err := cachedSt.GetOrCreateActor(ctx, msg.To, func() (*actor.Actor, error) {
+		return &actor.Actor{}, nil
+	})
+	if err != nil {
+		return nil, errors.FaultErrorWrap(err, "failed to get To actor")
+	}
+
+	// Set the gas limit to the max so the message only fails for lack of gas
+	// if it could never be mined.
+	gasTracker := vm.NewGasTracker()
+	gasTracker.MsgGasLimit = types.BlockGasLimit
+
+	// As in ApplyMessage, the sender pays for the verification of its
+	// signature.
+	if err := gasTracker.Charge(vm.GasScheduleAt(bh).SignatureVerification); err != nil {
+		return nil, errors.ApplyErrorPermanentWrapf(err, "insufficient gas")
+	}
+
+	trace := vm.NewTrace(msg)
+	events := &vm.EventLog{}
+	vmCtxParams := vm.NewContextParams{
+		From:        fromActor,
+		To:          toActor,
+		Message:     msg,
+		State:       cachedSt,
+		StorageMap:  vms,
+		GasTracker:  gasTracker,
+		BlockHeight: bh,
+		Ancestors:   ancestors,
+		LookBack:    LookBackParameter,
+		Trace:       trace,
+		Events:      events,
+	}
+	vmCtx := vm.NewVMContext(vmCtxParams)
+
+	ret, exitCode, vmErr := vm.Send(ctx, vmCtx)
+	if errors.IsFault(vmErr) {
+		return nil, vmErr
+	}
+
+	// As in ApplyMessage, a failed message changes nothing but the nonce.
+	if vmErr != nil {
+		cachedSt = state.NewCachedStateTree(st)
+		if fromActor, err = cachedSt.GetActor(ctx, msg.From); err != nil {
+			return nil, errors.FaultErrorWrap(err, "couldn't load from actor")
+		}
+	}
+	fromActor.IncNonce()
+
+	gasCharge := gasPrice.MulBigInt(big.NewInt(int64(vmCtx.GasUnits())))
+	if fromActor.Balance.LessThan(gasCharge) {
+		return nil, errInsufficientGas
+	}
+	fromActor.Balance = fromActor.Balance.Sub(gasCharge)
+
+	changes, err := cachedSt.Changes(ctx)
+	if err != nil {
+		return nil, errors.FaultErrorWrap(err, "failed to compute state changes")
+	}
+
+	receipt := &types.MessageReceipt{
+		ExitCode:   exitCode,
+		GasAttoFIL: gasCharge,
+	}
+	for _, b := range ret {
+		receipt.Return = append(receipt.Return, b)
+	}
+
+	res := &SimulationResult{
+		Receipt:        receipt,
+		GasUsed:        vmCtx.GasUnits(),
+		ExecutionError: vmErr,
+		Changes:        changes,
+		Trace:          trace,
+	}
+	if vmErr == nil && vm.EventsEnabledAt(bh) {
+		res.Events = events.Events
+		receipt.EventsRoot, err = types.EventsRoot(events.Events)
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "failed to compute events root")
+		}
+	}
+	return res, nil
+}
+
 // attemptApplyMessage encapsulates the work of trying to apply the message in order
 // to make ApplyMessage more readable. The distinction is that attemptApplyMessage
 // should deal with trying to apply the message to the state tree whereas
 // ApplyMessage should deal with any side effects and how it should be presented
 // to the caller. attemptApplyMessage should only be called from ApplyMessage.
-func (p *DefaultProcessor) attemptApplyMessage(ctx context.Context, st *state.CachedTree, store vm.StorageMap, msg *types.SignedMessage, bh *types.BlockHeight, gasTracker *vm.GasTracker, ancestors []types.TipSet) (*types.MessageReceipt, error) {
+func (p *DefaultProcessor) attemptApplyMessage(ctx context.Context, st *state.CachedTree, store vm.StorageMap, msg *types.SignedMessage, bh *types.BlockHeight, gasTracker *vm.GasTracker, ancestors []types.TipSet, trace *vm.Trace, events *vm.EventLog) (*types.MessageReceipt, error) {
 	gasTracker.ResetForNewMessage(msg.MeteredMessage)
 	if err := blockGasLimitError(gasTracker); err != nil {
 		return &types.MessageReceipt{
@@ -450,6 +645,14 @@ func (p *DefaultProcessor) attemptApplyMessage(ctx context.Context, st *state.Ca
 		}, err
 	}
 
+	// The sender pays for the verification of its signature.
+	if err := gasTracker.Charge(vm.GasScheduleAt(bh).SignatureVerification); err != nil {
+		return &types.MessageReceipt{
+			ExitCode:   exec.ErrInsufficientGas,
+			GasAttoFIL: msg.GasPrice.MulBigInt(big.NewInt(int64(gasTracker.GasConsumedByMessage()))),
+		}, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
 	toActor, err := st.GetOrCreateActor(ctx, msg.To, func() (*actor.Actor, error) {
 		// Addresses are deterministic so sending a message to a non-existent address must not install an actor,
 		// else actors could be installed ahead of address activation. So here we create the empty, upgradable
@@ -470,6 +673,8 @@ func (p *DefaultProcessor) attemptApplyMessage(ctx context.Context, st *state.Ca
 		BlockHeight: bh,
 		Ancestors:   ancestors,
 		LookBack:    LookBackParameter,
+		Trace:       trace,
+		Events:      events,
 	}
 	vmCtx := vm.NewVMContext(vmCtxParams)
 
@@ -492,6 +697,13 @@ func (p *DefaultProcessor) attemptApplyMessage(ctx context.Context, st *state.Ca
 		receipt.Return = append(receipt.Return, b)
 	}
 
+	if vmErr == nil && vm.EventsEnabledAt(bh) {
+		receipt.EventsRoot, err = types.EventsRoot(events.Events)
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "failed to compute events root")
+		}
+	}
+
 	return receipt, vmErr
 }
 
@@ -2,6 +2,7 @@ package consensus_test
 
 import (
 	"context"
+	"math/big"
 	"testing"
 
 	"gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
@@ -17,6 +18,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/state"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/upgrade"
 	"github.com/filecoin-project/go-filecoin/vm"
 	"github.com/filecoin-project/go-filecoin/vm/errors"
 	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
@@ -159,6 +161,41 @@ func TestProcessTipSetSuccess(t *testing.T) {
 	assert.True(expStCid.Equals(gotStCid))
 }
 
+func TestProcessTipSetCheckingInvariants(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	newAddress := address.NewForTestGetter()
+	ctx := context.Background()
+	cst := hamt.NewCborStore()
+	vms := th.VMStorage()
+
+	ki := types.MustGenerateKeyInfo(1, types.GenerateKeyInfoSeed())
+	mockSigner := types.NewMockSigner(ki)
+	fromAddr := mockSigner.Addresses[0]
+	stCid, st := th.RequireMakeStateTree(require, cst, map[address.Address]*actor.Actor{
+		address.NetworkAddress: th.RequireNewAccountActor(require, types.NewAttoFILFromFIL(1000000)),
+		fromAddr:               th.RequireNewAccountActor(require, types.NewAttoFILFromFIL(10000)),
+	})
+
+	msg := types.NewMessage(fromAddr, newAddress(), 0, types.NewAttoFILFromFIL(550), "", nil)
+	smsg, err := types.NewSignedMessage(*msg, &mockSigner, types.NewGasPrice(0), types.NewGasUnits(0))
+	require.NoError(err)
+	blk := &types.Block{
+		Height:    20,
+		StateRoot: stCid,
+		Messages:  []*types.SignedMessage{smsg},
+		Miner:     newAddress(),
+	}
+
+	// The state has no storage market, so the invariants can't be checked.
+	// That is logged rather than failing the tipset.
+	res, err := NewDefaultProcessor(WithInvariantChecks(true)).ProcessTipSet(ctx, st, vms, th.RequireNewTipSet(require, blk), nil)
+	require.NoError(err)
+	assert.Len(res.Results, 1)
+	assert.Equal(1, res.Successes.Len())
+}
+
 func TestProcessTipsConflicts(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
@@ -327,7 +364,7 @@ func TestProcessBlockVMErrors(t *testing.T) {
 		toAddr:                 act2,
 	})
 	msg := types.NewMessage(fromAddr, toAddr, 0, nil, "returnRevertError", nil)
-	smsg, err := types.NewSignedMessage(*msg, &mockSigner, types.NewGasPrice(0), types.NewGasUnits(0))
+	smsg, err := types.NewSignedMessage(*msg, &mockSigner, types.NewGasPrice(0), types.NewGasUnits(100))
 	require.NoError(err)
 	blk := &types.Block{
 		Height:    20,
@@ -1074,3 +1111,175 @@ func mustSetup2Actors(t *testing.T, balance1 *types.AttoFIL, balance2 *types.Att
 	})
 	return addr1, act1, addr2, act2, st, mockSigner
 }
+
+func TestTraceMessage(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	newAddress := address.NewForTestGetter()
+	ctx := context.Background()
+	cst := hamt.NewCborStore()
+	vms := th.VMStorage()
+
+	// Install the fake actor so we can execute it.
+	fakeActorCodeCid := types.NewCidForTestGetter()()
+	builtin.Actors[fakeActorCodeCid] = &actor.FakeActor{}
+	defer func() {
+		delete(builtin.Actors, fakeActorCodeCid)
+	}()
+
+	ki := types.MustGenerateKeyInfo(1, types.GenerateKeyInfoSeed())
+	mockSigner := types.NewMockSigner(ki)
+	fromAddr := mockSigner.Addresses[0]
+	minerAddr, addr1, addr2 := newAddress(), newAddress(), newAddress()
+
+	_, st := th.RequireMakeStateTree(require, cst, map[address.Address]*actor.Actor{
+		address.NetworkAddress: th.RequireNewAccountActor(require, types.NewAttoFILFromFIL(1000000)),
+		fromAddr:               th.RequireNewAccountActor(require, types.NewAttoFILFromFIL(1000)),
+		addr1:                  th.RequireNewFakeActor(require, vms, addr1, fakeActorCodeCid),
+		addr2:                  th.RequireNewFakeActor(require, vms, addr2, fakeActorCodeCid),
+	})
+
+	newSignedMessage := func(nonce uint64, to address.Address, method string, params ...interface{}) *types.SignedMessage {
+		msg := types.NewMessage(fromAddr, to, nonce, types.NewAttoFILFromFIL(1), method, actor.MustConvertParams(params...))
+		smsg, err := types.NewSignedMessage(*msg, &mockSigner, types.NewGasPrice(0), types.NewGasUnits(300))
+		require.NoError(err)
+		return smsg
+	}
+	smsg0 := newSignedMessage(0, addr2, "")
+	smsg1 := newSignedMessage(1, addr1, "runsAnotherMessage", addr2)
+	smsg2 := newSignedMessage(2, addr2, "")
+	blk := &types.Block{
+		Height:   20,
+		Messages: []*types.SignedMessage{smsg0, smsg1, smsg2},
+		Miner:    minerAddr,
+	}
+
+	msgCid, err := smsg1.Cid()
+	require.NoError(err)
+	trace, err := NewDefaultProcessor().TraceMessage(ctx, st, vms, th.RequireNewTipSet(require, blk), msgCid, nil)
+	require.NoError(err)
+
+	assert.Equal(fromAddr, trace.From)
+	assert.Equal(addr1, trace.To)
+	assert.Equal("runsAnotherMessage", trace.Method)
+	assert.Equal([]interface{}{addr2}, trace.Params)
+	assert.Equal(types.NewAttoFILFromFIL(1), trace.Value)
+	assert.Equal([]types.GasUnits{100}, trace.Charges)
+	assert.Equal(types.NewGasUnits(200), trace.GasUsed)
+	assert.Equal(uint8(0), trace.ExitCode)
+	assert.Empty(trace.Error)
+
+	require.Len(trace.Sends, 1)
+	send := trace.Sends[0]
+	assert.Equal(addr1, send.From)
+	assert.Equal(addr2, send.To)
+	assert.Equal("hasReturnValue", send.Method)
+	assert.Equal([]types.GasUnits{100}, send.Charges)
+	assert.Equal(types.NewGasUnits(100), send.GasUsed)
+	assert.Len(send.Return, 1)
+	assert.Empty(send.Sends)
+
+	// Messages after the traced one are not applied.
+	fromActor, err := st.GetActor(ctx, fromAddr)
+	require.NoError(err)
+	assert.Equal(types.Uint64(2), fromActor.Nonce)
+
+	_, err = NewDefaultProcessor().TraceMessage(ctx, st, vms, th.RequireNewTipSet(require, blk), types.SomeCid(), nil)
+	assert.Error(err)
+}
+
+func TestSimulateMessage(t *testing.T) {
+	newAddress := address.NewForTestGetter()
+	ctx := context.Background()
+
+	// Install the fake actor so we can execute it.
+	fakeActorCodeCid := types.NewCidForTestGetter()()
+	builtin.Actors[fakeActorCodeCid] = &actor.FakeActor{}
+	defer func() {
+		delete(builtin.Actors, fakeActorCodeCid)
+	}()
+
+	fromAddr, fakeAddr, toAddr := newAddress(), newAddress(), newAddress()
+	setup := func(require *require.Assertions) (state.Tree, vm.StorageMap) {
+		vms := th.VMStorage()
+		_, st := th.RequireMakeStateTree(require, hamt.NewCborStore(), map[address.Address]*actor.Actor{
+			fromAddr: th.RequireNewAccountActor(require, types.NewAttoFILFromFIL(100)),
+			fakeAddr: th.RequireNewFakeActor(require, vms, fakeAddr, fakeActorCodeCid),
+		})
+		return st, vms
+	}
+
+	t.Run("transfer", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		st, vms := setup(require)
+
+		msg := types.NewMessage(fromAddr, toAddr, 0, types.NewAttoFILFromFIL(30), "", nil)
+		res, err := SimulateMessage(ctx, st, vms, msg, types.NewGasPrice(1), types.NewBlockHeight(0), nil)
+		require.NoError(err)
+
+		assert.Equal(uint8(0), res.Receipt.ExitCode)
+		assert.NoError(res.ExecutionError)
+		require.Len(res.Changes, 2)
+		for _, c := range res.Changes {
+			switch c.Address {
+			case fromAddr:
+				assert.Equal(types.NewAttoFILFromFIL(70), c.After.Balance)
+				assert.Equal(types.Uint64(1), c.After.Nonce)
+			case toAddr:
+				assert.True(c.Added())
+				assert.Equal(types.NewAttoFILFromFIL(30), c.After.Balance)
+			default:
+				t.Errorf("unexpected change of %s", c.Address)
+			}
+		}
+
+		// The state is not changed.
+		fromActor, err := st.GetActor(ctx, fromAddr)
+		require.NoError(err)
+		assert.Equal(types.NewAttoFILFromFIL(100), fromActor.Balance)
+		_, err = st.GetActor(ctx, toAddr)
+		assert.True(state.IsActorNotFoundError(err))
+	})
+
+	t.Run("failing call", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		st, vms := setup(require)
+
+		msg := types.NewMessage(fromAddr, fakeAddr, 0, types.NewAttoFILFromFIL(30), "chargeGasAndRevertError", nil)
+		res, err := SimulateMessage(ctx, st, vms, msg, types.NewGasPrice(1), types.NewBlockHeight(0), nil)
+		require.NoError(err)
+
+		assert.Equal(uint8(1), res.Receipt.ExitCode)
+		assert.Error(res.ExecutionError)
+		assert.Equal(types.NewGasUnits(100), res.GasUsed)
+		assert.Equal(types.NewAttoFIL(big.NewInt(100)), res.Receipt.GasAttoFIL)
+		assert.Equal("boom", res.Trace.Error)
+
+		// Only the sender's nonce changes, and it pays for the gas.
+		require.Len(res.Changes, 1)
+		assert.Equal(fromAddr, res.Changes[0].Address)
+		assert.Equal(types.NewAttoFILFromFIL(100).Sub(types.NewAttoFIL(big.NewInt(100))), res.Changes[0].After.Balance)
+		assert.Equal(types.Uint64(1), res.Changes[0].After.Nonce)
+	})
+
+	t.Run("charges for the signature", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		st, vms := setup(require)
+
+		defer func(s upgrade.Schedule) { require.NoError(upgrade.SetSchedule(s)) }(upgrade.CurrentSchedule())
+		require.NoError(upgrade.SetSchedule(upgrade.Schedule{
+			{Height: 0, Version: upgrade.Version0},
+			{Height: 10, Version: upgrade.Version1},
+		}))
+
+		msg := types.NewMessage(fromAddr, toAddr, 0, types.NewAttoFILFromFIL(30), "", nil)
+		res, err := SimulateMessage(ctx, st, vms, msg, types.NewGasPrice(1), types.NewBlockHeight(10), nil)
+		require.NoError(err)
+		assert.NoError(res.ExecutionError)
+		assert.True(res.GasUsed >= vm.GasScheduleV1.SignatureVerification)
+	})
+}
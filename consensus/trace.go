@@ -0,0 +1,67 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+// TraceMessage replays the messages of ts on st, the state of its parent, the
+// way ProcessTipSet does, up to the message with cid msgCid, and returns the
+// execution trace of that message. Messages after it are not applied. The
+// trace is returned even if the message could not be applied, in which case
+// its Error says why.
+func (p *DefaultProcessor) TraceMessage(ctx context.Context, st state.Tree, vms vm.StorageMap, ts types.TipSet, msgCid cid.Cid, ancestors []types.TipSet) (*vm.Trace, error) {
+	h, err := ts.Height()
+	if err != nil {
+		return nil, errors.FaultErrorWrap(err, "processing empty tipset")
+	}
+	bh := types.NewBlockHeight(h)
+	msgFilter := make(map[string]struct{})
+
+	tips := ts.ToSlice()
+	types.SortBlocks(tips)
+
+	for _, blk := range tips {
+		if err := p.blockRewarder.BlockReward(ctx, st, blk.Miner); err != nil {
+			return nil, err
+		}
+
+		gasTracker := vm.NewGasTracker()
+		for _, msg := range blk.Messages {
+			mCid, err := msg.Cid()
+			if err != nil {
+				return nil, errors.FaultErrorWrap(err, "error getting message cid")
+			}
+			if _, ok := msgFilter[mCid.String()]; ok {
+				continue
+			}
+			msgFilter[mCid.String()] = struct{}{}
+
+			var trace *vm.Trace
+			if mCid.Equals(msgCid) {
+				trace = vm.NewTrace(&msg.Message)
+			}
+
+			_, err = p.applyMessage(ctx, st, vms, msg, blk.Miner, bh, gasTracker, ancestors, trace)
+			if errors.IsFault(err) {
+				return nil, err
+			}
+			if trace != nil {
+				// The message may have failed before reaching the vm.
+				if err != nil && trace.Error == "" {
+					trace.Error = err.Error()
+				}
+				return trace, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("message cid %s not in tipset", msgCid.String())
+}
@@ -0,0 +1,98 @@
+package consensus
+
+import (
+	"context"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/upgrade"
+	"github.com/filecoin-project/go-filecoin/vm"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+// Migration changes the state of the network when it upgrades to a new
+// version. It runs once, on the state the first tipset at or above the height
+// of the upgrade is applied to, before any of its messages.
+type Migration func(ctx context.Context, st state.Tree, vms vm.StorageMap) error
+
+// Migrations are the state migrations of the network upgrades, by the version
+// they upgrade to. Versions that only change behavior need none: Version1
+// changes the gas schedule, which is read from the version at each message,
+// and leaves the state as it is. A version that changes the code of builtin
+// actors or the layout of their state adds its migration here, built with
+// SwapActorCode or RewriteActorState.
+var Migrations = map[upgrade.Version]Migration{}
+
+// RunUpgrades runs the migrations of the upgrades scheduled after parentHeight
+// up to and including height on st, the state after the tipset at
+// parentHeight. It must be called before applying the messages of a tipset at
+// height to that state, by everyone who does: validators, miners and those
+// replaying the chain.
+func RunUpgrades(ctx context.Context, st state.Tree, vms vm.StorageMap, parentHeight, height uint64) error {
+	for _, u := range upgrade.CurrentSchedule().Between(parentHeight, height) {
+		migrate, ok := Migrations[u.Version]
+		if !ok {
+			continue
+		}
+		log.Infof("upgrading state to network version %d at height %d", u.Version, u.Height)
+		if err := migrate(ctx, st, vms); err != nil {
+			return errors.FaultErrorWrapf(err, "failed to migrate state to network version %d", u.Version)
+		}
+	}
+	return nil
+}
+
+// SwapActorCode returns a migration that moves every actor running the code
+// from to the code to, keeping its state. The code to must be a builtin actor
+// able to read that state.
+func SwapActorCode(from, to cid.Cid) Migration {
+	return func(ctx context.Context, st state.Tree, vms vm.StorageMap) error {
+		if _, err := st.GetBuiltinActorCode(to); err != nil {
+			return err
+		}
+
+		// Walk a flushed tree and change it only after the walk.
+		if _, err := st.Flush(ctx); err != nil {
+			return err
+		}
+		var addrs []address.Address
+		var actors []*actor.Actor
+		err := st.ForEachActor(ctx, func(addr address.Address, act *actor.Actor) error {
+			if act.Code.Equals(from) {
+				addrs = append(addrs, addr)
+				actors = append(actors, act)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for i, act := range actors {
+			act.Code = to
+			if err := st.SetActor(ctx, addrs[i], act); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// RewriteActorState returns a migration that lets rewrite change the storage
+// of the actor at addr, committing a new head for it.
+func RewriteActorState(addr address.Address, rewrite func(storage exec.Storage) error) Migration {
+	return func(ctx context.Context, st state.Tree, vms vm.StorageMap) error {
+		act, err := st.GetActor(ctx, addr)
+		if err != nil {
+			return err
+		}
+		if err := rewrite(vms.NewStorage(addr, act)); err != nil {
+			return err
+		}
+		return st.SetActor(ctx, addr, act)
+	}
+}
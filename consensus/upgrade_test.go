@@ -0,0 +1,160 @@
+package consensus_test
+
+import (
+	"context"
+	"testing"
+
+	"gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmRu7tiRnFk9mMPpVECQTBQJqXtmG132jJxA1w9A7TtpBz/go-ipfs-blockstore"
+	"gx/ipfs/QmUadX5EcvrBmxAV9sE7wUWtWSqxns5K84qKJBixmcT1w9/go-datastore"
+	cbor "gx/ipfs/QmcZLyosDwMKdB6NLRsiss9HXzDPhVhhRtPy67JFKTDQDX/go-ipld-cbor"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	. "github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/state"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/upgrade"
+	"github.com/filecoin-project/go-filecoin/vm"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
+)
+
+func TestRunUpgrades(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	defer func(s upgrade.Schedule) { require.NoError(upgrade.SetSchedule(s)) }(upgrade.CurrentSchedule())
+	defer func(m map[upgrade.Version]Migration) { Migrations = m }(Migrations)
+
+	require.NoError(upgrade.SetSchedule(upgrade.Schedule{
+		{Height: 0, Version: upgrade.Version0},
+		{Height: 10, Version: upgrade.Version1},
+		{Height: 12, Version: upgrade.Version(2)},
+	}))
+	var ran []upgrade.Version
+	Migrations = map[upgrade.Version]Migration{}
+	for _, v := range []upgrade.Version{upgrade.Version1, upgrade.Version(2)} {
+		v := v
+		Migrations[v] = func(ctx context.Context, st state.Tree, vms vm.StorageMap) error {
+			ran = append(ran, v)
+			return nil
+		}
+	}
+
+	cst := hamt.NewCborStore()
+	_, st := th.RequireMakeStateTree(require, cst, nil)
+	vms := vm.NewStorageMap(blockstore.NewBlockstore(datastore.NewMapDatastore()))
+
+	t.Run("runs nothing between upgrades", func(t *testing.T) {
+		ran = nil
+		require.NoError(RunUpgrades(ctx, st, vms, 8, 9))
+		require.NoError(RunUpgrades(ctx, st, vms, 10, 11))
+		assert.Empty(ran)
+	})
+
+	t.Run("runs the migration at the upgrade height", func(t *testing.T) {
+		ran = nil
+		require.NoError(RunUpgrades(ctx, st, vms, 9, 10))
+		assert.Equal([]upgrade.Version{upgrade.Version1}, ran)
+	})
+
+	t.Run("runs every migration skipped by null blocks in order", func(t *testing.T) {
+		ran = nil
+		require.NoError(RunUpgrades(ctx, st, vms, 9, 15))
+		assert.Equal([]upgrade.Version{upgrade.Version1, upgrade.Version(2)}, ran)
+	})
+}
+
+func TestECVAt(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	defer func(s upgrade.Schedule) { require.NoError(upgrade.SetSchedule(s)) }(upgrade.CurrentSchedule())
+	require.NoError(upgrade.SetSchedule(upgrade.Schedule{
+		{Height: 0, Version: upgrade.Version0},
+		{Height: 10, Version: upgrade.Version1},
+	}))
+
+	assert.Equal(ECV, ECVAt(0))
+	// Versions that do not introduce a value keep the one before them.
+	assert.Equal(ECV, ECVAt(10))
+}
+
+func TestSwapActorCode(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	newCid := types.NewCidForTestGetter()
+	oldCode, newCode := newCid(), newCid()
+	newAddress := address.NewForTestGetter()
+	addr1, addr2, addr3 := newAddress(), newAddress(), newAddress()
+
+	cst := hamt.NewCborStore()
+	vms := vm.NewStorageMap(blockstore.NewBlockstore(datastore.NewMapDatastore()))
+	st := state.NewEmptyStateTreeWithActors(cst, map[cid.Cid]exec.ExecutableActor{
+		oldCode: &actor.FakeActor{},
+		newCode: &actor.FakeActor{},
+	})
+
+	act1 := th.RequireNewFakeActor(require, vms, addr1, oldCode)
+	act2 := th.RequireNewFakeActor(require, vms, addr2, oldCode)
+	act3 := th.RequireNewAccountActor(require, types.NewZeroAttoFIL())
+	require.NoError(st.SetActor(ctx, addr1, act1))
+	require.NoError(st.SetActor(ctx, addr2, act2))
+	require.NoError(st.SetActor(ctx, addr3, act3))
+
+	require.NoError(SwapActorCode(oldCode, newCode)(ctx, st, vms))
+
+	for _, addr := range []address.Address{addr1, addr2} {
+		act, err := st.GetActor(ctx, addr)
+		require.NoError(err)
+		assert.Equal(newCode, act.Code)
+		assert.Equal(act1.Head, act.Head)
+	}
+	act, err := st.GetActor(ctx, addr3)
+	require.NoError(err)
+	assert.Equal(types.AccountActorCodeCid, act.Code)
+
+	// Only builtin code may be swapped in.
+	assert.Error(SwapActorCode(newCode, newCid())(ctx, st, vms))
+}
+
+func TestRewriteActorState(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	fakeCode := types.NewCidForTestGetter()()
+	addr := address.NewForTestGetter()()
+
+	cst := hamt.NewCborStore()
+	vms := vm.NewStorageMap(blockstore.NewBlockstore(datastore.NewMapDatastore()))
+	act := th.RequireNewFakeActor(require, vms, addr, fakeCode)
+	_, st := th.RequireMakeStateTree(require, cst, map[address.Address]*actor.Actor{addr: act})
+	oldHead := act.Head
+
+	migrate := RewriteActorState(addr, func(storage exec.Storage) error {
+		head, err := storage.Put(&actor.FakeActorStorage{Changed: true})
+		if err != nil {
+			return err
+		}
+		return storage.Commit(head, storage.Head())
+	})
+	require.NoError(migrate(ctx, st, vms))
+
+	act, err := st.GetActor(ctx, addr)
+	require.NoError(err)
+	assert.NotEqual(oldHead, act.Head)
+
+	chunk, err := vms.NewStorage(addr, act).Get(act.Head)
+	require.NoError(err)
+	var fakeState actor.FakeActorStorage
+	require.NoError(cbor.DecodeInto(chunk, &fakeState))
+	assert.True(fakeState.Changed)
+}
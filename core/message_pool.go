@@ -2,34 +2,63 @@ package core
 
 import (
 	"context"
+	"math/big"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/config"
 	"github.com/filecoin-project/go-filecoin/types"
 	"gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+	logging "gx/ipfs/QmbkT7eMTyXfpeyB3ZMxxcxg7XH8t6uXp49jqzz4HB7BGF/go-log"
 )
 
+var log = logging.Logger("core")
+
+// ActorNonceGetter returns the current nonce of the actor at addr, or zero if
+// there is no such actor.
+type ActorNonceGetter func(ctx context.Context, addr address.Address) (uint64, error)
+
 // MessagePool keeps an unordered, de-duplicated set of Messages and supports removal by CID.
 // By 'de-duplicated' we mean that insertion of a message by cid that already
 // exists is a nop. We use a MessagePool to store all messages received by this node
 // via network or directly created via user command that have yet to be included
 // in a block. Messages are removed as they are processed.
 //
+// The pool is bounded: it holds at most cfg.MaxPoolSize messages and at most
+// cfg.MaxPerSender messages from any one sender, rejects messages whose nonce
+// is too far ahead of their sender's nonce, and drops messages that have been
+// waiting longer than cfg.MaxMessageAgeSeconds when RemoveExpired is called.
+//
+// The pool holds at most one message per sender and nonce.  A message with
+// the same sender and nonce as a pending one replaces it if its gas price is
+// at least cfg.ReplaceByFeePercent higher, and is rejected otherwise.
+//
 // MessagePool is safe for concurrent access.
 type MessagePool struct {
 	lk sync.RWMutex
 
-	pending map[cid.Cid]*types.SignedMessage // all pending messages
+	cfg *config.MessagePoolConfig
+	// getNonce looks up the current nonce of a message's sender.  If nil
+	// nonces are not checked.
+	getNonce ActorNonceGetter
+
+	pending  map[cid.Cid]*poolEntry                       // all pending messages
+	bySender map[address.Address]map[types.Uint64]cid.Cid // pending messages by sender and nonce
 }
 
-// Add adds a message to the pool.
-func (pool *MessagePool) Add(msg *types.SignedMessage) (cid.Cid, error) {
-	pool.lk.Lock()
-	defer pool.lk.Unlock()
+// poolEntry is a pending message and the time it was added to the pool.
+type poolEntry struct {
+	msg   *types.SignedMessage
+	added time.Time
+}
 
+// Add adds a message to the pool.  When the pool is full the message
+// replaces the pending message with the lowest gas price, if it pays more.
+func (pool *MessagePool) Add(msg *types.SignedMessage) (cid.Cid, error) {
 	c, err := msg.Cid()
 	if err != nil {
 		return cid.Undef, errors.Wrap(err, "failed to create CID")
@@ -40,17 +69,93 @@ func (pool *MessagePool) Add(msg *types.SignedMessage) (cid.Cid, error) {
 		return cid.Undef, errors.Errorf("failed to add message %s to pool: sig invalid", c.String())
 	}
 
-	pool.pending[c] = msg
+	// Look the nonce up before taking the lock, it may need to load state.
+	if pool.getNonce != nil {
+		actorNonce, err := pool.getNonce(context.TODO(), msg.From)
+		if err != nil {
+			return cid.Undef, errors.Wrapf(err, "failed to get nonce of %s", msg.From)
+		}
+		if uint64(msg.Nonce) > actorNonce+pool.cfg.MaxNonceGap {
+			return cid.Undef, errors.Errorf("failed to add message %s to pool: nonce %d is more than %d ahead of sender nonce %d", c.String(), msg.Nonce, pool.cfg.MaxNonceGap, actorNonce)
+		}
+	}
+
+	pool.lk.Lock()
+	defer pool.lk.Unlock()
+
+	if _, ok := pool.pending[c]; ok {
+		return c, nil
+	}
+
+	if existing, ok := pool.bySender[msg.From][msg.Nonce]; ok {
+		old := pool.pending[existing].msg
+		if !pool.canReplace(old, msg) {
+			return cid.Undef, errors.Errorf("failed to add message %s to pool: gas price must be at least %d%% higher than %s of pending message %s with the same nonce", c.String(), pool.cfg.ReplaceByFeePercent, old.GasPrice.String(), existing.String())
+		}
+		pool.remove(existing)
+	} else if uint(len(pool.bySender[msg.From])) >= pool.cfg.MaxPerSender {
+		return cid.Undef, errors.Errorf("failed to add message %s to pool: sender %s has %d pending messages", c.String(), msg.From, len(pool.bySender[msg.From]))
+	}
+
+	if uint(len(pool.pending)) >= pool.cfg.MaxPoolSize {
+		cheapest, ok := pool.cheapest()
+		if !ok || !msg.GasPrice.GreaterThan(&pool.pending[cheapest].msg.GasPrice) {
+			return cid.Undef, errors.Errorf("failed to add message %s to pool: pool is full", c.String())
+		}
+		pool.remove(cheapest)
+	}
+
+	pool.pending[c] = &poolEntry{msg: msg, added: time.Now()}
+	if pool.bySender[msg.From] == nil {
+		pool.bySender[msg.From] = make(map[types.Uint64]cid.Cid)
+	}
+	pool.bySender[msg.From][msg.Nonce] = c
 	return c, nil
 }
 
+// canReplace returns true if the gas price of msg is high enough for it to
+// replace old.
+func (pool *MessagePool) canReplace(old, msg *types.SignedMessage) bool {
+	if !msg.GasPrice.GreaterThan(&old.GasPrice) {
+		return false
+	}
+	// msg.GasPrice * 100 >= old.GasPrice * (100 + ReplaceByFeePercent)
+	scaledNew := msg.GasPrice.MulBigInt(big.NewInt(100))
+	scaledOld := old.GasPrice.MulBigInt(new(big.Int).SetUint64(100 + uint64(pool.cfg.ReplaceByFeePercent)))
+	return scaledNew.GreaterEqual(scaledOld)
+}
+
+// Get returns the pending message with cid c, if there is one.
+func (pool *MessagePool) Get(c cid.Cid) (*types.SignedMessage, bool) {
+	pool.lk.RLock()
+	defer pool.lk.RUnlock()
+
+	entry, ok := pool.pending[c]
+	if !ok {
+		return nil, false
+	}
+	return entry.msg, true
+}
+
+// cheapest returns the cid of the pending message with the lowest gas price.
+func (pool *MessagePool) cheapest() (cid.Cid, bool) {
+	var out cid.Cid
+	var lowest *types.AttoFIL
+	for c, entry := range pool.pending {
+		if lowest == nil || entry.msg.GasPrice.LessThan(lowest) {
+			out, lowest = c, &entry.msg.GasPrice
+		}
+	}
+	return out, lowest != nil
+}
+
 // Pending returns all pending messages.
 func (pool *MessagePool) Pending() []*types.SignedMessage {
 	pool.lk.Lock()
 	defer pool.lk.Unlock()
 	out := make([]*types.SignedMessage, 0, len(pool.pending))
-	for _, msg := range pool.pending {
-		out = append(out, msg)
+	for _, entry := range pool.pending {
+		out = append(out, entry.msg)
 	}
 
 	return out
@@ -61,13 +166,47 @@ func (pool *MessagePool) Remove(c cid.Cid) {
 	pool.lk.Lock()
 	defer pool.lk.Unlock()
 
+	pool.remove(c)
+}
+
+func (pool *MessagePool) remove(c cid.Cid) {
+	entry, ok := pool.pending[c]
+	if !ok {
+		return
+	}
 	delete(pool.pending, c)
+	delete(pool.bySender[entry.msg.From], entry.msg.Nonce)
+	if len(pool.bySender[entry.msg.From]) == 0 {
+		delete(pool.bySender, entry.msg.From)
+	}
 }
 
-// NewMessagePool constructs a new MessagePool.
-func NewMessagePool() *MessagePool {
+// RemoveExpired removes the messages that have been in the pool longer than
+// the configured maximum age as of now, and returns how many were removed.
+func (pool *MessagePool) RemoveExpired(now time.Time) int {
+	pool.lk.Lock()
+	defer pool.lk.Unlock()
+
+	maxAge := time.Duration(pool.cfg.MaxMessageAgeSeconds) * time.Second
+	removed := 0
+	for c, entry := range pool.pending {
+		if now.Sub(entry.added) > maxAge {
+			pool.remove(c)
+			removed++
+		}
+	}
+	return removed
+}
+
+// NewMessagePool constructs a new MessagePool bounded by cfg.  getNonce is
+// used to reject messages with nonces too far ahead of their sender's, it
+// may be nil to skip that check.
+func NewMessagePool(cfg *config.MessagePoolConfig, getNonce ActorNonceGetter) *MessagePool {
 	return &MessagePool{
-		pending: make(map[cid.Cid]*types.SignedMessage),
+		cfg:      cfg,
+		getNonce: getNonce,
+		pending:  make(map[cid.Cid]*poolEntry),
+		bySender: make(map[address.Address]map[types.Uint64]cid.Cid),
 	}
 }
 
@@ -80,11 +219,11 @@ func getParentTipSet(ctx context.Context, store *hamt.CborIpldStore, ts types.Ti
 		return nil, err
 	}
 	for it := parents.Iter(); !it.Complete() && ctx.Err() == nil; it.Next() {
-		var newBlk types.Block
-		if err := store.Get(ctx, it.Value(), &newBlk); err != nil {
+		newBlk, err := types.GetBlock(ctx, store, it.Value())
+		if err != nil {
 			return nil, err
 		}
-		if err := newTipSet.AddBlock(&newBlk); err != nil {
+		if err := newTipSet.AddBlock(newBlk); err != nil {
 			return nil, err
 		}
 	}
@@ -135,9 +274,8 @@ func collectChainsMessagesToHeight(ctx context.Context, store *hamt.CborIpldStor
 // that the right model for keeping the message pool up to date is
 // to think about it like a garbage collector.
 //
-// TODO there is considerable functionality missing here: don't add
-//      messages that have expired, respect nonce, do this efficiently,
-//      etc.
+// TODO there is considerable functionality missing here: do this
+//      efficiently, etc.
 func UpdateMessagePool(ctx context.Context, pool *MessagePool, store *hamt.CborIpldStore, old, new types.TipSet) error {
 	// Strategy: walk head-of-chain pointers old and new back until they are at the same
 	// height, then walk back in lockstep to find the common ancesetor.
@@ -199,11 +337,11 @@ func UpdateMessagePool(ctx context.Context, pool *MessagePool, store *hamt.CborI
 		}
 	}
 
-	// Now actually update the pool.
+	// Now actually update the pool.  Messages from the abandoned chain
+	// that the pool won't take back, e.g. because it is full, are dropped.
 	for _, m := range addToPool {
-		_, err := pool.Add(m)
-		if err != nil {
-			return err
+		if _, err := pool.Add(m); err != nil {
+			log.Warningf("dropping message from abandoned chain: %s", err)
 		}
 	}
 	// m.Cid() can error, so collect all the Cids before
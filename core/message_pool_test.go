@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	hamt "gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
 
@@ -12,18 +13,19 @@ import (
 	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/config"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
 var seed = types.GenerateKeyInfoSeed()
 var ki = types.MustGenerateKeyInfo(10, seed)
 var mockSigner = types.NewMockSigner(ki)
-var newSignedMessage = types.NewSignedMessageForTestGetter(mockSigner)
+var newSignedMessage = types.NewSignedMessageSequenceForTestGetter(mockSigner)
 
 func TestMessagePoolAddRemove(t *testing.T) {
 	assert := assert.New(t)
 
-	pool := NewMessagePool()
+	pool := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 	msg1 := newSignedMessage()
 	msg2 := newSignedMessage()
 
@@ -49,7 +51,7 @@ func TestMessagePoolAddRemove(t *testing.T) {
 func TestMessagePoolAddBadSignature(t *testing.T) {
 	assert := assert.New(t)
 
-	pool := NewMessagePool()
+	pool := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 	smsg := newSignedMessage()
 	smsg.Message.Nonce = types.Uint64(uint64(smsg.Message.Nonce) + uint64(1)) // invalidate message
 
@@ -61,7 +63,7 @@ func TestMessagePoolAddBadSignature(t *testing.T) {
 func TestMessagePoolDedup(t *testing.T) {
 	assert := assert.New(t)
 
-	pool := NewMessagePool()
+	pool := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 	msg1 := newSignedMessage()
 
 	assert.Len(pool.Pending(), 0)
@@ -80,7 +82,10 @@ func TestMessagePoolAsync(t *testing.T) {
 	count := 400
 	msgs := types.NewSignedMsgs(count, mockSigner)
 
-	pool := NewMessagePool()
+	// All the messages are from the same sender.
+	cfg := config.NewDefaultConfig().Mpool
+	cfg.MaxPerSender = uint(count)
+	pool := NewMessagePool(cfg, nil)
 	var wg sync.WaitGroup
 
 	for i := 0; i < 4; i++ {
@@ -98,6 +103,119 @@ func TestMessagePoolAsync(t *testing.T) {
 	assert.Len(pool.Pending(), count)
 }
 
+// newPricedMessage returns a message from the signer's address at index
+// from with the given nonce and gas price.
+func newPricedMessage(require *require.Assertions, from int, nonce uint64, price int64) *types.SignedMessage {
+	msg := types.NewMessage(mockSigner.Addresses[from], address.NewForTestGetter()(), nonce, types.NewAttoFILFromFIL(0), "", nil)
+	smsg, err := types.NewSignedMessage(*msg, &mockSigner, types.NewGasPrice(price), types.NewGasUnits(0))
+	require.NoError(err)
+	return smsg
+}
+
+func TestMessagePoolLimits(t *testing.T) {
+	t.Run("per sender cap", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		cfg := config.NewDefaultConfig().Mpool
+		cfg.MaxPerSender = 2
+		pool := NewMessagePool(cfg, nil)
+
+		MustAdd(pool, newPricedMessage(require, 0, 0, 1), newPricedMessage(require, 0, 1, 1))
+		_, err := pool.Add(newPricedMessage(require, 0, 2, 1))
+		assert.Error(err)
+
+		// Other senders are unaffected.
+		_, err = pool.Add(newPricedMessage(require, 1, 0, 1))
+		assert.NoError(err)
+		assert.Len(pool.Pending(), 3)
+	})
+
+	t.Run("rejects nonces too far ahead", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		cfg := config.NewDefaultConfig().Mpool
+		cfg.MaxNonceGap = 5
+		pool := NewMessagePool(cfg, func(ctx context.Context, addr address.Address) (uint64, error) {
+			return 10, nil
+		})
+
+		_, err := pool.Add(newPricedMessage(require, 0, 15, 1))
+		assert.NoError(err)
+		_, err = pool.Add(newPricedMessage(require, 0, 16, 1))
+		assert.Error(err)
+	})
+
+	t.Run("evicts the lowest gas price when full", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		cfg := config.NewDefaultConfig().Mpool
+		cfg.MaxPoolSize = 2
+		pool := NewMessagePool(cfg, nil)
+
+		cheap := newPricedMessage(require, 0, 0, 1)
+		dear := newPricedMessage(require, 1, 0, 5)
+		MustAdd(pool, cheap, dear)
+
+		// A message that doesn't pay more than the cheapest is rejected.
+		_, err := pool.Add(newPricedMessage(require, 2, 0, 1))
+		assert.Error(err)
+		assertPoolEquals(assert, pool, cheap, dear)
+
+		dearer := newPricedMessage(require, 2, 0, 3)
+		_, err = pool.Add(dearer)
+		assert.NoError(err)
+		assertPoolEquals(assert, pool, dear, dearer)
+	})
+
+	t.Run("removes expired messages", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		cfg := config.NewDefaultConfig().Mpool
+		cfg.MaxMessageAgeSeconds = 60
+		pool := NewMessagePool(cfg, nil)
+		MustAdd(pool, newPricedMessage(require, 0, 0, 1))
+
+		assert.Equal(0, pool.RemoveExpired(time.Now()))
+		assert.Len(pool.Pending(), 1)
+
+		assert.Equal(1, pool.RemoveExpired(time.Now().Add(61*time.Second)))
+		assert.Len(pool.Pending(), 0)
+	})
+}
+
+func TestMessagePoolReplaceByFee(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	pool := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
+	orig := newPricedMessage(require, 0, 0, 100)
+	MustAdd(pool, orig)
+
+	// The default requires a 10% higher gas price.
+	_, err := pool.Add(newPricedMessage(require, 0, 0, 109))
+	assert.Error(err)
+	assertPoolEquals(assert, pool, orig)
+
+	replacement := newPricedMessage(require, 0, 0, 110)
+	_, err = pool.Add(replacement)
+	assert.NoError(err)
+	assertPoolEquals(assert, pool, replacement)
+
+	// A zero gas price can only be replaced by a positive one.
+	free := newPricedMessage(require, 1, 0, 0)
+	MustAdd(pool, free)
+	msg := types.NewMessage(free.From, free.To, 0, types.NewAttoFILFromFIL(0), "other", nil)
+	alsoFree, err := types.NewSignedMessage(*msg, &mockSigner, types.NewGasPrice(0), types.NewGasUnits(0))
+	require.NoError(err)
+	_, err = pool.Add(alsoFree)
+	assert.Error(err)
+	assertPoolEquals(assert, pool, replacement, free)
+}
+
 func msgAsString(msg *types.SignedMessage) string {
 	// When using NewMessageForTestGetter msg.Method is set
 	// to "msgN" so we print that (it will correspond
@@ -150,7 +268,7 @@ func TestUpdateMessagePool(t *testing.T) {
 		// to
 		// Msg pool: [m0],     Chain: b[m1]
 		store := hamt.NewCborStore()
-		p := NewMessagePool()
+		p := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 
 		m := types.NewSignedMsgs(2, mockSigner)
 		MustAdd(p, m[0], m[1])
@@ -170,7 +288,7 @@ func TestUpdateMessagePool(t *testing.T) {
 		// to
 		// Msg pool: [m0, m1], Chain: b[m2]
 		store := hamt.NewCborStore()
-		p := NewMessagePool()
+		p := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 
 		m := types.NewSignedMsgs(3, mockSigner)
 		MustAdd(p, m[0], m[1])
@@ -187,7 +305,7 @@ func TestUpdateMessagePool(t *testing.T) {
 		// to
 		// Msg pool: [m1],         Chain: b[m2, m3] -> b[m4] -> b[m0] -> b[] -> b[m5, m6]
 		store := hamt.NewCborStore()
-		p := NewMessagePool()
+		p := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 
 		m := types.NewSignedMsgs(7, mockSigner)
 		MustAdd(p, m[2], m[5])
@@ -213,7 +331,7 @@ func TestUpdateMessagePool(t *testing.T) {
 		// to
 		// Msg pool: [m1],         Chain: b[m2, m3] -> {b[m4], b[m0], b[], b[]} -> {b[], b[m6,m5]}
 		store := hamt.NewCborStore()
-		p := NewMessagePool()
+		p := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 
 		m := types.NewSignedMsgs(7, mockSigner)
 		MustAdd(p, m[2], m[5])
@@ -237,7 +355,7 @@ func TestUpdateMessagePool(t *testing.T) {
 		// to
 		// Msg pool: [m1, m2],     Chain: b[m0] -> b[m3] -> b[m4, m5]
 		store := hamt.NewCborStore()
-		p := NewMessagePool()
+		p := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 
 		m := types.NewSignedMsgs(6, mockSigner)
 		MustAdd(p, m[3], m[5])
@@ -257,7 +375,7 @@ func TestUpdateMessagePool(t *testing.T) {
 		// to
 		// Msg pool: [m6],         Chain: b[m0] -> b[m3] -> b[m4] -> b[m5] -> b[m1, m2]
 		store := hamt.NewCborStore()
-		p := NewMessagePool()
+		p := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 
 		m := types.NewSignedMsgs(7, mockSigner)
 		MustAdd(p, m[6])
@@ -286,7 +404,7 @@ func TestUpdateMessagePool(t *testing.T) {
 		// to
 		// Msg pool: [m6],         Chain: {b[m0], b[m1]} -> b[m3] -> b[m4] -> {b[m5], b[m1, m2]}
 		store := hamt.NewCborStore()
-		p := NewMessagePool()
+		p := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 
 		m := types.NewSignedMsgs(7, mockSigner)
 		MustAdd(p, m[6])
@@ -313,7 +431,7 @@ func TestUpdateMessagePool(t *testing.T) {
 		// to
 		// Msg pool: [m3, m5],     Chain: {b[m0], b[m1], b[m2]}
 		store := hamt.NewCborStore()
-		p := NewMessagePool()
+		p := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 
 		m := types.NewSignedMsgs(6, mockSigner)
 		MustAdd(p, m[3], m[5])
@@ -339,7 +457,7 @@ func TestUpdateMessagePool(t *testing.T) {
 		// to
 		// Msg pool: [m2, m3],         Chain: b[m0] -> b[m1]
 		store := hamt.NewCborStore()
-		p := NewMessagePool()
+		p := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 		m := types.NewSignedMsgs(4, mockSigner)
 
 		oldChain := NewChainWithMessages(store, types.TipSet{},
@@ -360,7 +478,7 @@ func TestUpdateMessagePool(t *testing.T) {
 		// to
 		// Msg pool: [m0],     Chain: b[] -> b[m1, m2]
 		store := hamt.NewCborStore()
-		p := NewMessagePool()
+		p := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 
 		m := types.NewSignedMsgs(3, mockSigner)
 		MustAdd(p, m[0], m[1])
@@ -380,7 +498,7 @@ func TestUpdateMessagePool(t *testing.T) {
 		// to
 		// Msg pool: [],           Chain: b[m0] -> b[m1] -> b[m2, m3] -> b[m4] -> b[m5, m6]
 		store := hamt.NewCborStore()
-		p := NewMessagePool()
+		p := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 
 		m := types.NewSignedMsgs(7, mockSigner)
 		MustAdd(p, m[2], m[5])
@@ -403,7 +521,7 @@ func TestUpdateMessagePool(t *testing.T) {
 func TestOrderMessagesByNonce(t *testing.T) {
 	t.Run("Empty pool", func(t *testing.T) {
 		assert := assert.New(t)
-		p := NewMessagePool()
+		p := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 		ordered := OrderMessagesByNonce(p.Pending())
 		assert.Equal(0, len(ordered))
 	})
@@ -411,7 +529,7 @@ func TestOrderMessagesByNonce(t *testing.T) {
 	t.Run("Msgs in three orders", func(t *testing.T) {
 		assert := assert.New(t)
 		require := require.New(t)
-		p := NewMessagePool()
+		p := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 
 		m := types.NewMsgsWithAddrs(9, mockSigner.Addresses)
 
@@ -460,7 +578,7 @@ func TestLargestNonce(t *testing.T) {
 	require := require.New(t)
 
 	t.Run("No matches", func(t *testing.T) {
-		p := NewMessagePool()
+		p := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 
 		m := types.NewSignedMsgs(2, mockSigner)
 		MustAdd(p, m[0], m[1])
@@ -470,7 +588,7 @@ func TestLargestNonce(t *testing.T) {
 	})
 
 	t.Run("Match, largest is zero", func(t *testing.T) {
-		p := NewMessagePool()
+		p := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 
 		m := types.NewMsgsWithAddrs(1, mockSigner.Addresses)
 		m[0].Nonce = 0
@@ -486,7 +604,7 @@ func TestLargestNonce(t *testing.T) {
 	})
 
 	t.Run("Match", func(t *testing.T) {
-		p := NewMessagePool()
+		p := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 
 		m := types.NewMsgsWithAddrs(3, mockSigner.Addresses)
 		m[1].Nonce = 1
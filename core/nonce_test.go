@@ -9,6 +9,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/config"
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/testhelpers"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -23,7 +24,7 @@ func TestNextNonce(t *testing.T) {
 		assert := assert.New(t)
 		store := hamt.NewCborStore()
 		st := state.NewEmptyStateTree(store)
-		mp := NewMessagePool()
+		mp := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 
 		address := address.NewForTestGetter()()
 
@@ -36,7 +37,7 @@ func TestNextNonce(t *testing.T) {
 		assert := assert.New(t)
 		store := hamt.NewCborStore()
 		st := state.NewEmptyStateTree(store)
-		mp := NewMessagePool()
+		mp := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 
 		address := address.NewForTestGetter()()
 		actor, err := storagemarket.NewActor()
@@ -52,7 +53,7 @@ func TestNextNonce(t *testing.T) {
 		assert := assert.New(t)
 		store := hamt.NewCborStore()
 		st := state.NewEmptyStateTree(store)
-		mp := NewMessagePool()
+		mp := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 		address := address.NewForTestGetter()()
 		actor, err := account.NewActor(types.NewAttoFILFromFIL(0))
 		assert.NoError(err)
@@ -68,7 +69,7 @@ func TestNextNonce(t *testing.T) {
 		assert := assert.New(t)
 		store := hamt.NewCborStore()
 		st := state.NewEmptyStateTree(store)
-		mp := NewMessagePool()
+		mp := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 		addr := mockSigner.Addresses[0]
 		actor, err := account.NewActor(types.NewAttoFILFromFIL(0))
 		assert.NoError(err)
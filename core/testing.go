@@ -14,6 +14,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/config"
 	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -24,7 +25,7 @@ import (
 
 // MustGetNonce returns the next nonce for an actor at the given address or panics.
 func MustGetNonce(st state.Tree, a address.Address) uint64 {
-	mp := NewMessagePool()
+	mp := NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 	nonce, err := NextNonce(context.Background(), st, mp, a)
 	if err != nil {
 		panic(err)
@@ -67,7 +68,7 @@ func NewChainWithMessages(store *hamt.CborIpldStore, root types.TipSet, msgSets
 	// only add root to the chain if it is not the zero-valued-tipset
 	if len(parents) != 0 {
 		for _, blk := range parents {
-			MustPut(store, blk)
+			MustPutBlock(store, blk)
 		}
 		tipSets = append(tipSets, parents)
 	}
@@ -82,7 +83,7 @@ func NewChainWithMessages(store *hamt.CborIpldStore, root types.TipSet, msgSets
 				Height:  types.Uint64(height + 1),
 				Parents: parents.ToSortedCidSet(),
 			}
-			MustPut(store, child)
+			MustPutBlock(store, child)
 			ts[child.Cid()] = child
 		}
 		for _, msgs := range tsMsgs {
@@ -91,7 +92,10 @@ func NewChainWithMessages(store *hamt.CborIpldStore, root types.TipSet, msgSets
 				Parents:  parents.ToSortedCidSet(),
 				Height:   types.Uint64(height + 1),
 			}
-			MustPut(store, child)
+			if err := child.SetCollectionRoots(); err != nil {
+				panic(err)
+			}
+			MustPutBlock(store, child)
 			ts[child.Cid()] = child
 		}
 		tipSets = append(tipSets, ts)
@@ -110,6 +114,16 @@ func MustPut(store *hamt.CborIpldStore, thingy interface{}) cid.Cid {
 	return cid
 }
 
+// MustPutBlock stores the block with its messages and receipts in the store
+// or panics if it cannot.
+func MustPutBlock(store *hamt.CborIpldStore, blk *types.Block) cid.Cid {
+	cid, err := types.PutBlock(context.Background(), store, blk)
+	if err != nil {
+		panic(err)
+	}
+	return cid
+}
+
 // MustDecodeCid decodes a string to a Cid pointer, panicking on error
 func MustDecodeCid(cidStr string) cid.Cid {
 	decode, err := cid.Decode(cidStr)
@@ -0,0 +1,87 @@
+package exec
+
+import (
+	"sort"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// ActorDescription is a machine readable description of the methods of an
+// actor, for clients that are not written in Go.
+type ActorDescription struct {
+	Code cid.Cid `json:"code"`
+	Name string  `json:"name"`
+	// Methods are the exported methods of the actor, by name.
+	Methods []*MethodDescription `json:"methods"`
+	// Initializer is the schema of the JSON array of the data the actor is
+	// initialized with at genesis, if it takes any, see Initializer.
+	Initializer *abi.Schema `json:"initializer,omitempty"`
+	// Errors are the errors the methods of the actor return, if it
+	// documents them. Any method may also return one of VMErrors.
+	Errors   []*ErrorDescription `json:"errors"`
+	VMErrors []*ErrorDescription `json:"vmErrors"`
+}
+
+// MethodDescription describes an exported method of an actor.
+//
+// Methods are identified by Name, the string messages carry in
+// types.Message.Method. They have no numbers: dispatching by number changes
+// the encoding of messages and so needs a network upgrade, which is left for
+// later.
+type MethodDescription struct {
+	Name string `json:"name"`
+	// Params is the schema of the JSON array of the parameters of the
+	// method, and Return that of its return values.
+	Params *abi.Schema `json:"params"`
+	Return *abi.Schema `json:"return"`
+}
+
+// ErrorDescription describes an error by its exit code.
+type ErrorDescription struct {
+	Code    uint8  `json:"code"`
+	Message string `json:"message"`
+}
+
+// Describe returns the description of the actor running impl as code.
+func Describe(code cid.Cid, impl ExecutableActor) *ActorDescription {
+	desc := &ActorDescription{
+		Code:     code,
+		Name:     types.ActorCodeTypeName(code),
+		Methods:  []*MethodDescription{},
+		Errors:   []*ErrorDescription{},
+		VMErrors: describeErrors(Errors),
+	}
+
+	for name, sig := range impl.Exports() {
+		desc.Methods = append(desc.Methods, &MethodDescription{
+			Name:   name,
+			Params: abi.TupleSchema(sig.Params),
+			Return: abi.TupleSchema(sig.Return),
+		})
+	}
+	sort.Slice(desc.Methods, func(i, j int) bool {
+		return desc.Methods[i].Name < desc.Methods[j].Name
+	})
+
+	if init, ok := impl.(Initializer); ok {
+		desc.Initializer = abi.TupleSchema(init.InitializerParams())
+	}
+	if ec, ok := impl.(ErrorCoder); ok {
+		desc.Errors = describeErrors(ec.Errors())
+	}
+	return desc
+}
+
+func describeErrors(errs map[uint8]error) []*ErrorDescription {
+	out := []*ErrorDescription{}
+	for code, err := range errs {
+		out = append(out, &ErrorDescription{Code: code, Message: err.Error()})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Code < out[j].Code
+	})
+	return out
+}
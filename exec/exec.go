@@ -47,6 +47,20 @@ func (e Exports) Has(method string) bool {
 // TODO fritz require actors to define their exit codes and associate
 // an error string with them.
 
+// ErrorCoder is implemented by actors that document the errors their methods
+// return, by exit code.
+type ErrorCoder interface {
+	Errors() map[uint8]error
+}
+
+// Initializer is implemented by actors that can be created at genesis from
+// initializer data given as JSON, e.g. in a gengen config. The data is a JSON
+// array of values of the types InitializerParams returns, which
+// InitializeState then receives decoded as a []interface{}.
+type Initializer interface {
+	InitializerParams() []abi.Type
+}
+
 // ExecutableActor is the interface all builtin actors have to implement.
 type ExecutableActor interface {
 	Exports() Exports
@@ -74,6 +88,15 @@ type VMContext interface {
 	BlockHeight() *types.BlockHeight
 	IsFromAccountActor() bool
 	Charge(cost types.GasUnits) error
+	// ChargeSignatureVerification, ChargeSealVerification and
+	// ChargePoStVerification charge the gas for checking a signature, a seal
+	// proof or a PoST proof under the gas schedule in effect.
+	ChargeSignatureVerification() error
+	ChargeSealVerification() error
+	ChargePoStVerification() error
+	// EmitEvent records an event with the given topic and data for clients
+	// following the chain. Events are kept only if the message succeeds.
+	EmitEvent(topic string, data interface{}) error
 
 	CreateNewActor(addr address.Address, code cid.Cid, initalizationParams interface{}) error
 
@@ -52,6 +52,12 @@ $ cat setup.json
 }
 $ cat setup.json | gengen > genesis.car
 
+Other actors can be created with an "actors" list of objects giving their
+"address", the cid of their "code" and their "balance" in whole filecoin.
+gengen only knows the code of the builtin actors: networks running actors of
+their own build their genesis with gengen.GenGen from a program that registers
+them with builtin.Register.
+
 The outputted file can be used by go-filecoin during init to
 set the initial genesis block:
 $ go-filecoin init --genesisfile=genesis.car
@@ -2,18 +2,21 @@ package gengen
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math/big"
 	mrand "math/rand"
 	"strconv"
 
+	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/crypto"
+	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/proofs"
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -47,6 +50,26 @@ type Miner struct {
 	Power uint64
 }
 
+// Actor is an actor running registered code, created with the initial state
+// of that code.
+type Actor struct {
+	// Address is the address of the actor
+	Address string
+
+	// Code is the cid of the code of the actor. Code defined outside of
+	// go-filecoin must be registered with builtin.Register by the program
+	// calling GenGen.
+	Code string
+
+	// Balance is the amount of whole filecoin the actor starts with
+	Balance string
+
+	// InitParams is a JSON array of the data the actor is initialized with,
+	// validated against the schema of the initializer parameters of its
+	// code, see exec.Initializer. It must be empty if the code takes none.
+	InitParams json.RawMessage
+}
+
 // GenesisCfg is
 type GenesisCfg struct {
 	// Keys is an array of names of keys. A random key will be generated
@@ -59,6 +82,14 @@ type GenesisCfg struct {
 
 	// Miners is a list of miners that should be set up at the start of the network
 	Miners []Miner
+
+	// Actors is a list of other actors that should be set up at the start of
+	// the network
+	Actors []Actor
+
+	// Time is the unix time in seconds of the genesis block, epochs are
+	// counted from it.  Zero anchors epochs at the head when mining starts.
+	Time uint64
 }
 
 // RenderedGenInfo contains information about a genesis block creation
@@ -113,20 +144,14 @@ func GenGen(ctx context.Context, cfg *GenesisCfg, cst *hamt.CborIpldStore, bs bl
 		return nil, err
 	}
 
-	if err := cst.Blocks.AddBlock(types.StorageMarketActorCodeObj); err != nil {
-		return nil, err
-	}
-	if err := cst.Blocks.AddBlock(types.MinerActorCodeObj); err != nil {
-		return nil, err
-	}
-	if err := cst.Blocks.AddBlock(types.BootstrapMinerActorCodeObj); err != nil {
-		return nil, err
-	}
-	if err := cst.Blocks.AddBlock(types.AccountActorCodeObj); err != nil {
+	if err := setupActors(st, storageMap, cfg.Actors); err != nil {
 		return nil, err
 	}
-	if err := cst.Blocks.AddBlock(types.PaymentBrokerActorCodeObj); err != nil {
-		return nil, err
+
+	for _, code := range builtin.CodeObjs() {
+		if err := cst.Blocks.AddBlock(code); err != nil {
+			return nil, err
+		}
 	}
 
 	stateRoot, err := st.Flush(ctx)
@@ -141,6 +166,7 @@ func GenGen(ctx context.Context, cfg *GenesisCfg, cst *hamt.CborIpldStore, bs bl
 
 	geneblk := &types.Block{
 		StateRoot: stateRoot,
+		Timestamp: types.Uint64(cfg.Time),
 	}
 
 	c, err := cst.Put(ctx, geneblk)
@@ -209,6 +235,59 @@ func setupPrealloc(st state.Tree, keys []*types.KeyInfo, prealloc []string) erro
 	return st.SetActor(context.Background(), address.NetworkAddress, netact)
 }
 
+func setupActors(st state.Tree, sm vm.StorageMap, actors []Actor) error {
+	ctx := context.Background()
+
+	for _, a := range actors {
+		addr, err := address.NewFromString(a.Address)
+		if err != nil {
+			return err
+		}
+
+		code, err := cid.Decode(a.Code)
+		if err != nil {
+			return err
+		}
+
+		valint, err := strconv.ParseUint(a.Balance, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		initializerData, err := decodeInitParams(st, code, a.InitParams)
+		if err != nil {
+			return errors.Wrapf(err, "invalid init params for actor %s", a.Address)
+		}
+
+		if err := consensus.SetupActor(ctx, st, sm, addr, code, types.NewAttoFILFromFIL(valint), initializerData); err != nil {
+			return errors.Wrapf(err, "failed to set up actor %s", a.Address)
+		}
+	}
+
+	return nil
+}
+
+// decodeInitParams decodes the init params of an actor running code into the
+// initializer data its InitializeState takes.
+func decodeInitParams(st state.Tree, code cid.Cid, raw json.RawMessage) (interface{}, error) {
+	impl, err := st.GetBuiltinActorCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	init, ok := impl.(exec.Initializer)
+	if !ok {
+		if len(raw) > 0 {
+			return nil, fmt.Errorf("actor code %s takes no init params", code)
+		}
+		return nil, nil
+	}
+	if len(raw) == 0 {
+		raw = json.RawMessage("[]")
+	}
+	return abi.DecodeJSONValues(init.InitializerParams(), raw)
+}
+
 func setupMiners(st state.Tree, sm vm.StorageMap, keys []*types.KeyInfo, miners []Miner, pnrg io.Reader) ([]RenderedMinerInfo, error) {
 	var minfos []RenderedMinerInfo
 	ctx := context.Background()
@@ -2,7 +2,9 @@ package gengen_test
 
 import (
 	"context"
+	"encoding/json"
 	"io/ioutil"
+	"math/big"
 	"testing"
 
 	"gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
@@ -11,10 +13,16 @@ import (
 	ds "gx/ipfs/QmUadX5EcvrBmxAV9sE7wUWtWSqxns5K84qKJBixmcT1w9/go-datastore"
 	bserv "gx/ipfs/QmZsGVGCqMCNzHLNMB6q4F6yyvomqf1VxwhJwSfgo1NGaF/go-blockservice"
 
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
 	. "github.com/filecoin-project/go-filecoin/gengen/util"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	"github.com/filecoin-project/go-filecoin/types"
 
 	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
 )
 
 var testConfig = &GenesisCfg{
@@ -73,3 +81,58 @@ func TestGenGenDeterministicBetweenBuilds(t *testing.T) {
 		}
 	}
 }
+
+// initParamsActor records the initializer data it is created with.
+type initParamsActor struct {
+	initializerData interface{}
+}
+
+func (a *initParamsActor) Exports() exec.Exports {
+	return exec.Exports{}
+}
+
+func (a *initParamsActor) InitializeState(_ exec.Storage, initializerData interface{}) error {
+	a.initializerData = initializerData
+	return nil
+}
+
+func (a *initParamsActor) InitializerParams() []abi.Type {
+	return []abi.Type{abi.Address, abi.Integer}
+}
+
+func TestGenGenActorInitParams(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	code := types.NewActorCodeObj("gengeninitparamsactor")
+	impl := &initParamsActor{}
+	require.NoError(builtin.Register(code, "GenGenInitParamsActor", impl))
+	defer delete(builtin.Actors, code.Cid())
+	defer delete(types.ActorCodeCidTypeNames, code.Cid())
+
+	newAddr := address.NewForTestGetter()
+	owner := newAddr()
+	genGen := func(code string, initParams string) error {
+		cfg := &GenesisCfg{
+			Actors: []Actor{{
+				Address:    newAddr().String(),
+				Code:       code,
+				Balance:    "0",
+				InitParams: json.RawMessage(initParams),
+			}},
+		}
+		mds := ds.NewMapDatastore()
+		bstore := blockstore.NewBlockstore(mds)
+		cst := &hamt.CborIpldStore{Blocks: bserv.New(bstore, offline.Exchange(bstore))}
+		_, err := GenGen(context.Background(), cfg, cst, bstore, 0)
+		return err
+	}
+
+	require.NoError(genGen(code.Cid().String(), `["`+owner.String()+`", 7]`))
+	assert.Equal([]interface{}{owner, big.NewInt(7)}, impl.initializerData)
+
+	// Init params are validated against the schema of the code.
+	assert.Error(genGen(code.Cid().String(), `["`+owner.String()+`"]`))
+	// Code that takes no init params refuses them.
+	assert.Error(genGen(types.AccountActorCodeCid.String(), `[]`))
+}
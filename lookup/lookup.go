@@ -41,7 +41,13 @@ func NewChainLookupService(chain chain.ReadStore, queryMethodFromAddr func() (ad
 // GetPeerIDByMinerAddress attempts to get a miner's libp2p identity by loading the actor from the state tree and sending
 // it a "getPeerID" message. The MinerActor is currently the only type of actor which has a peer ID.
 func (c *ChainLookupService) GetPeerIDByMinerAddress(ctx context.Context, minerAddr address.Address) (peer.ID, error) {
-	st, err := c.chainReader.LatestState(ctx)
+	return c.GetPeerIDByMinerAddressAt(ctx, chain.TipSetRef{}, minerAddr)
+}
+
+// GetPeerIDByMinerAddressAt is like GetPeerIDByMinerAddress but uses the state
+// after the tipset referenced by at.
+func (c *ChainLookupService) GetPeerIDByMinerAddressAt(ctx context.Context, at chain.TipSetRef, minerAddr address.Address) (peer.ID, error) {
+	st, err := c.chainReader.StateAt(ctx, at)
 	if err != nil {
 		return peer.ID(""), errors.Wrap(err, "failed to load state tree")
 	}
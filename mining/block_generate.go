@@ -10,8 +10,9 @@ import (
 
 	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 
-	"github.com/filecoin-project/go-filecoin/core"
+	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/proofs"
+	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
 	"github.com/filecoin-project/go-filecoin/vm"
 )
@@ -49,20 +50,10 @@ func (w *DefaultWorker) Generate(ctx context.Context,
 
 	blockHeight := baseHeight + nullBlockCount + 1
 
-	ancestors, err := w.getAncestors(ctx, baseTipSet, types.NewBlockHeight(blockHeight))
-	if err != nil {
-		return nil, errors.Wrap(err, "get base tip set ancestors")
-	}
-
-	pending := w.messagePool.Pending()
-	messages := make([]*types.SignedMessage, len(pending))
-
-	copy(messages, core.OrderMessagesByNonce(pending))
-
 	vms := vm.NewStorageMap(w.blockstore)
-	res, err := w.processor.ApplyMessagesAndPayRewards(ctx, stateTree, vms, messages, w.minerAddr, types.NewBlockHeight(blockHeight), ancestors)
+	_, res, err := w.selectAndApplyMessages(ctx, baseTipSet, stateTree, vms, blockHeight)
 	if err != nil {
-		return nil, errors.Wrap(err, "generate apply messages")
+		return nil, err
 	}
 
 	newStateTreeCid, err := stateTree.Flush(ctx)
@@ -74,6 +65,10 @@ func (w *DefaultWorker) Generate(ctx context.Context,
 		return nil, errors.Wrap(err, "generate flush vm storage map")
 	}
 
+	if err := consensus.StoreEvents(w.blockstore, res.Results); err != nil {
+		return nil, errors.Wrap(err, "generate store events")
+	}
+
 	var receipts []*types.MessageReceipt
 	for _, r := range res.Results {
 		receipts = append(receipts, r.Receipt)
@@ -90,6 +85,9 @@ func (w *DefaultWorker) Generate(ctx context.Context,
 		StateRoot:       newStateTreeCid,
 		Ticket:          ticket,
 	}
+	if err := next.SetCollectionRoots(); err != nil {
+		return nil, errors.Wrap(err, "generate collection roots")
+	}
 
 	// TODO: Should we really be pruning the message pool here at all? Maybe this should happen elsewhere.
 	for i, msg := range res.PermanentFailures {
@@ -112,3 +110,30 @@ func (w *DefaultWorker) Generate(ctx context.Context,
 
 	return next, nil
 }
+
+// selectAndApplyMessages selects messages from the pool for a block at
+// blockHeight on top of baseTipSet and applies them to stateTree, after running
+// the network upgrades scheduled in between. It returns the selected messages
+// along with the results of applying them.
+func (w *DefaultWorker) selectAndApplyMessages(ctx context.Context, baseTipSet types.TipSet, stateTree state.Tree, vms vm.StorageMap, blockHeight uint64) ([]*types.SignedMessage, consensus.ApplyMessagesResponse, error) {
+	baseHeight, err := baseTipSet.Height()
+	if err != nil {
+		return nil, consensus.ApplyMessagesResponse{}, errors.Wrap(err, "get base tip set height")
+	}
+	if err := consensus.RunUpgrades(ctx, stateTree, vms, baseHeight, blockHeight); err != nil {
+		return nil, consensus.ApplyMessagesResponse{}, errors.Wrap(err, "upgrade base state")
+	}
+
+	ancestors, err := w.getAncestors(ctx, baseTipSet, types.NewBlockHeight(blockHeight))
+	if err != nil {
+		return nil, consensus.ApplyMessagesResponse{}, errors.Wrap(err, "get base tip set ancestors")
+	}
+
+	messages := w.messageSelector.SelectMessages(ctx, stateTree, w.messagePool.Pending(), types.BlockGasLimit)
+
+	res, err := w.processor.ApplyMessagesAndPayRewards(ctx, stateTree, vms, messages, w.minerAddr, types.NewBlockHeight(blockHeight), ancestors)
+	if err != nil {
+		return nil, consensus.ApplyMessagesResponse{}, errors.Wrap(err, "generate apply messages")
+	}
+	return messages, res, nil
+}
@@ -0,0 +1,130 @@
+package mining
+
+// Block previews dry-run block generation: they select and apply messages the
+// way Generate does, without a winning ticket and without publishing anything
+// or pruning the message pool.
+
+import (
+	"context"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+// BlockPreview is the outcome of building a block without publishing it.
+type BlockPreview struct {
+	// Base is the tipset the block would be built on and Height its height.
+	Base   types.SortedCidSet
+	Height uint64
+	// Selected are the messages taken from the pool, in application order.
+	Selected []cid.Cid
+	// Included are the selected messages that applied and would go in the
+	// block.
+	Included []cid.Cid
+	// GasUsed is the gas charged for the included messages and GasFees what
+	// their senders paid for it.
+	GasUsed types.GasUnits
+	GasFees *types.AttoFIL
+	// Failures are the selected messages that did not apply and
+	// FailuresByReason counts them by the cause of their failure.
+	Failures         []*PreviewFailure
+	FailuresByReason map[string]int
+	// StateRoot is the root of the state after applying the messages.
+	StateRoot cid.Cid
+}
+
+// PreviewFailure is a selected message that could not be applied.
+type PreviewFailure struct {
+	Message cid.Cid
+	// Permanent is true if the message will never apply and would be removed
+	// from the pool by a real block.
+	Permanent bool
+	Reason    string
+}
+
+// Preview builds a block on baseTipSet from the messages in the pool as if the
+// worker had won the next epoch, and reports what the block would contain.
+// Unlike Generate it neither requires the miner to have power nor removes
+// failed messages from the pool. The resulting state is flushed to compute its
+// root but the actor storage is not.
+func (w *DefaultWorker) Preview(ctx context.Context, baseTipSet types.TipSet) (*BlockPreview, error) {
+	stateTree, err := w.getStateTree(ctx, baseTipSet)
+	if err != nil {
+		return nil, errors.Wrap(err, "get state tree")
+	}
+
+	baseHeight, err := baseTipSet.Height()
+	if err != nil {
+		return nil, errors.Wrap(err, "get base tip set height")
+	}
+	blockHeight := baseHeight + 1
+
+	vms := vm.NewStorageMap(w.blockstore)
+	messages, res, err := w.selectAndApplyMessages(ctx, baseTipSet, stateTree, vms, blockHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	stateRoot, err := stateTree.Flush(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "preview flush state tree")
+	}
+
+	preview := &BlockPreview{
+		Base:             baseTipSet.ToSortedCidSet(),
+		Height:           blockHeight,
+		GasUsed:          types.NewGasUnits(0),
+		GasFees:          types.NewZeroAttoFIL(),
+		FailuresByReason: make(map[string]int),
+		StateRoot:        stateRoot,
+	}
+
+	if preview.Selected, err = messageCids(messages); err != nil {
+		return nil, err
+	}
+	if preview.Included, err = messageCids(res.SuccessfulMessages); err != nil {
+		return nil, err
+	}
+	for _, r := range res.Results {
+		preview.GasUsed += r.GasUsed
+		if r.Receipt != nil && r.Receipt.GasAttoFIL != nil {
+			preview.GasFees = preview.GasFees.Add(r.Receipt.GasAttoFIL)
+		}
+	}
+
+	addFailures := func(msgs []*types.SignedMessage, errs []error, permanent bool) error {
+		for i, msg := range msgs {
+			c, err := msg.Cid()
+			if err != nil {
+				return errors.Wrap(err, "get message cid")
+			}
+			reason := errors.Cause(errs[i]).Error()
+			preview.Failures = append(preview.Failures, &PreviewFailure{Message: c, Permanent: permanent, Reason: reason})
+			preview.FailuresByReason[reason]++
+		}
+		return nil
+	}
+	if err := addFailures(res.PermanentFailures, res.PermanentErrors, true); err != nil {
+		return nil, err
+	}
+	if err := addFailures(res.TemporaryFailures, res.TemporaryErrors, false); err != nil {
+		return nil, err
+	}
+
+	return preview, nil
+}
+
+func messageCids(msgs []*types.SignedMessage) ([]cid.Cid, error) {
+	cids := make([]cid.Cid, len(msgs))
+	for i, msg := range msgs {
+		c, err := msg.Cid()
+		if err != nil {
+			return nil, errors.Wrap(err, "get message cid")
+		}
+		cids[i] = c
+	}
+	return cids, nil
+}
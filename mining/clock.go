@@ -0,0 +1,77 @@
+package mining
+
+import (
+	"time"
+)
+
+// Clock tells the time.  It is an interface so that tests can control the
+// passing of time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After returns a channel that receives the current time once d has
+	// passed.
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+// NewSystemClock returns a Clock backed by the system time.
+func NewSystemClock() Clock {
+	return systemClock{}
+}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// EpochClock maps wall-clock time to epochs.  Epoch 0 starts at the genesis
+// time and each epoch lasts one block time, so the epoch is the height a
+// block mined now should have, null blocks included.
+type EpochClock struct {
+	clock       Clock
+	genesisTime time.Time
+	blockTime   time.Duration
+}
+
+// NewEpochClock returns an EpochClock counting epochs of blockTime from
+// genesisTime.
+func NewEpochClock(clock Clock, genesisTime time.Time, blockTime time.Duration) *EpochClock {
+	return &EpochClock{
+		clock:       clock,
+		genesisTime: genesisTime,
+		blockTime:   blockTime,
+	}
+}
+
+// EpochAt returns the epoch t falls in.  Times before genesis are in epoch 0.
+func (ec *EpochClock) EpochAt(t time.Time) uint64 {
+	if !t.After(ec.genesisTime) {
+		return 0
+	}
+	return uint64(t.Sub(ec.genesisTime) / ec.blockTime)
+}
+
+// CurrentEpoch returns the epoch the clock's current time falls in.
+func (ec *EpochClock) CurrentEpoch() uint64 {
+	return ec.EpochAt(ec.clock.Now())
+}
+
+// EpochStart returns the time epoch starts at.
+func (ec *EpochClock) EpochStart(epoch uint64) time.Time {
+	return ec.genesisTime.Add(time.Duration(epoch) * ec.blockTime)
+}
+
+// BlockTime returns the duration of an epoch.
+func (ec *EpochClock) BlockTime() time.Duration {
+	return ec.blockTime
+}
+
+// WaitUntil returns a channel that receives once the clock reaches t.
+func (ec *EpochClock) WaitUntil(t time.Time) <-chan time.Time {
+	return ec.clock.After(t.Sub(ec.clock.Now()))
+}
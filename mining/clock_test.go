@@ -0,0 +1,55 @@
+package mining
+
+import (
+	"testing"
+	"time"
+
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+)
+
+func TestEpochClock(t *testing.T) {
+	assert := assert.New(t)
+
+	genesis := time.Unix(1000, 0)
+	fc := NewFakeClock(genesis.Add(-time.Second))
+	ec := NewEpochClock(fc, genesis, 10*time.Second)
+
+	assert.Equal(uint64(0), ec.CurrentEpoch())
+
+	fc.Advance(time.Second)
+	assert.Equal(uint64(0), ec.CurrentEpoch())
+
+	fc.Advance(9 * time.Second)
+	assert.Equal(uint64(1), ec.CurrentEpoch())
+
+	fc.Advance(25 * time.Second)
+	assert.Equal(uint64(3), ec.CurrentEpoch())
+
+	assert.Equal(genesis.Add(30*time.Second), ec.EpochStart(3))
+	assert.Equal(uint64(3), ec.EpochAt(ec.EpochStart(3)))
+	assert.Equal(uint64(2), ec.EpochAt(ec.EpochStart(3).Add(-time.Nanosecond)))
+}
+
+func TestFakeClockAfter(t *testing.T) {
+	assert := assert.New(t)
+
+	fc := NewFakeClock(time.Unix(0, 0))
+	assert.Equal(ChannelReceivedValue, receiveTimeCh(fc.After(0)))
+
+	ch := fc.After(2 * time.Second)
+	assert.Equal(1, fc.Waiters())
+	fc.Advance(time.Second)
+	assert.Equal(ChannelEmpty, receiveTimeCh(ch))
+	fc.Advance(time.Second)
+	assert.Equal(ChannelReceivedValue, receiveTimeCh(ch))
+	assert.Equal(0, fc.Waiters())
+}
+
+func receiveTimeCh(ch <-chan time.Time) int {
+	select {
+	case <-ch:
+		return ChannelReceivedValue
+	default:
+		return ChannelEmpty
+	}
+}
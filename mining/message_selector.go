@@ -0,0 +1,123 @@
+package mining
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/core"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// MessageSelector chooses which pending messages go into a new block and in
+// what order.  The processor may still drop selected messages that fail to
+// apply.
+type MessageSelector interface {
+	// SelectMessages returns the messages to include in a block mined on
+	// top of the state st, using at most gasLimit gas.
+	SelectMessages(ctx context.Context, st state.Tree, pending []*types.SignedMessage, gasLimit types.GasUnits) []*types.SignedMessage
+}
+
+// NonceOrderSelector selects every pending message, grouped by sender and
+// ordered by nonce.  It leaves it to the processor to drop the messages that
+// don't fit in the block.
+type NonceOrderSelector struct{}
+
+var _ MessageSelector = (*NonceOrderSelector)(nil)
+
+// SelectMessages implements MessageSelector.
+func (NonceOrderSelector) SelectMessages(ctx context.Context, st state.Tree, pending []*types.SignedMessage, gasLimit types.GasUnits) []*types.SignedMessage {
+	messages := make([]*types.SignedMessage, len(pending))
+	copy(messages, pending)
+	return core.OrderMessagesByNonce(messages)
+}
+
+// GasPriceSelector selects messages to maximize the fees the miner earns.
+// It builds a chain of consecutive nonces for each sender starting at the
+// sender's nonce in the state, ranks the chains by the fee they pay (their
+// effective gas price times their total gas limit), and packs the chains
+// greedily into the block.  When a chain doesn't fit whole, the longest
+// prefix that fits is taken.
+type GasPriceSelector struct{}
+
+var _ MessageSelector = (*GasPriceSelector)(nil)
+
+// messageChain is a sequence of messages from one sender with consecutive
+// nonces.
+type messageChain struct {
+	from     address.Address
+	messages []*types.SignedMessage
+	fee      *types.AttoFIL
+}
+
+// SelectMessages implements MessageSelector.
+func (GasPriceSelector) SelectMessages(ctx context.Context, st state.Tree, pending []*types.SignedMessage, gasLimit types.GasUnits) []*types.SignedMessage {
+	chains := buildMessageChains(ctx, st, pending)
+	sort.Slice(chains, func(i, j int) bool {
+		if !chains[i].fee.Equal(chains[j].fee) {
+			return chains[i].fee.GreaterThan(chains[j].fee)
+		}
+		return chains[i].from.String() < chains[j].from.String()
+	})
+
+	var selected []*types.SignedMessage
+	remaining := gasLimit
+	for _, chain := range chains {
+		for _, msg := range chain.messages {
+			if msg.GasLimit > remaining {
+				// Later messages in the chain can't be applied
+				// without this one.
+				break
+			}
+			selected = append(selected, msg)
+			remaining -= msg.GasLimit
+		}
+	}
+	return selected
+}
+
+// buildMessageChains groups pending messages by sender into chains of
+// consecutive nonces.  A sender's chain starts at the sender's nonce in st
+// and stops at the first gap, messages with stale or duplicate nonces are
+// left out.
+func buildMessageChains(ctx context.Context, st state.Tree, pending []*types.SignedMessage) []*messageChain {
+	bySender := make(map[address.Address][]*types.SignedMessage)
+	for _, msg := range pending {
+		bySender[msg.From] = append(bySender[msg.From], msg)
+	}
+
+	var chains []*messageChain
+	for from, msgs := range bySender {
+		sort.Slice(msgs, func(i, j int) bool { return msgs[i].Nonce < msgs[j].Nonce })
+
+		next := msgs[0].Nonce
+		if act, err := st.GetActor(ctx, from); err == nil {
+			next = act.Nonce
+		}
+
+		chain := &messageChain{from: from, fee: types.NewZeroAttoFIL()}
+		for _, msg := range msgs {
+			if msg.Nonce < next {
+				continue
+			}
+			if msg.Nonce > next {
+				break
+			}
+			chain.messages = append(chain.messages, msg)
+			chain.fee = chain.fee.Add(messageFee(msg))
+			next++
+		}
+		if len(chain.messages) > 0 {
+			chains = append(chains, chain)
+		}
+	}
+	return chains
+}
+
+// messageFee is the most a message can pay the miner, its gas price times its
+// gas limit.
+func messageFee(msg *types.SignedMessage) *types.AttoFIL {
+	return msg.GasPrice.MulBigInt(new(big.Int).SetUint64(uint64(msg.GasLimit)))
+}
@@ -0,0 +1,86 @@
+package mining_test
+
+import (
+	"context"
+	"testing"
+
+	hamt "gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/mining"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func newSelectorTestMessage(from address.Address, nonce uint64, price int64, limit uint64) *types.SignedMessage {
+	msg := types.NewMessage(from, address.NetworkAddress, nonce, nil, "", nil)
+	return &types.SignedMessage{MeteredMessage: *types.NewMeteredMessage(*msg, types.NewGasPrice(price), types.NewGasUnits(limit))}
+}
+
+func TestGasPriceSelector(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	addrGetter := address.NewForTestGetter()
+	alice, bob, carol := addrGetter(), addrGetter(), addrGetter()
+
+	t.Run("ranks chains by fee and packs them under the gas limit", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		aliceActor := th.RequireNewAccountActor(require, types.NewAttoFILFromFIL(100))
+		aliceActor.Nonce = 3
+		_, st := th.RequireMakeStateTree(require, hamt.NewCborStore(), map[address.Address]*actor.Actor{
+			alice: aliceActor,
+			bob:   th.RequireNewAccountActor(require, types.NewAttoFILFromFIL(100)),
+		})
+
+		// Alice's chain pays 2*100 + 10*100 = 1200, bob's pays 5*300 = 1500.
+		alice3 := newSelectorTestMessage(alice, 3, 2, 100)
+		alice4 := newSelectorTestMessage(alice, 4, 10, 100)
+		bob0 := newSelectorTestMessage(bob, 0, 5, 300)
+
+		selected := mining.GasPriceSelector{}.SelectMessages(ctx, st, []*types.SignedMessage{alice4, bob0, alice3}, types.NewGasUnits(1000))
+		assert.Equal([]*types.SignedMessage{bob0, alice3, alice4}, selected)
+
+		// With room for only 450 gas bob's chain goes first and only the
+		// head of alice's chain fits after it.
+		selected = mining.GasPriceSelector{}.SelectMessages(ctx, st, []*types.SignedMessage{alice4, bob0, alice3}, types.NewGasUnits(450))
+		assert.Equal([]*types.SignedMessage{bob0, alice3}, selected)
+	})
+
+	t.Run("drops stale nonces and messages after a gap", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		aliceActor := th.RequireNewAccountActor(require, types.NewAttoFILFromFIL(100))
+		aliceActor.Nonce = 1
+		_, st := th.RequireMakeStateTree(require, hamt.NewCborStore(), map[address.Address]*actor.Actor{
+			alice: aliceActor,
+		})
+
+		stale := newSelectorTestMessage(alice, 0, 100, 10)
+		next := newSelectorTestMessage(alice, 1, 1, 10)
+		gapped := newSelectorTestMessage(alice, 3, 100, 10)
+		// Carol has no actor yet, her chain starts at her lowest nonce.
+		carol0 := newSelectorTestMessage(carol, 0, 1, 1)
+
+		selected := mining.GasPriceSelector{}.SelectMessages(ctx, st, []*types.SignedMessage{gapped, stale, next, carol0}, types.BlockGasLimit)
+		assert.Equal([]*types.SignedMessage{next, carol0}, selected)
+	})
+}
+
+func TestNonceOrderSelector(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	from := address.NewForTestGetter()()
+	msg0 := newSelectorTestMessage(from, 0, 1, 1)
+	msg1 := newSelectorTestMessage(from, 1, 100, 1)
+
+	selected := mining.NonceOrderSelector{}.SelectMessages(context.Background(), nil, []*types.SignedMessage{msg1, msg0}, types.NewGasUnits(0))
+	assert.Equal([]*types.SignedMessage{msg0, msg1}, selected)
+}
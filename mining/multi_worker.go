@@ -0,0 +1,42 @@
+package mining
+
+import (
+	"context"
+	"sync"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// MultiWorker is a Worker mining for several miners at once.  Every run it
+// has each of its workers draw its own ticket and run its own election on the
+// same base, concurrently, and outputs every winning block.
+type MultiWorker struct {
+	workers []Worker
+}
+
+var _ Worker = &MultiWorker{}
+
+// NewMultiWorker returns a MultiWorker running workers.
+func NewMultiWorker(workers ...Worker) *MultiWorker {
+	return &MultiWorker{workers: workers}
+}
+
+// Mine runs Mine on each worker and returns true if any of them won.
+func (mw *MultiWorker) Mine(ctx context.Context, base types.TipSet, nullBlkCount int, outCh chan<- Output) bool {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	won := false
+	for _, w := range mw.workers {
+		wg.Add(1)
+		go func(w Worker) {
+			defer wg.Done()
+			if w.Mine(ctx, base, nullBlkCount, outCh) {
+				mu.Lock()
+				won = true
+				mu.Unlock()
+			}
+		}(w)
+	}
+	wg.Wait()
+	return won
+}
@@ -0,0 +1,32 @@
+package mining
+
+import (
+	"context"
+	"testing"
+
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestMultiWorker(t *testing.T) {
+	assert, _, ts := newTestUtils(t)
+
+	lose := func(c context.Context, inTS types.TipSet, nBC int, outCh chan<- Output) bool {
+		return false
+	}
+	win := func(c context.Context, inTS types.TipSet, nBC int, outCh chan<- Output) bool {
+		assert.Equal(ts, inTS)
+		assert.Equal(2, nBC)
+		outCh <- Output{NewBlock: inTS.ToSlice()[0]}
+		return true
+	}
+
+	outCh := make(chan Output, 2)
+	mw := NewMultiWorker(NewTestWorkerWithDeps(win), NewTestWorkerWithDeps(lose), NewTestWorkerWithDeps(win))
+	assert.True(mw.Mine(context.Background(), ts, 2, outCh))
+	assert.Equal(2, len(outCh))
+
+	mw = NewMultiWorker(NewTestWorkerWithDeps(lose), NewTestWorkerWithDeps(lose))
+	assert.False(mw.Mine(context.Background(), ts, 2, outCh))
+}
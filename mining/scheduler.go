@@ -31,6 +31,13 @@ package mining
 // do VDFs and PoSTs fit into mining, and what is the lookback parameter for
 // challenge sampling.  For more details see:
 // https://gist.github.com/whyrusleeping/4c05fd902f7123bdd1c729e3fffed797
+//
+// The epochScheduler, which nodes mine with, replaces the collect delay with
+// wall-clock epochs counted from the genesis time by an EpochClock.  For each
+// epoch it collects until a cutoff into the epoch, then mines on the heaviest
+// tipset seen, with as many null blocks as epochs have passed since that
+// tipset's height.  Null block counts therefore follow the clock rather than
+// how often the scheduler happened to poll.
 
 import (
 	"context"
@@ -168,6 +175,107 @@ func NewScheduler(w Worker, md time.Duration, f func() types.TipSet) Scheduler {
 	return &timingScheduler{worker: w, mineDelay: md, pollHeadFunc: f}
 }
 
+type epochScheduler struct {
+	// worker contains the actual mining logic.
+	worker Worker
+	// clock tells the current epoch.
+	clock *EpochClock
+	// cutoff is how long into each epoch the scheduler collects tipsets
+	// before mining on the heaviest one.
+	cutoff time.Duration
+	// pollHeadFunc is the function the scheduler uses to poll for the
+	// current heaviest tipset
+	pollHeadFunc func() types.TipSet
+
+	isStarted bool
+}
+
+// NewEpochScheduler returns a Scheduler that mines on the input worker once
+// per epoch of clock, on the heaviest tipset polled cutoff into the epoch.
+func NewEpochScheduler(w Worker, clock *EpochClock, cutoff time.Duration, f func() types.TipSet) Scheduler {
+	return &epochScheduler{worker: w, clock: clock, cutoff: cutoff, pollHeadFunc: f}
+}
+
+// Start starts mining, see Scheduler.  Epochs whose cutoff passes while the
+// worker is mining are not mined.
+func (s *epochScheduler) Start(miningCtx context.Context) (<-chan Output, *sync.WaitGroup) {
+	// we buffer 1 to make sure we do not get blocked when shutting down
+	outCh := make(chan Output, 1)
+	var doneWg sync.WaitGroup    // for internal use
+	var extDoneWg sync.WaitGroup // for external use
+
+	log.Debugf("Epoch scheduler starting main loop")
+	doneWg.Add(1)
+
+	s.isStarted = true
+	go func() {
+		defer doneWg.Done()
+		var prevBase types.TipSet
+		var prevWon bool
+		epoch := s.clock.CurrentEpoch()
+		for {
+			// Collect until the cutoff.
+			select {
+			case <-miningCtx.Done():
+				s.isStarted = false
+				return
+			case <-s.clock.WaitUntil(s.clock.EpochStart(epoch).Add(s.cutoff)):
+			}
+			base := s.pollHeadFunc()
+			if base == nil { // Don't try to mine on an unset head.
+				outCh <- NewOutput(nil, errors.New("cannot mine on unset (nil) head"))
+				return
+			}
+
+			nullBlkCount, ok := epochNullBlkCount(epoch, base)
+			if !ok {
+				log.Debugf("Not mining epoch %d on base %s at or above it", epoch, base.String())
+			} else if prevWon && prevBase.Equals(base) {
+				// The block we mined has not become the head yet, mining
+				// on the same base would compete with it.
+				log.Debugf("Not mining epoch %d on base %s we already won on", epoch, base.String())
+			} else {
+				prevWon = s.worker.Mine(miningCtx, base, nullBlkCount, outCh)
+				prevBase = base
+			}
+
+			next := s.clock.CurrentEpoch()
+			if next <= epoch {
+				next = epoch + 1
+			}
+			epoch = next
+		}
+	}()
+
+	// This tear down goroutine waits for all work to be done before closing
+	// channels.  When this goroutine is complete, external code can
+	// consider the scheduler to be done.
+	extDoneWg.Add(1)
+	go func() {
+		defer extDoneWg.Done()
+		doneWg.Wait()
+		close(outCh)
+	}()
+	return outCh, &extDoneWg
+}
+
+// IsStarted is called when starting mining to tell whether the scheduler should be
+// started
+func (s *epochScheduler) IsStarted() bool {
+	return s.isStarted
+}
+
+// epochNullBlkCount returns how many null blocks a block mined in epoch on
+// top of base needs so that its height is epoch.  It returns false if base is
+// already at or above epoch.
+func epochNullBlkCount(epoch uint64, base types.TipSet) (int, bool) {
+	h, err := base.Height()
+	if err != nil || epoch <= h {
+		return 0, false
+	}
+	return int(epoch - h - 1), true
+}
+
 // MineOnce is a convenience function that presents a synchronous blocking
 // interface to the mining scheduler.  The worker will mine as many null blocks
 // on top of the input tipset as necessary and output the winning block.
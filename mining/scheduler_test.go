@@ -281,3 +281,83 @@ func TestSchedulerMultiRoundWithCollect(t *testing.T) {
 
 	assert.Equal(ChannelClosed, ReceiveOutCh(outCh))
 }
+
+func TestEpochSchedulerNullBlocksFollowTheClock(t *testing.T) {
+	assert, require, ts := newTestUtils(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	blockTime := 10 * time.Second
+	genesis := time.Unix(1000, 0)
+	fc := NewFakeClock(genesis)
+	ec := NewEpochClock(fc, genesis, blockTime)
+
+	type run struct {
+		base         types.TipSet
+		nullBlkCount int
+	}
+	runs := make(chan run, 10)
+	recordMine := func(c context.Context, inTS types.TipSet, nBC int, outCh chan<- Output) bool {
+		runs <- run{inTS, nBC}
+		return false
+	}
+	var head types.TipSet
+	headFunc := func() types.TipSet {
+		return head
+	}
+	head = ts
+	scheduler := NewEpochScheduler(NewTestWorkerWithDeps(recordMine), ec, time.Second, headFunc)
+	scheduler.Start(ctx)
+
+	// Epoch 0 is the genesis height, nothing is mined until epoch 1.
+	waitForClockWaiter(t, fc)
+	fc.Advance(time.Second)
+	waitForClockWaiter(t, fc)
+	assert.Equal(0, len(runs))
+
+	fc.Advance(blockTime)
+	assert.Equal(run{ts, 0}, <-runs)
+
+	// Two epochs pass with the head unchanged.
+	waitForClockWaiter(t, fc)
+	fc.Advance(2 * blockTime)
+	assert.Equal(run{ts, 1}, <-runs)
+	assert.Equal(run{ts, 2}, <-runs)
+
+	// The head seen by the cutoff is mined on.
+	blk := &types.Block{StateRoot: types.SomeCid(), Height: 3}
+	ts2 := th.RequireNewTipSet(require, blk)
+	waitForClockWaiter(t, fc)
+	head = ts2
+	fc.Advance(blockTime)
+	assert.Equal(run{ts2, 0}, <-runs)
+}
+
+func TestEpochSchedulerErrorsOnUnsetHead(t *testing.T) {
+	assert, _, _ := newTestUtils(t)
+
+	genesis := time.Unix(1000, 0)
+	ec := NewEpochClock(NewFakeClock(genesis.Add(time.Hour)), genesis, time.Second)
+	nilHeadFunc := func() types.TipSet {
+		return nil
+	}
+	nothingMine := func(c context.Context, inTS types.TipSet, nBC int, outCh chan<- Output) bool {
+		return false
+	}
+	scheduler := NewEpochScheduler(NewTestWorkerWithDeps(nothingMine), ec, 0, nilHeadFunc)
+	outCh, doneWg := scheduler.Start(context.Background())
+	output := <-outCh
+	assert.Error(output.Err)
+	doneWg.Wait()
+}
+
+// waitForClockWaiter waits until the scheduler is waiting on fc.
+func waitForClockWaiter(t *testing.T, fc *FakeClock) {
+	deadline := time.Now().Add(5 * time.Second)
+	for fc.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the scheduler to wait on the clock")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -140,3 +140,71 @@ func (tv *TestPowerTableView) Miner(ctx context.Context, st state.Tree, bstore b
 func (tv *TestPowerTableView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool {
 	return true
 }
+
+// FakeClock is a Clock whose time only moves when Advance is called.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeClockWaiter
+}
+
+type fakeClockWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+var _ Clock = &FakeClock{}
+
+// NewFakeClock returns a FakeClock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the fake time.
+func (fc *FakeClock) Now() time.Time {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.now
+}
+
+// After returns a channel that receives once the fake time has been advanced
+// by d.
+func (fc *FakeClock) After(d time.Duration) <-chan time.Time {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	at := fc.now.Add(d)
+	if !at.After(fc.now) {
+		ch <- fc.now
+		return ch
+	}
+	fc.waiters = append(fc.waiters, fakeClockWaiter{at: at, ch: ch})
+	return ch
+}
+
+// Advance moves the fake time forward by d, firing the channels returned by
+// After that are due.
+func (fc *FakeClock) Advance(d time.Duration) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.now = fc.now.Add(d)
+	var waiting []fakeClockWaiter
+	for _, w := range fc.waiters {
+		if w.at.After(fc.now) {
+			waiting = append(waiting, w)
+			continue
+		}
+		w.ch <- fc.now
+	}
+	fc.waiters = waiting
+}
+
+// Waiters returns how many channels returned by After have not fired yet.
+// Tests use it to know the code under test is waiting on the clock.
+func (fc *FakeClock) Waiters() int {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return len(fc.waiters)
+}
@@ -6,6 +6,7 @@ package mining
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
@@ -65,6 +66,17 @@ type MessageApplier interface {
 	ApplyMessagesAndPayRewards(ctx context.Context, st state.Tree, vms vm.StorageMap, messages []*types.SignedMessage, minerAddr address.Address, bh *types.BlockHeight, ancestors []types.TipSet) (consensus.ApplyMessagesResponse, error)
 }
 
+// WorkerStats are counters of a DefaultWorker's mining runs.
+type WorkerStats struct {
+	// LastBase is the tipset of the latest mining run.
+	LastBase types.SortedCidSet
+	// TicketsTried is how many tickets the worker drew and ran an election
+	// with.
+	TicketsTried uint64
+	// BlocksWon is how many of those tickets won.
+	BlocksWon uint64
+}
+
 // DefaultWorker runs a mining job.
 type DefaultWorker struct {
 	createPoSTFunc  DoSomeWorkFunc
@@ -77,12 +89,16 @@ type DefaultWorker struct {
 	getAncestors GetAncestors
 
 	// core filecoin things
-	messagePool *core.MessagePool
-	processor   MessageApplier
-	powerTable  consensus.PowerTableView
-	blockstore  blockstore.Blockstore
-	cstore      *hamt.CborIpldStore
-	blockTime   time.Duration
+	messagePool     *core.MessagePool
+	messageSelector MessageSelector
+	processor       MessageApplier
+	powerTable      consensus.PowerTableView
+	blockstore      blockstore.Blockstore
+	cstore          *hamt.CborIpldStore
+	blockTime       time.Duration
+
+	statsLk sync.Mutex
+	stats   WorkerStats
 }
 
 // NewDefaultWorker instantiates a new Worker.
@@ -139,6 +155,7 @@ func NewDefaultWorkerWithDeps(messagePool *core.MessagePool,
 		getWeight:       getWeight,
 		getAncestors:    getAncestors,
 		messagePool:     messagePool,
+		messageSelector: GasPriceSelector{},
 		processor:       processor,
 		powerTable:      powerTable,
 		blockstore:      bs,
@@ -151,6 +168,20 @@ func NewDefaultWorkerWithDeps(messagePool *core.MessagePool,
 	}
 }
 
+// SetMessageSelector replaces the selector the worker uses to choose the
+// messages in the blocks it generates.  Workers use a GasPriceSelector by
+// default.
+func (w *DefaultWorker) SetMessageSelector(selector MessageSelector) {
+	w.messageSelector = selector
+}
+
+// Stats returns the worker's counters.
+func (w *DefaultWorker) Stats() WorkerStats {
+	w.statsLk.Lock()
+	defer w.statsLk.Unlock()
+	return w.stats
+}
+
 // DoSomeWorkFunc is a dummy function that mimics doing something time-consuming
 // in the mining loop such as computing proofs. Pass a function that calls Sleep()
 // is a good idea for now.
@@ -175,6 +206,10 @@ func (w *DefaultWorker) Mine(ctx context.Context, base types.TipSet, nullBlkCoun
 		return false
 	}
 
+	w.statsLk.Lock()
+	w.stats.LastBase = base.ToSortedCidSet()
+	w.statsLk.Unlock()
+
 	log.Debugf("Mining on tipset: %s, with %d null blocks.", base.String(), nullBlkCount)
 	if ctx.Err() != nil {
 		log.Warningf("Worker.Mine returning with ctx error %s", ctx.Err().Error())
@@ -213,6 +248,13 @@ func (w *DefaultWorker) Mine(ctx context.Context, base types.TipSet, nullBlkCoun
 		return false
 	}
 
+	w.statsLk.Lock()
+	w.stats.TicketsTried++
+	if weHaveAWinner {
+		w.stats.BlocksWon++
+	}
+	w.statsLk.Unlock()
+
 	if weHaveAWinner {
 		next, err := w.Generate(ctx, base, ticket, proof, uint64(nullBlkCount))
 		if err == nil {
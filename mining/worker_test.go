@@ -15,6 +15,7 @@ import (
 
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/config"
 	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/core"
 	"github.com/filecoin-project/go-filecoin/mining"
@@ -60,6 +61,10 @@ func Test_Mine(t *testing.T) {
 	r := <-outCh
 	assert.NoError(r.Err)
 	assert.True(doSomeWorkCalled)
+	stats := worker.Stats()
+	assert.Equal(tipSet.ToSortedCidSet(), stats.LastBase)
+	assert.Equal(uint64(1), stats.TicketsTried)
+	assert.Equal(uint64(1), stats.BlocksWon)
 	cancel()
 	// Block generation fails.
 	ctx, cancel = context.WithCancel(context.Background())
@@ -95,7 +100,7 @@ func TestGenerate(t *testing.T) {
 
 func sharedSetupInitial() (*hamt.CborIpldStore, *core.MessagePool, cid.Cid) {
 	cst := hamt.NewCborStore()
-	pool := core.NewMessagePool()
+	pool := core.NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 	// Install the fake actor so we can execute it.
 	fakeActorCodeCid := types.AccountActorCodeCid
 	return cst, pool, fakeActorCodeCid
@@ -298,6 +303,78 @@ func TestGeneratePoolBlockResults(t *testing.T) {
 	assert.Len(blk.Messages, 1) // This is the good message
 }
 
+func TestPreview(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	CreatePoSTFunc := func() {}
+
+	ctx := context.Background()
+	mockSigner, blockSignerAddr := setupSigner()
+	newCid := types.NewCidForTestGetter()
+	st, pool, addrs, cst, bs := sharedSetup(t, mockSigner)
+
+	getStateTree := func(c context.Context, ts types.TipSet) (state.Tree, error) {
+		return st, nil
+	}
+	getAncestors := func(ctx context.Context, ts types.TipSet, newBlockHeight *types.BlockHeight) ([]types.TipSet, error) {
+		return nil, nil
+	}
+	// The worker has no power, which Preview does not require.
+	worker := mining.NewDefaultWorkerWithDeps(pool, getStateTree, getWeightTest, getAncestors, consensus.NewDefaultProcessor(),
+		&th.TestView{}, bs, cst, addrs[3], blockSignerAddr, mockSigner, th.BlockTimeTest, CreatePoSTFunc)
+
+	// A temporary failure: addrs[2] is not an account.
+	msg1 := types.NewMessage(addrs[2], addrs[0], 0, nil, "", nil)
+	smsg1, err := types.NewSignedMessage(*msg1, &mockSigner, types.NewGasPrice(0), types.NewGasUnits(0))
+	require.NoError(err)
+
+	// A good message.
+	msg2 := types.NewMessage(addrs[0], addrs[1], 0, nil, "", nil)
+	smsg2, err := types.NewSignedMessage(*msg2, &mockSigner, types.NewGasPrice(0), types.NewGasUnits(0))
+	require.NoError(err)
+
+	// Two permanent failures: sending to self.
+	msg3 := types.NewMessage(addrs[0], addrs[0], 1, nil, "", nil)
+	smsg3, err := types.NewSignedMessage(*msg3, &mockSigner, types.NewGasPrice(0), types.NewGasUnits(0))
+	require.NoError(err)
+
+	msg4 := types.NewMessage(addrs[1], addrs[1], 0, nil, "", nil)
+	smsg4, err := types.NewSignedMessage(*msg4, &mockSigner, types.NewGasPrice(0), types.NewGasUnits(0))
+	require.NoError(err)
+
+	for _, smsg := range []*types.SignedMessage{smsg1, smsg2, smsg3, smsg4} {
+		_, err := pool.Add(smsg)
+		require.NoError(err)
+	}
+
+	baseBlock := types.Block{
+		Parents:   types.NewSortedCidSet(newCid()),
+		Height:    types.Uint64(100),
+		StateRoot: newCid(),
+	}
+	baseTipSet := th.RequireNewTipSet(require, &baseBlock)
+	preview, err := worker.Preview(ctx, baseTipSet)
+	require.NoError(err)
+
+	assert.Equal(baseTipSet.ToSortedCidSet(), preview.Base)
+	assert.Equal(uint64(101), preview.Height)
+	assert.Len(preview.Selected, 4)
+
+	smsg2Cid, err := smsg2.Cid()
+	require.NoError(err)
+	assert.Equal([]cid.Cid{smsg2Cid}, preview.Included)
+
+	assert.Len(preview.Failures, 3)
+	assert.Equal(2, preview.FailuresByReason["cannot send to self"])
+	for _, f := range preview.Failures {
+		assert.Equal(f.Reason == "cannot send to self", f.Permanent)
+	}
+	assert.True(preview.StateRoot.Defined())
+
+	// Nothing is removed from the pool.
+	assert.Len(pool.Pending(), 4)
+}
+
 func TestGenerateSetsBasicFields(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
@@ -462,3 +539,46 @@ func setupSigner() (types.MockSigner, address.Address) {
 	blockSignerAddr := mockSigner.Addresses[len(mockSigner.Addresses)-1]
 	return mockSigner, blockSignerAddr
 }
+
+type emptySelector struct{}
+
+func (emptySelector) SelectMessages(ctx context.Context, st state.Tree, pending []*types.SignedMessage, gasLimit types.GasUnits) []*types.SignedMessage {
+	return nil
+}
+
+func TestGenerateUsesMessageSelector(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ctx := context.Background()
+	mockSigner, blockSignerAddr := setupSigner()
+	newCid := types.NewCidForTestGetter()
+
+	st, pool, addrs, cst, bs := sharedSetup(t, mockSigner)
+	getStateTree := func(c context.Context, ts types.TipSet) (state.Tree, error) {
+		return st, nil
+	}
+	getAncestors := func(ctx context.Context, ts types.TipSet, newBlockHeight *types.BlockHeight) ([]types.TipSet, error) {
+		return nil, nil
+	}
+	worker := mining.NewDefaultWorkerWithDeps(pool, getStateTree, getWeightTest, getAncestors, consensus.NewDefaultProcessor(),
+		&th.TestView{}, bs, cst, addrs[3], blockSignerAddr, mockSigner, th.BlockTimeTest, func() {})
+	worker.SetMessageSelector(emptySelector{})
+
+	msg := types.NewMessage(addrs[0], addrs[1], 0, nil, "", nil)
+	smsg, err := types.NewSignedMessage(*msg, &mockSigner, types.NewGasPrice(0), types.NewGasUnits(0))
+	require.NoError(err)
+	pool.Add(smsg)
+
+	baseBlock := types.Block{
+		Parents:   types.NewSortedCidSet(newCid()),
+		Height:    types.Uint64(100),
+		StateRoot: newCid(),
+		Proof:     proofs.PoStProof{},
+	}
+	blk, err := worker.Generate(ctx, th.RequireNewTipSet(require, &baseBlock), nil, proofs.PoStProof{}, 0)
+	require.NoError(err)
+
+	assert.Len(blk.Messages, 0)
+	assert.Len(pool.Pending(), 1)
+}
@@ -18,7 +18,7 @@ func (node *Node) AddNewBlock(ctx context.Context, b *types.Block) (err error) {
 	// Put block in storage wired to an exchange so this node and other
 	// nodes can fetch it.
 	log.Debugf("putting block in bitswap exchange: %s", b.Cid().String())
-	blkCid, err := node.OnlineStore.Put(ctx, b)
+	blkCid, err := types.PutBlock(ctx, node.OnlineStore, b)
 	if err != nil {
 		return errors.Wrap(err, "could not add new block to online storage")
 	}
@@ -47,7 +47,7 @@ func (node *Node) processBlock(ctx context.Context, pubSubMsg *pubsub.Message) (
 	log.Infof("Received new block from network cid: %s", blk.Cid().String())
 	log.Debugf("Received new block from network: %s", blk)
 
-	err = node.Syncer.HandleNewBlocks(ctx, []cid.Cid{blk.Cid()})
+	err = node.Syncer.HandleNewBlocksFromPeer(ctx, pubSubMsg.GetFrom(), []cid.Cid{blk.Cid()})
 	if err != nil {
 		return errors.Wrap(err, "processing block from network")
 	}
@@ -0,0 +1,112 @@
+package node
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"gx/ipfs/QmUadX5EcvrBmxAV9sE7wUWtWSqxns5K84qKJBixmcT1w9/go-datastore"
+	"gx/ipfs/QmUadX5EcvrBmxAV9sE7wUWtWSqxns5K84qKJBixmcT1w9/go-datastore/namespace"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/config"
+	"github.com/filecoin-project/go-filecoin/proofs/sectorbuilder"
+	"github.com/filecoin-project/go-filecoin/protocol/storage"
+)
+
+// additionalMiner is a miner from mining.miners the node mines for besides
+// the one at mining.minerAddress.
+type additionalMiner struct {
+	cfg           config.MinerConfig
+	owner         address.Address
+	sectorBuilder sectorbuilder.SectorBuilder
+	storageMiner  *storage.Miner
+}
+
+// minerNode is the node as the storage miner of an additional miner sees it,
+// with that miner's sector builder.
+type minerNode struct {
+	*Node
+	sectorBuilder sectorbuilder.SectorBuilder
+}
+
+// SectorBuilder returns the miner's sector builder.
+func (mn *minerNode) SectorBuilder() sectorbuilder.SectorBuilder {
+	return mn.sectorBuilder
+}
+
+// MinerConfigs returns every miner the node mines for, the one at
+// mining.minerAddress first.
+func (node *Node) MinerConfigs() []config.MinerConfig {
+	mcfg := node.Repo.Config().Mining
+
+	var out []config.MinerConfig
+	if !mcfg.MinerAddress.Empty() {
+		out = append(out, config.MinerConfig{
+			Address:            mcfg.MinerAddress,
+			BlockSignerAddress: mcfg.BlockSignerAddress,
+		})
+	}
+	return append(out, mcfg.Miners...)
+}
+
+// setupAdditionalMiners sets up the miners in mining.miners that are not set
+// up yet and reports whether there were any.
+func (node *Node) setupAdditionalMiners(ctx context.Context) (bool, error) {
+	setUp := make(map[address.Address]bool)
+	for _, am := range node.additionalMiners {
+		setUp[am.cfg.Address] = true
+	}
+
+	added := false
+	for _, mcfg := range node.Repo.Config().Mining.Miners {
+		if setUp[mcfg.Address] {
+			continue
+		}
+		am, err := node.setupAdditionalMiner(ctx, mcfg)
+		if err != nil {
+			return added, errors.Wrapf(err, "failed to set up miner %s", mcfg.Address)
+		}
+		node.additionalMiners = append(node.additionalMiners, am)
+		added = true
+	}
+	return added, nil
+}
+
+// setupAdditionalMiner creates the sector builder and storage miner of the
+// miner in mcfg.  Its sectors are kept in subdirectories named after it of
+// the repo's staging and sealed directories and its deals under its own
+// namespace of the deals datastore.
+func (node *Node) setupAdditionalMiner(ctx context.Context, mcfg config.MinerConfig) (*additionalMiner, error) {
+	owner, err := node.miningOwnerAddress(ctx, mcfg.Address)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get mining owner address")
+	}
+
+	stagingDir := filepath.Join(node.Repo.StagingDir(), mcfg.Address.String())
+	sealedDir := filepath.Join(node.Repo.SealedDir(), mcfg.Address.String())
+	for _, dir := range []string{stagingDir, sealedDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, errors.Wrapf(err, "failed to create sector directory %s", dir)
+		}
+	}
+	sb, err := initSectorBuilderForMiner(ctx, node, mcfg.Address, stagingDir, sealedDir, sectorStoreType())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize sector builder")
+	}
+
+	dealsDs := namespace.Wrap(node.Repo.DealsDatastore(), datastore.NewKey("/miners/"+mcfg.Address.String()))
+	sm, err := storage.NewMiner(ctx, mcfg.Address, owner, &minerNode{node, sb}, dealsDs, node.PorcelainAPI)
+	if err != nil {
+		sb.Close() // nolint: errcheck
+		return nil, errors.Wrap(err, "failed to instantiate storage miner")
+	}
+
+	return &additionalMiner{
+		cfg:           mcfg,
+		owner:         owner,
+		sectorBuilder: sb,
+		storageMiner:  sm,
+	}, nil
+}
@@ -0,0 +1,141 @@
+package node
+
+import (
+	"context"
+	"sync"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/mining"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// maxMinedBlocks is how many of the latest blocks it mined the node remembers
+// to find orphans among.
+const maxMinedBlocks = 1000
+
+// MinerMiningStats are the mining statistics of one miner on the node since
+// it started.
+type MinerMiningStats struct {
+	mining.WorkerStats
+	// BlocksMined is how many blocks the node mined for the miner.
+	BlocksMined uint64
+	// BlocksOrphaned is how many of the latest maxMinedBlocks blocks mined
+	// for the miner are below the head but not in the node's chain.
+	BlocksOrphaned uint64
+}
+
+type minedBlock struct {
+	cid    cid.Cid
+	miner  address.Address
+	height uint64
+}
+
+// minedBlockLog records the blocks the node mined.
+type minedBlockLog struct {
+	mu     sync.Mutex
+	counts map[address.Address]uint64
+	// blocks are the latest maxMinedBlocks blocks, oldest first.
+	blocks []minedBlock
+}
+
+func (l *minedBlockLog) add(blk *types.Block) {
+	if blk == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.counts == nil {
+		l.counts = make(map[address.Address]uint64)
+	}
+	l.counts[blk.Miner]++
+	l.blocks = append(l.blocks, minedBlock{cid: blk.Cid(), miner: blk.Miner, height: uint64(blk.Height)})
+	if len(l.blocks) > maxMinedBlocks {
+		l.blocks = l.blocks[len(l.blocks)-maxMinedBlocks:]
+	}
+}
+
+// forMiner returns how many blocks were mined for miner and the latest of
+// them.
+func (l *minedBlockLog) forMiner(miner address.Address) (uint64, []minedBlock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var blocks []minedBlock
+	for _, b := range l.blocks {
+		if b.miner == miner {
+			blocks = append(blocks, b)
+		}
+	}
+	return l.counts[miner], blocks
+}
+
+// MiningStats returns the mining statistics of the miner at minerAddr.  The
+// worker counters are zero until the node mined for the miner.
+func (node *Node) MiningStats(ctx context.Context, minerAddr address.Address) (*MinerMiningStats, error) {
+	stats := &MinerMiningStats{}
+	node.mining.Lock()
+	w, ok := node.miningWorkers[minerAddr]
+	node.mining.Unlock()
+	if ok {
+		stats.WorkerStats = w.Stats()
+	}
+
+	mined, blocks := node.minedBlocks.forMiner(minerAddr)
+	stats.BlocksMined = mined
+	orphaned, err := node.countOrphans(ctx, blocks)
+	if err != nil {
+		return nil, err
+	}
+	stats.BlocksOrphaned = orphaned
+	return stats, nil
+}
+
+// countOrphans returns how many of blocks are below the head but not in the
+// chain.  Blocks at the head's height can still join it.
+func (node *Node) countOrphans(ctx context.Context, blocks []minedBlock) (uint64, error) {
+	head := node.ChainReader.Head()
+	headHeight, err := head.Height()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get head height")
+	}
+
+	var candidates []minedBlock
+	lowest := headHeight
+	for _, b := range blocks {
+		if b.height < headHeight {
+			candidates = append(candidates, b)
+			if b.height < lowest {
+				lowest = b.height
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tipSets, err := chain.CollectTipSetsOfHeightAtLeast(ctx, node.ChainReader.BlockHistory(ctx, head), types.NewBlockHeight(lowest))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to walk the chain")
+	}
+	inChain := make(map[cid.Cid]bool)
+	for _, ts := range tipSets {
+		for c := range ts {
+			inChain[c] = true
+		}
+	}
+
+	var orphaned uint64
+	for _, b := range candidates {
+		if !inChain[b.cid] {
+			orphaned++
+		}
+	}
+	return orphaned, nil
+}
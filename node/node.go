@@ -50,10 +50,13 @@ import (
 	"github.com/filecoin-project/go-filecoin/mining"
 	"github.com/filecoin-project/go-filecoin/plumbing"
 	"github.com/filecoin-project/go-filecoin/plumbing/cfg"
+	"github.com/filecoin-project/go-filecoin/plumbing/evt"
 	"github.com/filecoin-project/go-filecoin/plumbing/msg"
 	"github.com/filecoin-project/go-filecoin/plumbing/mthdsig"
 	"github.com/filecoin-project/go-filecoin/plumbing/ntwk"
 	"github.com/filecoin-project/go-filecoin/plumbing/ps"
+	"github.com/filecoin-project/go-filecoin/plumbing/stdiff"
+	"github.com/filecoin-project/go-filecoin/plumbing/stverify"
 	"github.com/filecoin-project/go-filecoin/porcelain"
 	"github.com/filecoin-project/go-filecoin/proofs"
 	"github.com/filecoin-project/go-filecoin/proofs/sectorbuilder"
@@ -63,6 +66,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/repo"
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/upgrade"
 	vmErrors "github.com/filecoin-project/go-filecoin/vm/errors"
 	"github.com/filecoin-project/go-filecoin/wallet"
 )
@@ -78,6 +82,11 @@ var (
 
 type pubSubProcessorFunc func(ctx context.Context, msg *pubsub.Message) error
 
+// maxConcurrentBlockHandlers is the number of blocks received over pubsub
+// that are handled at once, so that a block whose chain is slow to fetch
+// doesn't hold up the blocks behind it.
+const maxConcurrentBlockHandlers = 8
+
 // Node represents a full Filecoin node.
 type Node struct {
 	host     host.Host
@@ -110,11 +119,19 @@ type Node struct {
 	miningDoneWg       *sync.WaitGroup
 	AddNewlyMinedBlock newBlockFunc
 	blockTime          time.Duration
+	// miningWorkers are the workers of the mining scheduler by miner,
+	// guarded by mining.
+	miningWorkers map[address.Address]*mining.DefaultWorker
+	minedBlocks   minedBlockLog
 
 	// Storage Market Interfaces
 	StorageMinerClient *storage.Client
 	StorageMiner       *storage.Miner
 
+	// additionalMiners are the miners in mining.miners, set up when mining
+	// starts.
+	additionalMiners []*additionalMiner
+
 	// Retrieval Interfaces
 	RetrievalClient *retrieval.Client
 	RetrievalMiner  *retrieval.Miner
@@ -360,6 +377,13 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 
 	cstOnline := hamt.CborIpldStore{Blocks: bservice}
 	cstOffline := hamt.CborIpldStore{Blocks: bserv.New(bs, offline.Exchange(bs))}
+
+	if upgrades := nc.Repo.Config().Chain.Upgrades; len(upgrades) > 0 {
+		if err := upgrade.SetSchedule(upgrades); err != nil {
+			return nil, errors.Wrap(err, "invalid upgrade schedule in config")
+		}
+	}
+
 	genCid, err := readGenesisCid(nc.Repo.Datastore())
 	if err != nil {
 		return nil, err
@@ -368,11 +392,12 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 	var chainStore chain.Store = chain.NewDefaultStore(nc.Repo.ChainDatastore(), &cstOffline, genCid)
 	powerTable := &consensus.MarketView{}
 
+	checkInvariants := consensus.WithInvariantChecks(nc.Repo.Config().Chain.CheckInvariants)
 	var processor consensus.Processor
 	if nc.Rewarder == nil {
-		processor = consensus.NewDefaultProcessor()
+		processor = consensus.NewDefaultProcessor(checkInvariants)
 	} else {
-		processor = consensus.NewConfiguredProcessor(consensus.NewDefaultMessageValidator(), nc.Rewarder)
+		processor = consensus.NewConfiguredProcessor(consensus.NewDefaultMessageValidator(), nc.Rewarder, checkInvariants)
 	}
 
 	var nodeConsensus consensus.Protocol
@@ -382,13 +407,36 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 		nodeConsensus = consensus.NewExpected(&cstOffline, bs, processor, powerTable, genCid, nc.Verifier)
 	}
 
+	// The finality policy is read from the config on every sync so that
+	// checkpoints set while the node is running take effect immediately.
+	finality := func() chain.FinalityPolicy {
+		chainCfg := nc.Repo.Config().Chain
+		return chain.FinalityPolicy{
+			MaxReorgDepth:    chainCfg.MaxReorgDepth,
+			Checkpoint:       chainCfg.Checkpoint,
+			CheckpointHeight: chainCfg.CheckpointHeight,
+		}
+	}
+
 	// only the syncer gets the storage which is online connected
-	chainSyncer := chain.NewDefaultSyncer(&cstOnline, &cstOffline, nodeConsensus, chainStore)
+	chainSyncer := chain.NewDefaultSyncer(&cstOnline, &cstOffline, nodeConsensus, chainStore, nc.Repo.ChainDatastore(), finality)
 	chainReader, ok := chainStore.(chain.ReadStore)
 	if !ok {
 		return nil, errors.New("failed to cast chain.Store to chain.ReadStore")
 	}
-	msgPool := core.NewMessagePool()
+	msgPool := core.NewMessagePool(nc.Repo.Config().Mpool, func(ctx context.Context, addr address.Address) (uint64, error) {
+		st, err := chainReader.LatestState(ctx)
+		if err != nil {
+			return 0, err
+		}
+		act, err := st.GetActor(ctx, addr)
+		if state.IsActorNotFoundError(err) {
+			return 0, nil
+		} else if err != nil {
+			return 0, err
+		}
+		return uint64(act.Nonce), nil
+	})
 
 	// Set up libp2p pubsub
 	fsub, err := pubsub.NewFloodSub(ctx, peerHost)
@@ -402,18 +450,26 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 	fcWallet := wallet.New(backend)
 
 	PorcelainAPI := porcelain.New(plumbing.New(&plumbing.APIDeps{
-		Chain:        chainReader,
-		Config:       cfg.NewConfig(nc.Repo),
-		MsgPool:      msgPool,
-		MsgPreviewer: msg.NewPreviewer(fcWallet, chainReader, &cstOffline, bs),
-		MsgQueryer:   msg.NewQueryer(nc.Repo, fcWallet, chainReader, &cstOffline, bs),
-		MsgSender:    msg.NewSender(nc.Repo, fcWallet, chainReader, msgPool, fsub.Publish),
-		MsgWaiter:    msg.NewWaiter(chainReader, bs, &cstOffline),
-		Subscriber:   ps.NewSubscriber(fsub),
-		Publisher:    ps.NewPublisher(fsub),
-		Network:      ntwk.NewNetwork(peerHost),
-		SigGetter:    mthdsig.NewGetter(chainReader),
-		Wallet:       fcWallet,
+		Chain:         chainReader,
+		Syncer:        chainSyncer,
+		Config:        cfg.NewConfig(nc.Repo),
+		EventWatcher:  evt.NewWatcher(chainReader, bs),
+		MsgEstimator:  msg.NewEstimator(chainReader, msgPool, bs),
+		MsgPool:       msgPool,
+		MsgPreviewer:  msg.NewPreviewer(fcWallet, chainReader, &cstOffline, bs),
+		MsgProver:     msg.NewProver(chainReader),
+		MsgQueryer:    msg.NewQueryer(nc.Repo, fcWallet, chainReader, &cstOffline, bs),
+		MsgSender:     msg.NewSender(nc.Repo, fcWallet, chainReader, msgPool, fsub.Publish),
+		MsgSimulator:  msg.NewSimulator(chainReader, &cstOffline, bs),
+		MsgTracer:     msg.NewTracer(chainReader, bs, &cstOffline),
+		MsgWaiter:     msg.NewWaiter(chainReader, bs, &cstOffline),
+		Subscriber:    ps.NewSubscriber(fsub),
+		Publisher:     ps.NewPublisher(fsub),
+		Network:       ntwk.NewNetwork(peerHost),
+		SigGetter:     mthdsig.NewGetter(chainReader),
+		StateDiffer:   stdiff.NewDiffer(chainReader, &cstOffline),
+		StateVerifier: stverify.NewVerifier(chainReader, &cstOffline, bs),
+		Wallet:        fcWallet,
 	}))
 
 	nd := &Node{
@@ -480,9 +536,9 @@ func (node *Node) Start(ctx context.Context) error {
 	// Start up 'hello' handshake service
 	syncCallBack := func(pid libp2ppeer.ID, cids []cid.Cid, height uint64) {
 		// TODO it is possible the syncer interface should be modified to
-		// make use of the additional context not used here (from addr + height).
+		// make use of the additional context not used here (height).
 		// To keep things simple for now this info is not used.
-		err := node.Syncer.HandleNewBlocks(context.Background(), cids)
+		err := node.Syncer.HandleNewBlocksFromPeer(context.Background(), pid, cids)
 		if err != nil {
 			log.Infof("error handling blocks: %s", types.NewSortedCidSet(cids...).String())
 		}
@@ -513,15 +569,25 @@ func (node *Node) Start(ctx context.Context) error {
 	}
 	node.MessageSub = msgSub
 
+	// Put messages we sent before a restart back in the pool.
+	restored, err := node.PorcelainAPI.MessagePoolRestoreLocal(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to restore sent messages")
+	}
+	if restored > 0 {
+		log.Infof("restored %d sent messages to the message pool", restored)
+	}
+
 	cctx, cancel := context.WithCancel(context.Background())
 	node.cancelSubscriptionsCtx = cancel
 
-	go node.handleSubscription(cctx, node.processBlock, "processBlock", node.BlockSub, "BlockSub")
-	go node.handleSubscription(cctx, node.processMessage, "processMessage", node.MessageSub, "MessageSub")
+	go node.handleSubscription(cctx, node.processBlock, "processBlock", node.BlockSub, "BlockSub", maxConcurrentBlockHandlers)
+	go node.handleSubscription(cctx, node.processMessage, "processMessage", node.MessageSub, "MessageSub", 1)
 
 	node.HeaviestTipSetHandled = func() {}
 	node.HeaviestTipSetCh = node.ChainReader.HeadEvents().Sub(chain.NewHeadTopic)
 	go node.handleNewHeaviestTipSet(cctx, node.ChainReader.Head())
+	go node.rebroadcastMessages(cctx)
 
 	if !node.OfflineMode {
 		node.Bootstrapper.Start(context.Background())
@@ -556,15 +622,18 @@ func (node *Node) Start(ctx context.Context) error {
 	return nil
 }
 
-func (node *Node) setupMining(ctx context.Context) error {
-	// configure the underlying sector store, defaulting to the non-test version
-	sectorStoreType := proofs.Live
+// sectorStoreType returns the type of sector store to configure sector
+// builders with, defaulting to the non-test version.
+func sectorStoreType() proofs.SectorStoreType {
 	if os.Getenv("FIL_USE_SMALL_SECTORS") == "true" {
-		sectorStoreType = proofs.Test
+		return proofs.Test
 	}
+	return proofs.Live
+}
 
+func (node *Node) setupMining(ctx context.Context) error {
 	// initialize a sector builder
-	sectorBuilder, err := initSectorBuilderForNode(ctx, node, sectorStoreType)
+	sectorBuilder, err := initSectorBuilderForNode(ctx, node, sectorStoreType())
 	if err != nil {
 		return errors.Wrap(err, "failed to initialize sector builder")
 	}
@@ -579,7 +648,8 @@ func (node *Node) setIsMining(isMining bool) {
 	node.mining.isMining = isMining
 }
 
-func (node *Node) isMining() bool {
+// IsMining returns true if the node is mining.
+func (node *Node) IsMining() bool {
 	node.mining.Lock()
 	defer node.mining.Unlock()
 	return node.mining.isMining
@@ -600,9 +670,10 @@ func (node *Node) handleNewMiningOutput(miningOutCh <-chan mining.Output) {
 			if output.Err != nil {
 				log.Errorf("problem mining a block: %s", output.Err.Error())
 			} else {
+				node.minedBlocks.add(output.NewBlock)
 				node.miningDoneWg.Add(1)
 				go func() {
-					if node.isMining() {
+					if node.IsMining() {
 						node.AddNewlyMinedBlock(node.miningCtx, output.NewBlock)
 					}
 					node.miningDoneWg.Done()
@@ -636,11 +707,18 @@ func (node *Node) handleNewHeaviestTipSet(ctx context.Context, head types.TipSet
 				log.Error("error updating message pool for new tipset:", err)
 				continue
 			}
+			if n := node.MsgPool.RemoveExpired(time.Now()); n > 0 {
+				log.Infof("removed %d expired messages from the message pool", n)
+			}
+			node.PorcelainAPI.MessagePoolPruneLocal(ctx)
 			head = newHead
 
 			if node.StorageMiner != nil {
 				node.StorageMiner.OnNewHeaviestTipSet(newHead)
 			}
+			for _, am := range node.additionalMiners {
+				am.storageMiner.OnNewHeaviestTipSet(newHead)
+			}
 			node.HeaviestTipSetHandled()
 		case <-ctx.Done():
 			return
@@ -648,6 +726,24 @@ func (node *Node) handleNewHeaviestTipSet(ctx context.Context, head types.TipSet
 	}
 }
 
+// rebroadcastMessages runs a round of rebroadcasting the messages this node
+// sent once every block time, until ctx is done.  It does not wait for new
+// heads so that messages are rebroadcast while the chain is stalled too.
+func (node *Node) rebroadcastMessages(ctx context.Context) {
+	for {
+		period := node.GetBlockTime()
+		if period <= 0 {
+			period = mining.DefaultBlockTime
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(period):
+			node.PorcelainAPI.MessageRebroadcast(ctx)
+		}
+	}
+}
+
 func (node *Node) cancelSubscriptions() {
 	if node.BlockSub != nil || node.MessageSub != nil {
 		node.cancelSubscriptionsCtx()
@@ -678,6 +774,12 @@ func (node *Node) Stop(ctx context.Context) {
 		}
 		node.sectorBuilder = nil
 	}
+	for _, am := range node.additionalMiners {
+		if err := am.sectorBuilder.Close(); err != nil {
+			fmt.Printf("error closing sector builder of miner %s: %s\n", am.cfg.Address, err)
+		}
+	}
+	node.additionalMiners = nil
 
 	if err := node.Host().Close(); err != nil {
 		fmt.Printf("error closing host: %s\n", err)
@@ -732,6 +834,25 @@ func (node *Node) SetBlockTime(blockTime time.Duration) {
 	node.blockTime = blockTime
 }
 
+// miningScheduler returns the scheduler that runs worker.  Epochs are counted
+// from the genesis block's timestamp, so that every node agrees on them.  If
+// the genesis block has none, there are no epochs to agree on and mining is
+// scheduled on the node's block time instead.
+func (node *Node) miningScheduler(ctx context.Context, worker mining.Worker, mineDelay time.Duration) (mining.Scheduler, error) {
+	genesis, err := node.ChainReader.GetBlock(ctx, node.ChainReader.GenesisCid())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get genesis block")
+	}
+	if genesis.Timestamp == 0 {
+		log.Warning("genesis block has no timestamp, mining without epochs")
+		return mining.NewScheduler(worker, mineDelay, node.ChainReader.Head), nil
+	}
+
+	genesisTime := time.Unix(int64(genesis.Timestamp), 0)
+	epochClock := mining.NewEpochClock(mining.NewSystemClock(), genesisTime, node.GetBlockTime())
+	return mining.NewEpochScheduler(worker, epochClock, mineDelay, node.ChainReader.Head), nil
+}
+
 // StartMining starts the node mining and logs an error if it cannot start.
 // We wrap starting in this free function to ensure an error is logged.
 func StartMining(ctx context.Context, node *Node) error {
@@ -741,7 +862,7 @@ func StartMining(ctx context.Context, node *Node) error {
 // StartMining causes the node to start feeding blocks to the mining worker and initializes
 // the SectorBuilder for the mining address.
 func (node *Node) StartMining(ctx context.Context) error {
-	if node.isMining() {
+	if node.IsMining() {
 		return errors.New("Node is already mining")
 	}
 	minerAddr, err := node.miningAddress()
@@ -762,6 +883,15 @@ func (node *Node) StartMining(ctx context.Context) error {
 		return errors.Wrapf(err, "failed to get mining owner address for miner %s", minerAddr)
 	}
 
+	added, err := node.setupAdditionalMiners(ctx)
+	if err != nil {
+		return err
+	}
+	if added {
+		// Rebuild the workers so they mine for the new miners too.
+		node.MiningScheduler = nil
+	}
+
 	blockTime, mineDelay := node.MiningTimes()
 
 	if node.MiningScheduler == nil {
@@ -794,9 +924,27 @@ func (node *Node) StartMining(ctx context.Context) error {
 			return chain.GetRecentAncestors(ctx, ts, node.ChainReader, newBlockHeight, consensus.AncestorRoundsNeeded, consensus.LookBackParameter)
 		}
 		processor := consensus.NewDefaultProcessor()
-		worker := mining.NewDefaultWorker(node.MsgPool, getState, getWeight, getAncestors, processor, node.PowerTable,
-			node.Blockstore, node.CborStore(), minerAddr, minerSigningAddress, node.Wallet, blockTime)
-		node.MiningScheduler = mining.NewScheduler(worker, mineDelay, node.ChainReader.Head)
+		// Each miner draws its own ticket and runs its own election.
+		miningWorkers := map[address.Address]*mining.DefaultWorker{
+			minerAddr: mining.NewDefaultWorker(node.MsgPool, getState, getWeight, getAncestors, processor, node.PowerTable,
+				node.Blockstore, node.CborStore(), minerAddr, minerSigningAddress, node.Wallet, blockTime),
+		}
+		for _, am := range node.additionalMiners {
+			miningWorkers[am.cfg.Address] = mining.NewDefaultWorker(node.MsgPool, getState, getWeight, getAncestors, processor, node.PowerTable,
+				node.Blockstore, node.CborStore(), am.cfg.Address, am.cfg.BlockSignerAddress, node.Wallet, blockTime)
+		}
+		var workers []mining.Worker
+		for _, w := range miningWorkers {
+			workers = append(workers, w)
+		}
+		node.mining.Lock()
+		node.miningWorkers = miningWorkers
+		node.mining.Unlock()
+		worker := mining.NewMultiWorker(workers...)
+		node.MiningScheduler, err = node.miningScheduler(ctx, worker, mineDelay)
+		if err != nil {
+			return errors.Wrap(err, "failed to set up the mining scheduler")
+		}
 	}
 
 	// paranoid check
@@ -817,66 +965,25 @@ func (node *Node) StartMining(ctx context.Context) error {
 	}
 	node.StorageMiner = storageMiner
 
-	// loop, turning sealing-results into commitSector messages to be included
-	// in the chain
-	go func() {
-		for {
-			select {
-			case result := <-node.SectorBuilder().SectorSealResults():
-				if result.SealingErr != nil {
-					log.Errorf("failed to seal sector with id %d: %s", result.SectorID, result.SealingErr.Error())
-				} else if result.SealingResult != nil {
-
-					// TODO: determine these algorithmically by simulating call and querying historical prices
-					gasPrice := types.NewGasPrice(0)
-					gasUnits := types.NewGasUnits(300)
-
-					val := result.SealingResult
-					// This call can fail due to, e.g. nonce collisions. Our miners existence depends on this.
-					// We should deal with this, but MessageSendWithRetry is problematic.
-					_, err := node.PorcelainAPI.MessageSend(
-						node.miningCtx,
-						minerOwnerAddr,
-						minerAddr,
-						nil,
-						gasPrice,
-						gasUnits,
-						"commitSector",
-						val.SectorID,
-						val.CommD[:],
-						val.CommR[:],
-						val.CommRStar[:],
-						val.Proof[:],
-					)
-					if err != nil {
-						log.Errorf("failed to send commitSector message from %s to %s for sector with id %d: %s", minerOwnerAddr, minerAddr, val.SectorID, err)
-						continue
-					}
+	storageMiners := []*storage.Miner{storageMiner}
+	for _, am := range node.additionalMiners {
+		storageMiners = append(storageMiners, am.storageMiner)
+	}
+	if len(storageMiners) > 1 {
+		storage.NewMinerSet(node.Host(), storageMiners...)
+	}
 
-					node.StorageMiner.OnCommitmentAddedToChain(val, nil)
-				}
-			case <-node.miningCtx.Done():
-				return
-			}
-		}
-	}()
+	go node.handleSealResults(minerAddr, minerOwnerAddr, node.SectorBuilder(), storageMiner)
+	for _, am := range node.additionalMiners {
+		go node.handleSealResults(am.cfg.Address, am.owner, am.sectorBuilder, am.storageMiner)
+	}
 
 	// schedules sealing of staged piece-data
 	if node.Repo.Config().Mining.AutoSealIntervalSeconds > 0 {
-		go func() {
-			for {
-				select {
-				case <-node.miningCtx.Done():
-					return
-				case <-time.After(time.Duration(node.Repo.Config().Mining.AutoSealIntervalSeconds) * time.Second):
-					log.Info("auto-seal has been triggered")
-					if err := node.SectorBuilder().SealAllStagedSectors(node.miningCtx); err != nil {
-						log.Errorf("scheduler received error from node.SectorBuilder.SealAllStagedSectors (%s) - exiting", err.Error())
-						return
-					}
-				}
-			}
-		}()
+		go node.autoSeal(node.SectorBuilder())
+		for _, am := range node.additionalMiners {
+			go node.autoSeal(am.sectorBuilder)
+		}
 	} else {
 		log.Debug("auto-seal is disabled")
 	}
@@ -885,6 +992,68 @@ func (node *Node) StartMining(ctx context.Context) error {
 	return nil
 }
 
+// handleSealResults turns the sealing results of sb into commitSector
+// messages from owner to minerAddr to be included in the chain, and tells sm
+// about them, until mining stops.
+func (node *Node) handleSealResults(minerAddr, owner address.Address, sb sectorbuilder.SectorBuilder, sm *storage.Miner) {
+	for {
+		select {
+		case result := <-sb.SectorSealResults():
+			if result.SealingErr != nil {
+				log.Errorf("failed to seal sector with id %d: %s", result.SectorID, result.SealingErr.Error())
+			} else if result.SealingResult != nil {
+
+				// TODO: determine these algorithmically by simulating call and querying historical prices
+				gasPrice := types.NewGasPrice(0)
+				gasUnits := types.NewGasUnits(300)
+
+				val := result.SealingResult
+				// This call can fail due to, e.g. nonce collisions. Our miners existence depends on this.
+				// We should deal with this, but MessageSendWithRetry is problematic.
+				_, err := node.PorcelainAPI.MessageSend(
+					node.miningCtx,
+					owner,
+					minerAddr,
+					nil,
+					gasPrice,
+					gasUnits,
+					"commitSector",
+					val.SectorID,
+					val.CommD[:],
+					val.CommR[:],
+					val.CommRStar[:],
+					val.Proof[:],
+				)
+				if err != nil {
+					log.Errorf("failed to send commitSector message from %s to %s for sector with id %d: %s", owner, minerAddr, val.SectorID, err)
+					continue
+				}
+
+				sm.OnCommitmentAddedToChain(val, nil)
+			}
+		case <-node.miningCtx.Done():
+			return
+		}
+	}
+}
+
+// autoSeal seals the staged sectors of sb every mining.autoSealIntervalSeconds
+// until mining stops.
+func (node *Node) autoSeal(sb sectorbuilder.SectorBuilder) {
+	for {
+		select {
+		case <-node.miningCtx.Done():
+			return
+		case <-time.After(time.Duration(node.Repo.Config().Mining.AutoSealIntervalSeconds) * time.Second):
+			log.Info("auto-seal has been triggered")
+			if err := sb.SealAllStagedSectors(node.miningCtx); err != nil {
+				log.Errorf("scheduler received error from node.SectorBuilder.SealAllStagedSectors (%s) - exiting", err.Error())
+				return
+			}
+		}
+	}
+}
+
 func (node *Node) getLastUsedSectorID(ctx context.Context, minerAddr address.Address) (uint64, error) {
 	rets, methodSignature, err := node.PorcelainAPI.MessageQuery(
 		ctx,
@@ -914,6 +1083,13 @@ func initSectorBuilderForNode(ctx context.Context, node *Node, sectorStoreType p
 		return nil, errors.Wrap(err, "failed to get node's mining address")
 	}
 
+	return initSectorBuilderForMiner(ctx, node, minerAddr, node.Repo.StagingDir(), node.Repo.SealedDir(), sectorStoreType)
+}
+
+// initSectorBuilderForMiner returns a sector builder for the miner at
+// minerAddr keeping its staged and sealed sectors in stagingDir and
+// sealedDir.
+func initSectorBuilderForMiner(ctx context.Context, node *Node, minerAddr address.Address, stagingDir, sealedDir string, sectorStoreType proofs.SectorStoreType) (sectorbuilder.SectorBuilder, error) {
 	lastUsedSectorID, err := node.getLastUsedSectorID(ctx, minerAddr)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get last used sector id for miner w/address %s", minerAddr.String())
@@ -926,11 +1102,11 @@ func initSectorBuilderForNode(ctx context.Context, node *Node, sectorStoreType p
 	cfg := sectorbuilder.RustSectorBuilderConfig{
 		BlockService:     node.blockservice,
 		LastUsedSectorID: lastUsedSectorID,
-		MetadataDir:      node.Repo.StagingDir(),
+		MetadataDir:      stagingDir,
 		MinerAddr:        minerAddr,
-		SealedSectorDir:  node.Repo.SealedDir(),
+		SealedSectorDir:  sealedDir,
 		SectorStoreType:  sectorStoreType,
-		StagedSectorDir:  node.Repo.StagingDir(),
+		StagedSectorDir:  stagingDir,
 	}
 
 	sb, err := sectorbuilder.NewRustSectorBuilder(cfg)
@@ -981,17 +1157,13 @@ func (node *Node) NewAddress() (address.Address, error) {
 }
 
 // CreateMiner creates a new miner actor for the given account and returns its address.
-// It will wait for the the actor to appear on-chain and add set the address to mining.minerAddress in the config.
+// It will wait for the the actor to appear on-chain and set the address to mining.minerAddress in the config,
+// or add it to mining.miners if the node already has a miner.
 // TODO: This should live in a MinerAPI or some such. It's here until we have a proper API layer.
 // TODO: add ability to pass in a KeyInfo to store for signing blocks.
 //       See https://github.com/filecoin-project/go-filecoin/issues/1843
 func (node *Node) CreateMiner(ctx context.Context, accountAddr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, pledge uint64, pid libp2ppeer.ID, collateral *types.AttoFIL) (_ *address.Address, err error) {
 
-	// Only create a miner if we don't already have one.
-	if _, err := node.miningAddress(); err != ErrNoMinerAddress {
-		return nil, fmt.Errorf("can only have one miner per node")
-	}
-
 	ctx = log.Start(ctx, "Node.CreateMiner")
 	defer func() {
 		log.FinishWithErr(ctx, err)
@@ -1036,22 +1208,36 @@ func (node *Node) CreateMiner(ctx context.Context, accountAddr address.Address,
 		return &minerAddr, err
 	}
 
+	hadMiner := !node.Repo.Config().Mining.MinerAddress.Empty()
 	err = node.saveMinerConfig(minerAddr, blockSignerAddr)
 	if err != nil {
 		return &minerAddr, err
 	}
 
+	// Further miners are set up when mining next starts, so a node that is
+	// mining must stop and start mining to mine for them.
+	if hadMiner {
+		return &minerAddr, nil
+	}
 	err = node.setupMining(ctx)
 
 	return &minerAddr, err
 }
 
 // saveMinerConfig updates the Node Mining config with the MinerAddress and the BlockSignerAddress.
+// If the node already has a miner the new one is added to mining.miners.
 func (node *Node) saveMinerConfig(minerAddr address.Address, signerAddr address.Address) error {
 	r := node.Repo
 	newConfig := r.Config()
-	newConfig.Mining.MinerAddress = minerAddr
-	newConfig.Mining.BlockSignerAddress = signerAddr
+	if newConfig.Mining.MinerAddress.Empty() {
+		newConfig.Mining.MinerAddress = minerAddr
+		newConfig.Mining.BlockSignerAddress = signerAddr
+	} else {
+		newConfig.Mining.Miners = append(newConfig.Mining.Miners, config.MinerConfig{
+			Address:            minerAddr,
+			BlockSignerAddress: signerAddr,
+		})
+	}
 	return r.ReplaceConfig(newConfig)
 }
 
@@ -1101,7 +1287,11 @@ func (node *Node) getMinerActorPubKey() ([]byte, error) {
 	return node.Wallet.GetPubKeyForAddress(addr)
 }
 
-func (node *Node) handleSubscription(ctx context.Context, f pubSubProcessorFunc, fname string, s ps.Subscription, sname string) {
+// handleSubscription calls f on each message of subscription s, on at most
+// workers messages at once.  With a single worker messages are handled in
+// the order they are received.
+func (node *Node) handleSubscription(ctx context.Context, f pubSubProcessorFunc, fname string, s ps.Subscription, sname string, workers int) {
+	sem := make(chan struct{}, workers)
 	for {
 		pubSubMsg, err := s.Next(ctx)
 		if err != nil {
@@ -1109,9 +1299,17 @@ func (node *Node) handleSubscription(ctx context.Context, f pubSubProcessorFunc,
 			return
 		}
 
-		if err := f(ctx, pubSubMsg); err != nil {
-			log.Errorf("%s(): %s", fname, err)
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return
 		}
+		go func() {
+			defer func() { <-sem }()
+			if err := f(ctx, pubSubMsg); err != nil {
+				log.Errorf("%s(): %s", fname, err)
+			}
+		}()
 	}
 }
 
@@ -451,3 +451,28 @@ func TestNode_getMinerOwnerPubKey(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, pkey)
 }
+
+func TestMinedBlockLog(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	addrGetter := address.NewForTestGetter()
+	minerA, minerB := addrGetter(), addrGetter()
+
+	var l minedBlockLog
+	l.add(nil)
+	for i := 0; i < maxMinedBlocks+1; i++ {
+		l.add(&types.Block{Miner: minerA, Height: types.Uint64(i)})
+	}
+	l.add(&types.Block{Miner: minerB, Height: 7})
+
+	mined, blocks := l.forMiner(minerA)
+	assert.Equal(uint64(maxMinedBlocks+1), mined)
+	assert.Len(blocks, maxMinedBlocks-1)
+	assert.Equal(uint64(2), blocks[0].height)
+
+	mined, blocks = l.forMiner(minerB)
+	assert.Equal(uint64(1), mined)
+	assert.Len(blocks, 1)
+	assert.Equal(uint64(7), blocks[0].height)
+}
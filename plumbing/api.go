@@ -10,14 +10,19 @@ import (
 
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/core"
 	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/plumbing/cfg"
+	"github.com/filecoin-project/go-filecoin/plumbing/evt"
 	"github.com/filecoin-project/go-filecoin/plumbing/msg"
 	"github.com/filecoin-project/go-filecoin/plumbing/mthdsig"
 	"github.com/filecoin-project/go-filecoin/plumbing/ntwk"
 	"github.com/filecoin-project/go-filecoin/plumbing/ps"
+	"github.com/filecoin-project/go-filecoin/plumbing/stdiff"
+	"github.com/filecoin-project/go-filecoin/plumbing/stverify"
 	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
 	"github.com/filecoin-project/go-filecoin/wallet"
 )
 
@@ -29,34 +34,50 @@ import (
 type API struct {
 	logger logging.EventLogger
 
-	chain        chain.ReadStore
-	config       *cfg.Config
-	msgPool      *core.MessagePool
-	msgPreviewer *msg.Previewer
-	msgQueryer   *msg.Queryer
-	msgSender    *msg.Sender
-	msgWaiter    *msg.Waiter
-	subscriber   *ps.Subscriber
-	publisher    *ps.Publisher
-	network      *ntwk.Network
-	sigGetter    *mthdsig.Getter
-	wallet       *wallet.Wallet
+	chain         chain.ReadStore
+	syncer        chain.Syncer
+	config        *cfg.Config
+	eventWatcher  *evt.Watcher
+	msgEstimator  *msg.Estimator
+	msgPool       *core.MessagePool
+	msgPreviewer  *msg.Previewer
+	msgProver     *msg.Prover
+	msgQueryer    *msg.Queryer
+	msgSender     *msg.Sender
+	msgSimulator  *msg.Simulator
+	msgTracer     *msg.Tracer
+	msgWaiter     *msg.Waiter
+	subscriber    *ps.Subscriber
+	publisher     *ps.Publisher
+	network       *ntwk.Network
+	sigGetter     *mthdsig.Getter
+	stateDiffer   *stdiff.Differ
+	stateVerifier *stverify.Verifier
+	wallet        *wallet.Wallet
 }
 
 // APIDeps contains all the API's dependencies
 type APIDeps struct {
-	Chain        chain.ReadStore
-	Config       *cfg.Config
-	MsgPool      *core.MessagePool
-	MsgPreviewer *msg.Previewer
-	MsgQueryer   *msg.Queryer
-	MsgSender    *msg.Sender
-	MsgWaiter    *msg.Waiter
-	Subscriber   *ps.Subscriber
-	Publisher    *ps.Publisher
-	Network      *ntwk.Network
-	SigGetter    *mthdsig.Getter
-	Wallet       *wallet.Wallet
+	Chain         chain.ReadStore
+	Syncer        chain.Syncer
+	Config        *cfg.Config
+	EventWatcher  *evt.Watcher
+	MsgEstimator  *msg.Estimator
+	MsgPool       *core.MessagePool
+	MsgPreviewer  *msg.Previewer
+	MsgProver     *msg.Prover
+	MsgQueryer    *msg.Queryer
+	MsgSender     *msg.Sender
+	MsgSimulator  *msg.Simulator
+	MsgTracer     *msg.Tracer
+	MsgWaiter     *msg.Waiter
+	Subscriber    *ps.Subscriber
+	Publisher     *ps.Publisher
+	Network       *ntwk.Network
+	SigGetter     *mthdsig.Getter
+	StateDiffer   *stdiff.Differ
+	StateVerifier *stverify.Verifier
+	Wallet        *wallet.Wallet
 }
 
 // New constructs a new instance of the API.
@@ -64,18 +85,26 @@ func New(deps *APIDeps) *API {
 	return &API{
 		logger: logging.Logger("porcelain"),
 
-		chain:        deps.Chain,
-		config:       deps.Config,
-		msgPool:      deps.MsgPool,
-		msgPreviewer: deps.MsgPreviewer,
-		msgQueryer:   deps.MsgQueryer,
-		msgSender:    deps.MsgSender,
-		msgWaiter:    deps.MsgWaiter,
-		subscriber:   deps.Subscriber,
-		publisher:    deps.Publisher,
-		network:      deps.Network,
-		sigGetter:    deps.SigGetter,
-		wallet:       deps.Wallet,
+		chain:         deps.Chain,
+		syncer:        deps.Syncer,
+		config:        deps.Config,
+		eventWatcher:  deps.EventWatcher,
+		msgEstimator:  deps.MsgEstimator,
+		msgPool:       deps.MsgPool,
+		msgPreviewer:  deps.MsgPreviewer,
+		msgProver:     deps.MsgProver,
+		msgQueryer:    deps.MsgQueryer,
+		msgSender:     deps.MsgSender,
+		msgSimulator:  deps.MsgSimulator,
+		msgTracer:     deps.MsgTracer,
+		msgWaiter:     deps.MsgWaiter,
+		subscriber:    deps.Subscriber,
+		publisher:     deps.Publisher,
+		network:       deps.Network,
+		sigGetter:     deps.SigGetter,
+		stateDiffer:   deps.StateDiffer,
+		stateVerifier: deps.StateVerifier,
+		wallet:        deps.Wallet,
 	}
 }
 
@@ -86,6 +115,18 @@ func (api *API) ActorGetSignature(ctx context.Context, actorAddr address.Address
 	return api.sigGetter.Get(ctx, actorAddr, method)
 }
 
+// ActorDescribe returns a machine readable description of the methods of the
+// given actor, with the JSON schemas of their parameters and return values.
+func (api *API) ActorDescribe(ctx context.Context, actorAddr address.Address) (*exec.ActorDescription, error) {
+	return api.sigGetter.Describe(ctx, actorAddr)
+}
+
+// ActorDescribeCode is like ActorDescribe but describes the actors with the
+// given code.
+func (api *API) ActorDescribeCode(ctx context.Context, code cid.Cid) (*exec.ActorDescription, error) {
+	return api.sigGetter.DescribeCode(ctx, code)
+}
+
 // ConfigSet sets the given parameters at the given path in the local config.
 // The given path may be either a single field name, or a dotted path to a field.
 // The JSON value may be either a single value or a whole data structure to be replace.
@@ -112,11 +153,63 @@ func (api *API) ChainLs(ctx context.Context) <-chan interface{} {
 	return api.chain.BlockHistory(ctx, api.chain.Head())
 }
 
+// ChainEvents streams the events emitted by the messages of new heads as
+// *evt.ChainEvent, only those with the given topic unless it is empty. See
+// evt.Watcher.Watch.
+func (api *API) ChainEvents(ctx context.Context, topic string) <-chan interface{} {
+	return api.eventWatcher.Watch(ctx, topic)
+}
+
+// ChainSyncStatus returns a snapshot of the chain syncer's progress
+func (api *API) ChainSyncStatus() chain.SyncStatus {
+	return api.syncer.Status()
+}
+
+// ChainBadTipSets lists the tipsets the syncer has recorded as invalid
+func (api *API) ChainBadTipSets() []chain.BadTipSet {
+	return api.syncer.BadTipSets()
+}
+
+// ChainRemoveBadTipSet removes the tipset with the given block cids from the
+// syncer's bad tipset cache, so that it is validated again if received
+func (api *API) ChainRemoveBadTipSet(ids types.SortedCidSet) error {
+	return api.syncer.RemoveBadTipSet(ids.String())
+}
+
+// ChainBadPeers returns the number of invalid chains each peer has sent
+func (api *API) ChainBadPeers() map[peer.ID]int {
+	return api.syncer.PeerScores()
+}
+
+// ChainStateDiff returns the actors whose state differs between the tipsets
+// with keys a and b
+func (api *API) ChainStateDiff(ctx context.Context, a, b types.SortedCidSet) ([]*stdiff.ActorDiff, error) {
+	return api.stateDiffer.Diff(ctx, a, b)
+}
+
+// ChainVerifyState returns the invariants the state after the tipset
+// referenced by at violates, see consensus.CheckInvariants.
+func (api *API) ChainVerifyState(ctx context.Context, at chain.TipSetRef) ([]*consensus.Violation, error) {
+	return api.stateVerifier.Verify(ctx, at)
+}
+
 // BlockGet gets a block by CID
 func (api *API) BlockGet(ctx context.Context, id cid.Cid) (*types.Block, error) {
 	return api.chain.GetBlock(ctx, id)
 }
 
+// MessageEstimateGasLimit estimates the gas limit for a message from the
+// given address, taking the sender's pending messages into account.
+func (api *API) MessageEstimateGasLimit(ctx context.Context, from, to address.Address, value *types.AttoFIL, method string, params ...interface{}) (types.GasUnits, error) {
+	return api.msgEstimator.EstimateGasLimit(ctx, from, to, value, method, params...)
+}
+
+// MessageGasPriceStats returns statistics about the gas prices of the messages
+// included in recent tipsets.
+func (api *API) MessageGasPriceStats(ctx context.Context) (*msg.GasPriceStats, error) {
+	return api.msgEstimator.GasPriceStats(ctx)
+}
+
 // MessagePoolPending lists messages un-mined in the pool
 func (api *API) MessagePoolPending() []*types.SignedMessage {
 	return api.msgPool.Pending()
@@ -127,6 +220,19 @@ func (api *API) MessagePoolRemove(cid cid.Cid) {
 	api.msgPool.Remove(cid)
 }
 
+// MessagePoolPruneLocal forgets the messages this node sent that have left the
+// message pool, so they are neither rebroadcast nor restored after a restart.
+func (api *API) MessagePoolPruneLocal(ctx context.Context) {
+	api.msgSender.Prune(ctx)
+}
+
+// MessagePoolRestoreLocal puts the messages this node sent that are not yet
+// on chain back in the message pool and republishes them. It returns the
+// number of messages restored.
+func (api *API) MessagePoolRestoreLocal(ctx context.Context) (int, error) {
+	return api.msgSender.Restore(ctx)
+}
+
 // MessagePreview previews the Gas cost of a message by running it locally on the client and
 // recording the amount of Gas used.
 func (api *API) MessagePreview(ctx context.Context, from, to address.Address, method string, params ...interface{}) (types.GasUnits, error) {
@@ -140,6 +246,12 @@ func (api *API) MessageQuery(ctx context.Context, optFrom, to address.Address, m
 	return api.msgQueryer.Query(ctx, optFrom, to, method, params...)
 }
 
+// MessageQueryAt calls an actor's method using the state after the tipset
+// referenced by at. See MessageQuery.
+func (api *API) MessageQueryAt(ctx context.Context, at chain.TipSetRef, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, *exec.FunctionSignature, error) {
+	return api.msgQueryer.QueryAt(ctx, at, optFrom, to, method, params...)
+}
+
 // MessageSend sends a message. It uses the default from address if none is given and signs the
 // message using the wallet. This call "sends" in the sense that it enqueues the
 // message in the msg pool and broadcasts it to the network; it does not wait for the
@@ -149,6 +261,46 @@ func (api *API) MessageSend(ctx context.Context, from, to address.Address, value
 	return api.msgSender.Send(ctx, from, to, value, gasPrice, gasLimit, method, params...)
 }
 
+// MessageRebroadcast publishes again the messages this node sent that are
+// still in the message pool and due for rebroadcast. Each call is a round of
+// the rebroadcast backoff.
+func (api *API) MessageRebroadcast(ctx context.Context) {
+	api.msgSender.Rebroadcast(ctx)
+}
+
+// MessageReplace replaces the pending message with the given cid by a copy
+// signed with a higher gas price, and returns the cid of the replacement.
+func (api *API) MessageReplace(ctx context.Context, msgCid cid.Cid, gasPrice types.AttoFIL) (cid.Cid, error) {
+	return api.msgSender.Replace(ctx, msgCid, gasPrice)
+}
+
+// MessageSentStatus returns the status of the messages this node sent that
+// are still in the message pool, oldest first.
+func (api *API) MessageSentStatus(ctx context.Context) []*msg.SentMessage {
+	return api.msgSender.Status(ctx)
+}
+
+// MessageProof finds the message with the given cid on chain and returns the
+// cid of the block including it and the proof of its inclusion and receipt,
+// which can be checked knowing only the block cid.
+func (api *API) MessageProof(ctx context.Context, msgCid cid.Cid) (cid.Cid, *types.MessageProof, error) {
+	return api.msgProver.Prove(ctx, msgCid)
+}
+
+// MessageSimulate applies an unsigned message to the state after the tipset
+// referenced by at and reports what it would do, without sending it. Any
+// method may be called, including ones that change state.
+func (api *API) MessageSimulate(ctx context.Context, at chain.TipSetRef, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, method string, params ...interface{}) (*consensus.SimulationResult, error) {
+	return api.msgSimulator.Simulate(ctx, at, from, to, value, gasPrice, method, params...)
+}
+
+// MessageTrace replays the message with the given cid on the state its
+// tipset was applied to and returns the trace of its execution, including the
+// messages it sent to other actors.
+func (api *API) MessageTrace(ctx context.Context, msgCid cid.Cid) (*vm.Trace, error) {
+	return api.msgTracer.Trace(ctx, msgCid)
+}
+
 // MessageWait invokes the callback when a message with the given cid appears on chain.
 // It will find the message in both the case that it is already on chain and
 // the case that it appears in a newly mined block. An error is returned if one is
@@ -0,0 +1,171 @@
+package evt
+
+import (
+	"context"
+	"fmt"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmRu7tiRnFk9mMPpVECQTBQJqXtmG132jJxA1w9A7TtpBz/go-ipfs-blockstore"
+
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// ChainEvent is an event emitted on chain, along with the message that
+// emitted it.
+type ChainEvent struct {
+	*types.Event
+
+	// Height is the height of the block that included the message.
+	Height uint64 `json:"height"`
+	// Block is the cid of the block that included the message.
+	Block cid.Cid `json:"block"`
+	// Message is the cid of the message.
+	Message cid.Cid `json:"message"`
+	// Reverted is set when the tipset that included the message has left the
+	// chain, undoing an event delivered earlier.
+	Reverted bool `json:"reverted"`
+}
+
+// Watcher follows the events emitted by the messages of new heads.
+type Watcher struct {
+	// To be told of new heads and to walk between them.
+	chainReader chain.ReadStore
+	// To load the events stored when blocks are validated or mined.
+	bs blockstore.Blockstore
+}
+
+// NewWatcher returns a new Watcher.
+func NewWatcher(chainReader chain.ReadStore, bs blockstore.Blockstore) *Watcher {
+	return &Watcher{
+		chainReader: chainReader,
+		bs:          bs,
+	}
+}
+
+// Watch streams the events with the given topic, or all events if topic is
+// empty, emitted by the messages of each tipset that joins the chain, until
+// ctx is done. Only new heads are published, so on each one Watch walks from
+// the common ancestor of the previous head: the events of tipsets that left
+// the chain are sent again with Reverted set, newest first, then the events
+// of the tipsets that joined it, oldest first. The channel carries
+// ChainEvents, or an error after which it is closed.
+func (w *Watcher) Watch(ctx context.Context, topic string) <-chan interface{} {
+	out := make(chan interface{})
+	headCh := w.chainReader.HeadEvents().Sub(chain.NewHeadTopic)
+	prev := w.chainReader.Head()
+
+	go func() {
+		defer close(out)
+		defer func() {
+			// Drain the subscription so that unsubscribing does not block
+			// the publisher of a new head.
+			go func() {
+				for range headCh {
+				}
+			}()
+			w.chainReader.HeadEvents().Unsub(headCh, chain.NewHeadTopic)
+		}()
+
+		send := func(v interface{}) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case out <- v:
+				return true
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case raw, more := <-headCh:
+				if !more {
+					return
+				}
+				head, ok := raw.(types.TipSet)
+				if !ok {
+					send(fmt.Errorf("unexpected type in head channel: %T", raw))
+					return
+				}
+				dropped, added := []types.TipSet{}, []types.TipSet{head}
+				if len(prev) > 0 {
+					var err error
+					dropped, added, err = chain.ReorgPath(ctx, w.chainReader, prev, head)
+					if err != nil {
+						send(err)
+						return
+					}
+				}
+				prev = head
+
+				for _, ts := range dropped {
+					if !w.sendTipSetEvents(ts, topic, true, send) {
+						return
+					}
+				}
+				for _, ts := range added {
+					if !w.sendTipSetEvents(ts, topic, false, send) {
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// sendTipSetEvents sends the events of ts with the given topic, marked as
+// reverted if they are being undone, or the error loading them. It returns
+// false once the stream should end.
+func (w *Watcher) sendTipSetEvents(ts types.TipSet, topic string, reverted bool, send func(interface{}) bool) bool {
+	events, err := w.tipSetEvents(ts, topic)
+	if err != nil {
+		send(err)
+		return false
+	}
+	for _, e := range events {
+		e.Reverted = reverted
+		if !send(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// tipSetEvents returns the events with the given topic, or all if topic is
+// empty, emitted by the messages of ts.
+func (w *Watcher) tipSetEvents(ts types.TipSet, topic string) ([]*ChainEvent, error) {
+	var out []*ChainEvent
+	for _, blk := range ts.ToSlice() {
+		blkCid := blk.Cid()
+		for i, msg := range blk.Messages {
+			if i >= len(blk.MessageReceipts) || !blk.MessageReceipts[i].EventsRoot.Defined() {
+				continue
+			}
+			events, err := consensus.LoadEvents(w.bs, blk.MessageReceipts[i].EventsRoot)
+			if err != nil {
+				return nil, err
+			}
+			msgCid, err := msg.Cid()
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range events {
+				if topic != "" && e.Topic != topic {
+					continue
+				}
+				out = append(out, &ChainEvent{
+					Event:   e,
+					Height:  uint64(blk.Height),
+					Block:   blkCid,
+					Message: msgCid,
+				})
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,172 @@
+package msg
+
+import (
+	"context"
+	"sort"
+
+	bstore "gx/ipfs/QmRu7tiRnFk9mMPpVECQTBQJqXtmG132jJxA1w9A7TtpBz/go-ipfs-blockstore"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/core"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+// GasLimitMarginPercent is how much the estimated gas limit exceeds the gas
+// the message used when applied on top of the latest state.
+const GasLimitMarginPercent = 20
+
+// gasPriceLookback is how many recent tipsets gas prices are sampled from.
+const gasPriceLookback = 20
+
+// GasPriceStats summarizes the gas prices of the messages included in recent
+// tipsets.
+type GasPriceStats struct {
+	// Samples is the number of messages the prices were taken from.  The
+	// percentiles are zero if there are none.
+	Samples int
+	P25     types.AttoFIL
+	P50     types.AttoFIL
+	P75     types.AttoFIL
+}
+
+// Estimator suggests gas limits and gas prices for messages.
+type Estimator struct {
+	// To get the latest state and recent tipsets.
+	chainReader chain.ReadStore
+	// To find the sender's pending messages.
+	msgPool *core.MessagePool
+	// For vm storage.
+	bs bstore.Blockstore
+}
+
+// NewEstimator constructs an Estimator.
+func NewEstimator(chainReader chain.ReadStore, msgPool *core.MessagePool, bs bstore.Blockstore) *Estimator {
+	return &Estimator{chainReader, msgPool, bs}
+}
+
+// EstimateGasLimit returns a gas limit for a message from from to to sending
+// value.  It applies from's pending messages in the pool on top of the latest
+// state, simulates the message after them, and adds GasLimitMarginPercent.  The
+// result never exceeds the block gas limit.  It returns an error if the message
+// would fail, e.g. because from can't pay value.
+func (e *Estimator) EstimateGasLimit(ctx context.Context, from, to address.Address, value *types.AttoFIL, method string, params ...interface{}) (types.GasUnits, error) {
+	encodedParams, err := abi.ToEncodedValues(params...)
+	if err != nil {
+		return types.NewGasUnits(0), errors.Wrap(err, "couldnt encode message params")
+	}
+
+	st, err := e.chainReader.LatestState(ctx)
+	if err != nil {
+		return types.NewGasUnits(0), errors.Wrap(err, "couldnt get latest state")
+	}
+	h, err := e.chainReader.Head().Height()
+	if err != nil {
+		return types.NewGasUnits(0), errors.Wrap(err, "couldnt get head height")
+	}
+	bh := types.NewBlockHeight(h + 1)
+	vms := vm.NewStorageMap(e.bs)
+
+	if err := e.applyPending(ctx, st, vms, from, bh); err != nil {
+		return types.NewGasUnits(0), err
+	}
+
+	// The gas price doesn't change the gas used.
+	msg := types.NewMessage(from, to, 0, value, method, encodedParams)
+	res, err := consensus.SimulateMessage(ctx, st, vms, msg, *types.NewZeroAttoFIL(), bh, nil)
+	if err != nil {
+		return types.NewGasUnits(0), errors.Wrap(err, "couldnt simulate message")
+	}
+	if res.ExecutionError != nil {
+		return types.NewGasUnits(0), errors.Wrap(res.ExecutionError, "message failed when applied to the latest state")
+	}
+
+	limit := uint64(res.GasUsed) * (100 + GasLimitMarginPercent) / 100
+	if limit > uint64(types.BlockGasLimit) {
+		limit = uint64(types.BlockGasLimit)
+	}
+	return types.NewGasUnits(limit), nil
+}
+
+// applyPending applies from's pending messages with consecutive nonces
+// starting at from's nonce to st.
+func (e *Estimator) applyPending(ctx context.Context, st state.Tree, vms vm.StorageMap, from address.Address, bh *types.BlockHeight) error {
+	var pending []*types.SignedMessage
+	for _, smsg := range e.msgPool.Pending() {
+		if smsg.From == from {
+			pending = append(pending, smsg)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Nonce < pending[j].Nonce })
+
+	act, err := st.GetActor(ctx, from)
+	if state.IsActorNotFoundError(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "couldnt get actor %s", from)
+	}
+
+	processor := consensus.NewDefaultProcessor()
+	gasTracker := vm.NewGasTracker()
+	next := act.Nonce
+	for _, smsg := range pending {
+		if smsg.Nonce != next {
+			continue
+		}
+		// The fees go to the network actor, there is no miner.
+		if _, err := processor.ApplyMessage(ctx, st, vms, smsg, address.NetworkAddress, bh, gasTracker, nil); err != nil {
+			// The message won't apply, neither will later ones.
+			break
+		}
+		next++
+	}
+	return nil
+}
+
+// GasPriceStats returns statistics about the gas prices of the messages
+// included in the last few tipsets.
+func (e *Estimator) GasPriceStats(ctx context.Context) (*GasPriceStats, error) {
+	tipSets, err := chain.CollectAtMostNTipSets(ctx, e.chainReader.BlockHistory(ctx, e.chainReader.Head()), gasPriceLookback)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldnt get recent tipsets")
+	}
+
+	var prices []*types.AttoFIL
+	for _, ts := range tipSets {
+		for _, blk := range ts {
+			for _, smsg := range blk.Messages {
+				price := smsg.GasPrice
+				prices = append(prices, &price)
+			}
+		}
+	}
+	sort.Slice(prices, func(i, j int) bool { return prices[i].LessThan(prices[j]) })
+
+	return &GasPriceStats{
+		Samples: len(prices),
+		P25:     percentile(prices, 25),
+		P50:     percentile(prices, 50),
+		P75:     percentile(prices, 75),
+	}, nil
+}
+
+// percentile returns the p-th percentile of the sorted prices, using the
+// nearest rank.
+func percentile(sorted []*types.AttoFIL, p int) types.AttoFIL {
+	if len(sorted) == 0 {
+		return *types.NewZeroAttoFIL()
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return *sorted[rank-1]
+}
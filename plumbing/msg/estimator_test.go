@@ -0,0 +1,99 @@
+package msg
+
+import (
+	"context"
+	"testing"
+
+	bstore "gx/ipfs/QmRu7tiRnFk9mMPpVECQTBQJqXtmG132jJxA1w9A7TtpBz/go-ipfs-blockstore"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/config"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/core"
+	"github.com/filecoin-project/go-filecoin/repo"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
+)
+
+func TestEstimateGasLimit(t *testing.T) {
+	// Don't add t.Parallel here; these tests muck with globals.
+
+	ctx := context.Background()
+	newAddr := address.NewForTestGetter()
+	signer := types.NewMockSigner(types.MustGenerateKeyInfo(1, types.GenerateKeyInfoSeed()))
+	fromAddr := signer.Addresses[0]
+	fakeActorCodeCid := types.NewCidForTestGetter()()
+	builtin.Actors[fakeActorCodeCid] = &actor.FakeActor{}
+	defer delete(builtin.Actors, fakeActorCodeCid)
+
+	// setup returns an estimator over a chain whose genesis has a fake actor
+	// and an account holding 10 FIL to send from.
+	setup := func(require *require.Assertions) (*Estimator, *core.MessagePool, address.Address) {
+		r := repo.NewInMemoryRepo()
+		bs := bstore.NewBlockstore(r.Datastore())
+		fakeActorAddr := newAddr()
+		fakeActor := th.RequireNewFakeActor(require, vm.NewStorageMap(bs), fakeActorAddr, fakeActorCodeCid)
+		testGen := consensus.MakeGenesisFunc(
+			consensus.AddActor(fakeActorAddr, fakeActor),
+			consensus.ActorAccount(fromAddr, types.NewAttoFILFromFIL(10)),
+		)
+		deps := requireCommonDepsWithGifAndBlockstore(require, testGen, r, bs)
+		msgPool := core.NewMessagePool(config.NewDefaultConfig().Mpool, nil)
+		return NewEstimator(deps.chainStore, msgPool, deps.blockstore), msgPool, fakeActorAddr
+	}
+
+	t.Run("adds the margin to the gas used", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		estimator, _, fakeActorAddr := setup(require)
+		limit, err := estimator.EstimateGasLimit(ctx, fromAddr, fakeActorAddr, types.NewZeroAttoFIL(), "hasReturnValue")
+		require.NoError(err)
+		assert.Equal(types.NewGasUnits(100*(100+GasLimitMarginPercent)/100), limit)
+	})
+
+	t.Run("fails if the sender can't pay the value", func(t *testing.T) {
+		require := require.New(t)
+
+		estimator, _, fakeActorAddr := setup(require)
+		_, err := estimator.EstimateGasLimit(ctx, fromAddr, fakeActorAddr, types.NewAttoFILFromFIL(11), "hasReturnValue")
+		assert.Error(t, err)
+	})
+
+	t.Run("applies the sender's pending messages first", func(t *testing.T) {
+		require := require.New(t)
+
+		estimator, msgPool, fakeActorAddr := setup(require)
+		_, err := estimator.EstimateGasLimit(ctx, fromAddr, fakeActorAddr, types.NewAttoFILFromFIL(5), "hasReturnValue")
+		require.NoError(err)
+
+		pending, err := types.NewSignedMessage(*types.NewMessage(fromAddr, newAddr(), 0, types.NewAttoFILFromFIL(8), "", nil), signer, *types.NewZeroAttoFIL(), types.NewGasUnits(0))
+		require.NoError(err)
+		_, err = msgPool.Add(pending)
+		require.NoError(err)
+
+		_, err = estimator.EstimateGasLimit(ctx, fromAddr, fakeActorAddr, types.NewAttoFILFromFIL(5), "hasReturnValue")
+		assert.Error(t, err)
+	})
+}
+
+func TestPercentile(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	assert.Equal(*types.NewZeroAttoFIL(), percentile(nil, 50))
+
+	var sorted []*types.AttoFIL
+	for i := 1; i <= 8; i++ {
+		sorted = append(sorted, types.NewAttoFILFromFIL(uint64(i)))
+	}
+	assert.Equal(*types.NewAttoFILFromFIL(2), percentile(sorted, 25))
+	assert.Equal(*types.NewAttoFILFromFIL(4), percentile(sorted, 50))
+	assert.Equal(*types.NewAttoFILFromFIL(6), percentile(sorted, 75))
+	assert.Equal(*types.NewAttoFILFromFIL(1), percentile(sorted, 0))
+}
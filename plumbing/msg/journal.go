@@ -0,0 +1,64 @@
+package msg
+
+import (
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmUadX5EcvrBmxAV9sE7wUWtWSqxns5K84qKJBixmcT1w9/go-datastore"
+	"gx/ipfs/QmUadX5EcvrBmxAV9sE7wUWtWSqxns5K84qKJBixmcT1w9/go-datastore/query"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/repo"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// journalKey is the datastore namespace under which locally sent messages
+// are journaled.
+var journalKey = datastore.NewKey("/mpool/local")
+
+// journal persists the messages this node sent so that they can be put back
+// in the message pool after a restart.  Entries are keyed by message cid.
+type journal struct {
+	ds repo.Datastore
+}
+
+func newJournal(ds repo.Datastore) *journal {
+	return &journal{ds: ds}
+}
+
+// put records smsg in the journal.
+func (j *journal) put(smsg *types.SignedMessage) error {
+	c, err := smsg.Cid()
+	if err != nil {
+		return errors.Wrap(err, "failed to get message cid")
+	}
+	data, err := smsg.Marshal()
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal message")
+	}
+	return j.ds.Put(journalKey.ChildString(c.String()), data)
+}
+
+// remove deletes the message with cid c from the journal.
+func (j *journal) remove(c cid.Cid) error {
+	return j.ds.Delete(journalKey.ChildString(c.String()))
+}
+
+// list returns all messages in the journal.
+func (j *journal) list() ([]*types.SignedMessage, error) {
+	res, err := j.ds.Query(query.Query{Prefix: journalKey.String()})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query message journal")
+	}
+
+	var out []*types.SignedMessage
+	for entry := range res.Next() {
+		if entry.Error != nil {
+			return nil, errors.Wrap(entry.Error, "failed to read message journal")
+		}
+		var smsg types.SignedMessage
+		if err := smsg.Unmarshal(entry.Value); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal journaled message %s", entry.Key)
+		}
+		out = append(out, &smsg)
+	}
+	return out, nil
+}
@@ -0,0 +1,57 @@
+package msg
+
+import (
+	"context"
+	"fmt"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// Prover builds proofs that messages are on chain, see types.MessageProof.
+type Prover struct {
+	chainReader chain.ReadStore
+}
+
+// NewProver returns a new Prover.
+func NewProver(chainReader chain.ReadStore) *Prover {
+	return &Prover{chainReader: chainReader}
+}
+
+// Prove finds the message with the given cid on chain and returns the cid of
+// the block including it and the proof of its inclusion in that block.
+//
+// TODO: like Waiter.Wait this traverses the chain to find the message.
+func (p *Prover) Prove(ctx context.Context, msgCid cid.Cid) (cid.Cid, *types.MessageProof, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for raw := range p.chainReader.BlockHistory(ctx, p.chainReader.Head()) {
+		switch v := raw.(type) {
+		case error:
+			return cid.Undef, nil, v
+		case types.TipSet:
+			for _, blk := range v {
+				for i, msg := range blk.Messages {
+					c, err := msg.Cid()
+					if err != nil {
+						return cid.Undef, nil, err
+					}
+					if !c.Equals(msgCid) {
+						continue
+					}
+					proof, err := types.NewMessageProof(blk, i)
+					if err != nil {
+						return cid.Undef, nil, err
+					}
+					return blk.Cid(), proof, nil
+				}
+			}
+		default:
+			return cid.Undef, nil, fmt.Errorf("unexpected type in channel: %T", raw)
+		}
+	}
+	return cid.Undef, nil, fmt.Errorf("message %s not found on chain", msgCid)
+}
@@ -41,30 +41,35 @@ func NewQueryer(repo repo.Repo, wallet *wallet.Wallet, chainReader chain.ReadSto
 
 // Query sends a read-only message to an actor.
 func (q *Queryer) Query(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, *exec.FunctionSignature, error) {
+	return q.QueryAt(ctx, chain.TipSetRef{}, optFrom, to, method, params...)
+}
+
+// QueryAt sends a read-only message to an actor, against the state after
+// the tipset referenced by at.
+func (q *Queryer) QueryAt(ctx context.Context, at chain.TipSetRef, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, *exec.FunctionSignature, error) {
 	encodedParams, err := abi.ToEncodedValues(params...)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "couldnt encode message params")
 	}
 
-	// We return the method signature so callers know how to decode the return value.
-	// Probably would be better to do the decoding here since we are after all accepting
-	// golang types.
-	sigGetter := mthdsig.NewGetter(q.chainReader)
-	sig, err := sigGetter.Get(ctx, to, method)
+	tsas, err := q.chainReader.GetTipSetAndStateAt(ctx, at)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "unable to determine return type")
+		return nil, nil, errors.Wrap(err, "couldnt get state root")
 	}
-
-	headTs := q.chainReader.Head()
-	tsas, err := q.chainReader.GetTipSetAndState(ctx, headTs.String())
+	st, err := state.LoadStateTree(ctx, q.cst, tsas.TipSetStateRoot, builtin.Actors)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "couldnt get latest state root")
+		return nil, nil, errors.Wrap(err, "could load tree for state root")
 	}
-	st, err := state.LoadStateTree(ctx, q.cst, tsas.TipSetStateRoot, builtin.Actors)
+
+	// We return the method signature so callers know how to decode the return value.
+	// Probably would be better to do the decoding here since we are after all accepting
+	// golang types. It is taken from the actor's code in the queried state,
+	// which may differ from its code in the latest state.
+	sig, err := mthdsig.GetFromState(ctx, st, to, method)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "could load tree for latest state root")
+		return nil, nil, errors.Wrap(err, "unable to determine return type")
 	}
-	h, err := headTs.Height()
+	h, err := tsas.TipSet.Height()
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "couldnt get base tipset height")
 	}
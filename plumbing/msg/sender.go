@@ -12,6 +12,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/chain"
 	"github.com/filecoin-project/go-filecoin/core"
 	"github.com/filecoin-project/go-filecoin/repo"
+	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
 	"github.com/filecoin-project/go-filecoin/wallet"
 )
@@ -35,14 +36,30 @@ type Sender struct {
 	// To publish the new message to the network.
 	publish PublishFunc
 
+	// To put sent messages back in the pool after a restart.
+	journal *journal
+
+	// To rebroadcast sent messages and tell which are stuck.
+	tracker *tracker
+
 	// Locking in send reduces the chance of nonce collision.
 	l sync.Mutex
 }
 
 // NewSender returns a new Sender. There should be exactly one of these per node because
-// sending locks to reduce nonce collisions.
+// sending locks to reduce nonce collisions. Sent messages are journaled to the repo's
+// datastore and tracked, so that Rebroadcast can publish them again, until Prune finds
+// they left the message pool.
 func NewSender(repo repo.Repo, wallet *wallet.Wallet, chainReader chain.ReadStore, msgPool *core.MessagePool, publish PublishFunc) *Sender {
-	return &Sender{repo: repo, wallet: wallet, chainReader: chainReader, msgPool: msgPool, publish: publish}
+	return &Sender{
+		repo:        repo,
+		wallet:      wallet,
+		chainReader: chainReader,
+		msgPool:     msgPool,
+		publish:     publish,
+		journal:     newJournal(repo.Datastore()),
+		tracker:     newTracker(),
+	}
 }
 
 // Send sends a message. See api description.
@@ -67,22 +84,182 @@ func (s *Sender) Send(ctx context.Context, from, to address.Address, value *type
 		return cid.Undef, errors.Wrap(err, "failed to sign message")
 	}
 
+	if err := s.enqueue(smsg); err != nil {
+		return cid.Undef, err
+	}
+
+	log.Debugf("MessageSend with message: %s", smsg)
+
+	return smsg.Cid()
+}
+
+// Replace re-signs the pending message with cid c with a new gas price and
+// sends it in place of the original.  The message pool only accepts the
+// replacement if the new gas price is sufficiently higher than the old one.
+func (s *Sender) Replace(ctx context.Context, c cid.Cid, gasPrice types.AttoFIL) (cid.Cid, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	old, ok := s.msgPool.Get(c)
+	if !ok {
+		return cid.Undef, errors.Errorf("message %s is not in the message pool", c.String())
+	}
+	if !s.wallet.HasAddress(old.From) {
+		return cid.Undef, errors.Errorf("cannot replace message %s: sender %s is not in the wallet", c.String(), old.From)
+	}
+
+	smsg, err := types.NewSignedMessage(old.Message, s.wallet, gasPrice, old.GasLimit)
+	if err != nil {
+		return cid.Undef, errors.Wrap(err, "failed to sign message")
+	}
+
+	if err := s.enqueue(smsg); err != nil {
+		return cid.Undef, err
+	}
+
+	if err := s.journal.remove(c); err != nil {
+		log.Warningf("failed to remove replaced message %s from journal: %s", c.String(), err)
+	}
+	s.tracker.untrack(c)
+
+	log.Debugf("MessageReplace of %s with message: %s", c.String(), smsg)
+
+	return smsg.Cid()
+}
+
+// enqueue adds smsg to the message pool, publishes it to the network and
+// starts tracking it.
+func (s *Sender) enqueue(smsg *types.SignedMessage) error {
+	c, err := smsg.Cid()
+	if err != nil {
+		return errors.Wrap(err, "failed to get message cid")
+	}
 	smsgdata, err := smsg.Marshal()
 	if err != nil {
-		return cid.Undef, errors.Wrap(err, "failed to marshal message")
+		return errors.Wrap(err, "failed to marshal message")
 	}
 
 	if _, err := s.msgPool.Add(smsg); err != nil {
-		return cid.Undef, errors.Wrap(err, "failed to add message to the message pool")
+		return errors.Wrap(err, "failed to add message to the message pool")
+	}
+
+	// A failure to journal only means the message won't survive a restart,
+	// so it is not surfaced to the caller.
+	if err := s.journal.put(smsg); err != nil {
+		log.Warningf("failed to journal message %s: %s", smsg, err)
 	}
 
 	if err = s.publish(Topic, smsgdata); err != nil {
-		return cid.Undef, errors.Wrap(err, "couldnt publish new message to network")
+		return errors.Wrap(err, "couldnt publish new message to network")
 	}
+	s.tracker.track(c, smsg, s.headHeight())
+	return nil
+}
 
-	log.Debugf("MessageSend with message: %s", smsg)
+// Prune forgets the sent messages that have left the message pool, because
+// they were included in the chain or expired, and removes them from the
+// journal so they are not restored after a restart.
+func (s *Sender) Prune(ctx context.Context) {
+	inPool := func(c cid.Cid) bool {
+		_, ok := s.msgPool.Get(c)
+		return ok
+	}
+	for _, c := range s.tracker.prune(inPool) {
+		if err := s.journal.remove(c); err != nil {
+			log.Warningf("failed to remove message %s from journal: %s", c.String(), err)
+		}
+	}
+}
 
-	return smsg.Cid()
+// Rebroadcast publishes again the sent messages that are still in the message
+// pool and are due for it.  Each call is a round of rebroadcasting, and a
+// message is rebroadcast in the round after it was sent, then after two more,
+// four more and so on.  It is meant to be called periodically, independently
+// of the head, so that messages are rebroadcast even if no tipsets arrive.
+func (s *Sender) Rebroadcast(ctx context.Context) {
+	s.Prune(ctx)
+	for _, smsg := range s.tracker.due() {
+		smsgdata, err := smsg.Marshal()
+		if err != nil {
+			log.Warningf("failed to marshal message for rebroadcast: %s", err)
+			continue
+		}
+		if err := s.publish(Topic, smsgdata); err != nil {
+			log.Warningf("failed to rebroadcast message: %s", err)
+		}
+	}
+}
+
+// Status returns the status of the sent messages that are still in the
+// message pool, oldest first.
+func (s *Sender) Status(ctx context.Context) []*SentMessage {
+	return s.tracker.status(s.headHeight(), s.repo.Config().Mpool.StuckAfterTipSets)
+}
+
+// headHeight returns the height of the head, or zero if there is none.
+func (s *Sender) headHeight() uint64 {
+	h, err := s.chainReader.Head().Height()
+	if err != nil {
+		return 0
+	}
+	return h
+}
+
+// Restore puts the journaled messages that are not yet on chain back in the
+// message pool and publishes them again. Messages whose nonce is behind their
+// sender's nonce in the latest state, or that the pool no longer accepts, are
+// dropped from the journal. It returns the number of messages restored.
+func (s *Sender) Restore(ctx context.Context) (int, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	msgs, err := s.journal.list()
+	if err != nil {
+		return 0, err
+	}
+	st, err := s.chainReader.LatestState(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to load latest state")
+	}
+
+	restored := 0
+	for _, smsg := range msgs {
+		c, err := smsg.Cid()
+		if err != nil {
+			return restored, errors.Wrap(err, "failed to get message cid")
+		}
+
+		act, err := st.GetActor(ctx, smsg.From)
+		if err != nil && !state.IsActorNotFoundError(err) {
+			return restored, errors.Wrapf(err, "failed to get actor %s", smsg.From)
+		}
+		if act != nil && smsg.Nonce < act.Nonce {
+			// Already on chain, or another message took its nonce.
+			if err := s.journal.remove(c); err != nil {
+				return restored, errors.Wrap(err, "failed to remove message from journal")
+			}
+			continue
+		}
+
+		if _, err := s.msgPool.Add(smsg); err != nil {
+			log.Warningf("dropping journaled message %s: %s", c.String(), err)
+			if err := s.journal.remove(c); err != nil {
+				return restored, errors.Wrap(err, "failed to remove message from journal")
+			}
+			continue
+		}
+		restored++
+
+		smsgdata, err := smsg.Marshal()
+		if err != nil {
+			return restored, errors.Wrap(err, "failed to marshal message")
+		}
+		if err := s.publish(Topic, smsgdata); err != nil {
+			log.Warningf("failed to publish journaled message %s: %s", c.String(), err)
+		}
+		s.tracker.track(c, smsg, s.headHeight())
+	}
+	return restored, nil
 }
 
 // nextNonce returns the next nonce for the given address. It checks
@@ -7,8 +7,11 @@ import (
 
 	"testing"
 
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/config"
 	"github.com/filecoin-project/go-filecoin/core"
 	"github.com/filecoin-project/go-filecoin/repo"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -84,6 +87,132 @@ func TestSend(t *testing.T) {
 
 }
 
+func TestReplace(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	repo, w, chainStore, msgPool := setupSendTest(require)
+	addr, err := wallet.NewAddress(w)
+	require.NoError(err)
+
+	published := 0
+	publish := func(string, []byte) error {
+		published++
+		return nil
+	}
+	s := NewSender(repo, w, chainStore, msgPool, publish)
+
+	orig, err := s.Send(ctx, addr, addr, types.NewZeroAttoFIL(), types.NewGasPrice(100), types.NewGasUnits(50), "")
+	require.NoError(err)
+
+	t.Run("rejects a gas price that is not high enough", func(t *testing.T) {
+		_, err := s.Replace(ctx, orig, types.NewGasPrice(105))
+		assert.Error(err)
+		assert.Equal(1, published)
+	})
+
+	t.Run("replaces the message in the pool", func(t *testing.T) {
+		replaced, err := s.Replace(ctx, orig, types.NewGasPrice(110))
+		require.NoError(err)
+		assert.Equal(2, published)
+
+		pending := msgPool.Pending()
+		require.Len(pending, 1)
+		c, err := pending[0].Cid()
+		require.NoError(err)
+		assert.True(c.Equals(replaced))
+		assert.Equal(types.Uint64(0), pending[0].Nonce)
+		assert.Equal(types.NewGasPrice(110), pending[0].GasPrice)
+		assert.Equal(types.NewGasUnits(50), pending[0].GasLimit)
+	})
+
+	t.Run("errors for unknown messages", func(t *testing.T) {
+		_, err := s.Replace(ctx, orig, types.NewGasPrice(1000))
+		assert.Error(err)
+	})
+}
+
+func TestRestore(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	repo, w, chainStore, msgPool := setupSendTest(require)
+	addr, err := wallet.NewAddress(w)
+	require.NoError(err)
+	nopPublish := func(string, []byte) error { return nil }
+	s := NewSender(repo, w, chainStore, msgPool, nopPublish)
+
+	first, err := s.Send(ctx, addr, addr, types.NewZeroAttoFIL(), types.NewGasPrice(100), types.NewGasUnits(0), "")
+	require.NoError(err)
+	second, err := s.Send(ctx, addr, addr, types.NewZeroAttoFIL(), types.NewGasPrice(100), types.NewGasUnits(0), "")
+	require.NoError(err)
+	replaced, err := s.Replace(ctx, second, types.NewGasPrice(200))
+	require.NoError(err)
+
+	// A new pool and sender over the same repo, as after a restart.
+	restartedPool := core.NewMessagePool(config.NewDefaultConfig().Mpool, nil)
+	var published []cid.Cid
+	publish := func(topic string, data []byte) error {
+		var smsg types.SignedMessage
+		require.NoError(smsg.Unmarshal(data))
+		c, err := smsg.Cid()
+		require.NoError(err)
+		published = append(published, c)
+		return nil
+	}
+	restarted := NewSender(repo, w, chainStore, restartedPool, publish)
+
+	n, err := restarted.Restore(ctx)
+	require.NoError(err)
+	assert.Equal(2, n)
+	assert.Len(restartedPool.Pending(), 2)
+	require.Len(published, 2)
+	assert.Contains(published, first)
+	assert.Contains(published, replaced)
+	_, ok := restartedPool.Get(second)
+	assert.False(ok)
+}
+
+func TestPrune(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	repo, w, chainStore, msgPool := setupSendTest(require)
+	addr, err := wallet.NewAddress(w)
+	require.NoError(err)
+	nopPublish := func(string, []byte) error { return nil }
+	s := NewSender(repo, w, chainStore, msgPool, nopPublish)
+
+	included, err := s.Send(ctx, addr, addr, types.NewZeroAttoFIL(), types.NewGasPrice(100), types.NewGasUnits(0), "")
+	require.NoError(err)
+	pending, err := s.Send(ctx, addr, addr, types.NewZeroAttoFIL(), types.NewGasPrice(100), types.NewGasUnits(0), "")
+	require.NoError(err)
+
+	// The message leaves the pool as when it is included in a block.
+	msgPool.Remove(included)
+	s.Prune(ctx)
+
+	journaled, err := s.journal.list()
+	require.NoError(err)
+	require.Len(journaled, 1)
+	c, err := journaled[0].Cid()
+	require.NoError(err)
+	assert.True(c.Equals(pending))
+
+	status := s.Status(ctx)
+	require.Len(status, 1)
+	assert.True(status[0].Cid.Equals(pending))
+}
+
 func TestNextNonce(t *testing.T) {
 	t.Parallel()
 
@@ -123,5 +252,5 @@ func TestNextNonce(t *testing.T) {
 
 func setupSendTest(require *require.Assertions) (repo.Repo, *wallet.Wallet, *chain.DefaultStore, *core.MessagePool) {
 	d := requireCommonDeps(require)
-	return d.repo, d.wallet, d.chainStore, core.NewMessagePool()
+	return d.repo, d.wallet, d.chainStore, core.NewMessagePool(config.NewDefaultConfig().Mpool, nil)
 }
@@ -0,0 +1,74 @@
+package msg
+
+import (
+	"context"
+
+	"gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
+	bstore "gx/ipfs/QmRu7tiRnFk9mMPpVECQTBQJqXtmG132jJxA1w9A7TtpBz/go-ipfs-blockstore"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+// Simulator runs messages against the state of a tipset without sending them.
+type Simulator struct {
+	// To get the state of the tipset to simulate on.
+	chainReader chain.ReadStore
+	// To load the state tree.
+	cst *hamt.CborIpldStore
+	// For vm storage.
+	bs bstore.Blockstore
+}
+
+// NewSimulator constructs a Simulator.
+func NewSimulator(chainReader chain.ReadStore, cst *hamt.CborIpldStore, bs bstore.Blockstore) *Simulator {
+	return &Simulator{chainReader, cst, bs}
+}
+
+// Simulate applies an unsigned message from the given address to the state
+// after the tipset referenced by at, as if it were in the next block, and
+// reports its receipt, gas and the actors it would change. Any method may be
+// called and any value sent; nothing is written to the chain or its state.
+func (s *Simulator) Simulate(ctx context.Context, at chain.TipSetRef, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, method string, params ...interface{}) (*consensus.SimulationResult, error) {
+	encodedParams, err := abi.ToEncodedValues(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldnt encode message params")
+	}
+
+	tsas, err := s.chainReader.GetTipSetAndStateAt(ctx, at)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldnt get state root")
+	}
+	st, err := state.LoadStateTree(ctx, s.cst, tsas.TipSetStateRoot, builtin.Actors)
+	if err != nil {
+		return nil, errors.Wrap(err, "could load tree for state root")
+	}
+	h, err := tsas.TipSet.Height()
+	if err != nil {
+		return nil, errors.Wrap(err, "couldnt get base tipset height")
+	}
+	bh := types.NewBlockHeight(h + 1)
+	ancestors, err := chain.GetRecentAncestors(ctx, tsas.TipSet, s.chainReader, bh, consensus.AncestorRoundsNeeded, consensus.LookBackParameter)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldnt get ancestors")
+	}
+
+	vms := vm.NewStorageMap(s.bs)
+	if err := consensus.RunUpgrades(ctx, st, vms, h, h+1); err != nil {
+		return nil, errors.Wrap(err, "couldnt upgrade state")
+	}
+
+	msg := types.NewMessage(from, to, 0, value, method, encodedParams)
+	res, err := consensus.SimulateMessage(ctx, st, vms, msg, gasPrice, bh, ancestors)
+	if err != nil {
+		return nil, errors.Wrap(err, "simulating message failed")
+	}
+	return res, nil
+}
@@ -0,0 +1,111 @@
+package msg
+
+import (
+	"context"
+	"fmt"
+
+	"gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	bstore "gx/ipfs/QmRu7tiRnFk9mMPpVECQTBQJqXtmG132jJxA1w9A7TtpBz/go-ipfs-blockstore"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+// Tracer replays messages on chain to record their execution.
+type Tracer struct {
+	chainReader chain.ReadStore
+	cst         *hamt.CborIpldStore
+	bs          bstore.Blockstore
+}
+
+// NewTracer returns a new Tracer.
+func NewTracer(chainReader chain.ReadStore, bs bstore.Blockstore, cst *hamt.CborIpldStore) *Tracer {
+	return &Tracer{
+		chainReader: chainReader,
+		cst:         cst,
+		bs:          bs,
+	}
+}
+
+// Trace finds the message with the given cid on chain and replays its tipset
+// on the parent state up to it, returning the execution trace of the message.
+// Nothing is written to the chain or its state.
+//
+// TODO: like Waiter.Wait this traverses the chain to find the message.
+func (t *Tracer) Trace(ctx context.Context, msgCid cid.Cid) (*vm.Trace, error) {
+	ts, err := t.findTipSet(ctx, msgCid)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := ts.Parents()
+	if err != nil {
+		return nil, err
+	}
+	if ids.Len() == 0 {
+		return nil, fmt.Errorf("message %s is in the genesis block", msgCid)
+	}
+	tsas, err := t.chainReader.GetTipSetAndState(ctx, ids.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get parent state")
+	}
+	st, err := state.LoadStateTree(ctx, t.cst, tsas.TipSetStateRoot, builtin.Actors)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load parent state")
+	}
+
+	h, err := ts.Height()
+	if err != nil {
+		return nil, err
+	}
+	ancestors, err := chain.GetRecentAncestors(ctx, tsas.TipSet, t.chainReader, types.NewBlockHeight(h), consensus.AncestorRoundsNeeded, consensus.LookBackParameter)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get ancestors")
+	}
+
+	parentHeight, err := tsas.TipSet.Height()
+	if err != nil {
+		return nil, err
+	}
+	vms := vm.NewStorageMap(t.bs)
+	if err := consensus.RunUpgrades(ctx, st, vms, parentHeight, h); err != nil {
+		return nil, err
+	}
+
+	return consensus.NewDefaultProcessor().TraceMessage(ctx, st, vms, ts, msgCid, ancestors)
+}
+
+// findTipSet returns the tipset of the chain containing the message with the
+// given cid.
+func (t *Tracer) findTipSet(ctx context.Context, msgCid cid.Cid) (types.TipSet, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for raw := range t.chainReader.BlockHistory(ctx, t.chainReader.Head()) {
+		switch v := raw.(type) {
+		case error:
+			return nil, v
+		case types.TipSet:
+			for _, blk := range v {
+				for _, msg := range blk.Messages {
+					c, err := msg.Cid()
+					if err != nil {
+						return nil, err
+					}
+					if c.Equals(msgCid) {
+						return v, nil
+					}
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unexpected type in channel: %T", raw)
+		}
+	}
+	return nil, fmt.Errorf("message %s not found on chain", msgCid)
+}
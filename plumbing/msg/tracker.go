@@ -0,0 +1,154 @@
+package msg
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// maxRebroadcastInterval is the most rounds the tracker waits between
+// rebroadcasts of a message.
+const maxRebroadcastInterval = 32
+
+// SentMessage is the status of a message this node sent that is still in the
+// message pool.
+type SentMessage struct {
+	Cid     cid.Cid
+	Message *types.SignedMessage
+	// SentAtHeight is the height of the head when the message was sent.
+	SentAtHeight uint64
+	// PendingTipSets is how many tipsets the message has been waiting for.
+	PendingTipSets uint64
+	// Broadcasts is how many times the message has been published.
+	Broadcasts uint
+	// LastBroadcast is when the message was last published.
+	LastBroadcast time.Time
+	// Stuck is true if the message has been pending for more tipsets than
+	// the configured mpool.stuckAfterTipSets.
+	Stuck bool
+}
+
+type trackedMessage struct {
+	msg           *types.SignedMessage
+	sentAtHeight  uint64
+	broadcasts    uint
+	lastBroadcast time.Time
+	// The message is rebroadcast in round nextRound, after which interval
+	// doubles.
+	nextRound uint64
+	interval  uint64
+}
+
+// tracker keeps track of locally sent messages while they are in the
+// message pool, to rebroadcast them on an exponential backoff and to tell
+// which are stuck.  The backoff is measured in rounds, each call to due
+// being one, so that messages are rebroadcast even if the head does not
+// change.  Messages are stuck after a number of tipsets.
+type tracker struct {
+	mu       sync.Mutex
+	messages map[cid.Cid]*trackedMessage
+	// round is the number of calls to due so far.
+	round uint64
+}
+
+func newTracker() *tracker {
+	return &tracker{messages: make(map[cid.Cid]*trackedMessage)}
+}
+
+// track starts tracking smsg, which was just published with the head at
+// height.
+func (t *tracker) track(c cid.Cid, smsg *types.SignedMessage, height uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.messages[c] = &trackedMessage{
+		msg:           smsg,
+		sentAtHeight:  height,
+		broadcasts:    1,
+		lastBroadcast: time.Now(),
+		nextRound:     t.round + 1,
+		interval:      1,
+	}
+}
+
+// untrack stops tracking the message with cid c.
+func (t *tracker) untrack(c cid.Cid) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.messages, c)
+}
+
+// prune stops tracking the messages for which inPool returns false and
+// returns their cids.
+func (t *tracker) prune(inPool func(cid.Cid) bool) []cid.Cid {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var out []cid.Cid
+	for c := range t.messages {
+		if !inPool(c) {
+			delete(t.messages, c)
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// due starts the next round and returns the messages that should be
+// rebroadcast in it, recording them as broadcast.
+func (t *tracker) due() []*types.SignedMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.round++
+	var out []*types.SignedMessage
+	for _, tm := range t.messages {
+		if t.round < tm.nextRound {
+			continue
+		}
+		tm.broadcasts++
+		tm.lastBroadcast = time.Now()
+		if tm.interval < maxRebroadcastInterval {
+			tm.interval *= 2
+		}
+		tm.nextRound = t.round + tm.interval
+		out = append(out, tm.msg)
+	}
+	return out
+}
+
+// status returns the status of the tracked messages with the head at height,
+// oldest first.
+func (t *tracker) status(height, stuckAfter uint64) []*SentMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make([]*SentMessage, 0, len(t.messages))
+	for c, tm := range t.messages {
+		var pending uint64
+		if height > tm.sentAtHeight {
+			pending = height - tm.sentAtHeight
+		}
+		out = append(out, &SentMessage{
+			Cid:            c,
+			Message:        tm.msg,
+			SentAtHeight:   tm.sentAtHeight,
+			PendingTipSets: pending,
+			Broadcasts:     tm.broadcasts,
+			LastBroadcast:  tm.lastBroadcast,
+			Stuck:          pending > stuckAfter,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].SentAtHeight != out[j].SentAtHeight {
+			return out[i].SentAtHeight < out[j].SentAtHeight
+		}
+		return out[i].Cid.String() < out[j].Cid.String()
+	})
+	return out
+}
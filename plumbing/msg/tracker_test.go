@@ -0,0 +1,78 @@
+package msg
+
+import (
+	"testing"
+
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestTracker(t *testing.T) {
+	t.Parallel()
+
+	newMsg := types.NewSignedMessageForTestGetter(mockSigner)
+
+	t.Run("rebroadcasts on an exponential backoff", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		tr := newTracker()
+		smsg := newMsg()
+		c, err := smsg.Cid()
+		require.NoError(err)
+		tr.track(c, smsg, 10)
+
+		var rebroadcastIn []int
+		for round := 1; round <= 15; round++ {
+			if len(tr.due()) > 0 {
+				rebroadcastIn = append(rebroadcastIn, round)
+			}
+		}
+		assert.Equal([]int{1, 3, 7, 15}, rebroadcastIn)
+
+		status := tr.status(25, 100)
+		require.Len(status, 1)
+		assert.Equal(uint(5), status[0].Broadcasts)
+		assert.Equal(uint64(15), status[0].PendingTipSets)
+	})
+
+	t.Run("stops tracking messages that left the pool", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		tr := newTracker()
+		smsg := newMsg()
+		c, err := smsg.Cid()
+		require.NoError(err)
+		tr.track(c, smsg, 0)
+
+		assert.Empty(tr.prune(func(cid.Cid) bool { return true }))
+		assert.Equal([]cid.Cid{c}, tr.prune(func(cid.Cid) bool { return false }))
+		assert.Empty(tr.due())
+		assert.Empty(tr.status(1, 100))
+	})
+
+	t.Run("flags stuck messages", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		tr := newTracker()
+		old, recent := newMsg(), newMsg()
+		oldCid, err := old.Cid()
+		require.NoError(err)
+		recentCid, err := recent.Cid()
+		require.NoError(err)
+		tr.track(recentCid, recent, 8)
+		tr.track(oldCid, old, 2)
+
+		status := tr.status(10, 5)
+		require.Len(status, 2)
+		assert.Equal(oldCid, status[0].Cid)
+		assert.True(status[0].Stuck)
+		assert.Equal(recentCid, status[1].Cid)
+		assert.False(status[1].Stuck)
+	})
+}
@@ -163,7 +163,16 @@ func (w *Waiter) receiptFromTipSet(ctx context.Context, msgCid cid.Cid, ts types
 		return nil, err
 	}
 
-	res, err := consensus.NewDefaultProcessor().ProcessTipSet(ctx, st, vm.NewStorageMap(w.bs), ts, ancestors)
+	parentHeight, err := tsas.TipSet.Height()
+	if err != nil {
+		return nil, err
+	}
+	vms := vm.NewStorageMap(w.bs)
+	if err := consensus.RunUpgrades(ctx, st, vms, parentHeight, tsHeight); err != nil {
+		return nil, err
+	}
+
+	res, err := consensus.NewDefaultProcessor().ProcessTipSet(ctx, st, vms, ts, ancestors)
 	if err != nil {
 		return nil, err
 	}
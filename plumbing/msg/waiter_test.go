@@ -158,7 +158,8 @@ func TestWaitConflicting(t *testing.T) {
 			Parent: baseTS, GenesisCid: chainStore.GenesisCid(), StateRoot: baseBlock.StateRoot})
 	b1.Messages = []*types.SignedMessage{sm1}
 	b1.Ticket = []byte{0} // block 1 comes first in message application
-	core.MustPut(cst, b1)
+	require.NoError(b1.SetCollectionRoots())
+	core.MustPutBlock(cst, b1)
 
 	b2 := chain.RequireMkFakeChild(require,
 		chain.FakeChildParams{
@@ -166,7 +167,8 @@ func TestWaitConflicting(t *testing.T) {
 			StateRoot: baseBlock.StateRoot, Nonce: uint64(1)})
 	b2.Messages = []*types.SignedMessage{sm2}
 	b2.Ticket = []byte{1}
-	core.MustPut(cst, b2)
+	require.NoError(b2.SetCollectionRoots())
+	core.MustPutBlock(cst, b2)
 
 	ts := testhelpers.RequireNewTipSet(require, b1, b2)
 	chain.RequirePutTsas(ctx, require, chainStore, &chain.TipSetAndState{
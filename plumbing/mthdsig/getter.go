@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 
 	"github.com/filecoin-project/go-filecoin/address"
@@ -43,6 +44,13 @@ func (sg *Getter) Get(ctx context.Context, actorAddr address.Address, method str
 		return nil, errors.Wrap(err, "couldnt get current state tree")
 	}
 
+	return GetFromState(ctx, st, actorAddr, method)
+}
+
+// GetFromState is like Getter.Get but looks the actor's code up in st rather
+// than in the latest state, e.g. to decode the return value of a query
+// against an earlier state.
+func GetFromState(ctx context.Context, st state.Tree, actorAddr address.Address, method string) (*exec.FunctionSignature, error) {
 	actor, err := st.GetActor(ctx, actorAddr)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get actor")
@@ -66,3 +74,40 @@ func (sg *Getter) Get(ctx context.Context, actorAddr address.Address, method str
 
 	return export, nil
 }
+
+// Describe returns the description of the methods of the actor at actorAddr.
+func (sg *Getter) Describe(ctx context.Context, actorAddr address.Address) (*exec.ActorDescription, error) {
+	st, err := sg.chainReader.LatestState(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldnt get current state tree")
+	}
+
+	actor, err := st.GetActor(ctx, actorAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get actor")
+	} else if !actor.Code.Defined() {
+		return nil, ErrNoActorImpl
+	}
+
+	return sg.describeCode(st, actor.Code)
+}
+
+// DescribeCode returns the description of the methods of actors with the
+// given code.
+func (sg *Getter) DescribeCode(ctx context.Context, code cid.Cid) (*exec.ActorDescription, error) {
+	st, err := sg.chainReader.LatestState(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldnt get current state tree")
+	}
+
+	return sg.describeCode(st, code)
+}
+
+func (sg *Getter) describeCode(st state.Tree, code cid.Cid) (*exec.ActorDescription, error) {
+	executable, err := st.GetBuiltinActorCode(code)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load actor code")
+	}
+
+	return exec.Describe(code, executable), nil
+}
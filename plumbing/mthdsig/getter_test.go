@@ -61,6 +61,36 @@ func TestGet(t *testing.T) {
 		require.Equal(expected, sig)
 	})
 
+	t.Run("GetFromState looks up the actor in the given state", func(t *testing.T) {
+		require := require.New(t)
+
+		ctx := context.Background()
+		cst := hamt.NewCborStore()
+		addr := address.NewForTestGetter()()
+		bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+		vms := vm.NewStorageMap(bs)
+
+		emptyActorCodeCid := types.NewCidForTestGetter()()
+		builtin.Actors[emptyActorCodeCid] = &actor.FakeActor{}
+		defer func() {
+			delete(builtin.Actors, emptyActorCodeCid)
+		}()
+
+		fakeActor := th.RequireNewFakeActorWithTokens(require, vms, addr, emptyActorCodeCid, types.NewAttoFILFromFIL(102))
+		_, st := th.RequireMakeStateTree(require, cst, map[address.Address]*actor.Actor{
+			addr: fakeActor,
+		})
+		_, latest := th.RequireMakeStateTree(require, cst, map[address.Address]*actor.Actor{})
+
+		_, err := mthdsig.NewGetter(&fakeChainReadStore{latest}).Get(ctx, addr, "hasReturnValue")
+		require.Error(err)
+
+		sig, err := mthdsig.GetFromState(ctx, st, addr, "hasReturnValue")
+		require.NoError(err)
+		expected := &exec.FunctionSignature{Params: []abi.Type(nil), Return: []abi.Type{abi.Address}}
+		require.Equal(expected, sig)
+	})
+
 	t.Run("errors if no such method", func(t *testing.T) {
 		require := require.New(t)
 
@@ -124,3 +154,55 @@ func TestGet(t *testing.T) {
 		require.Error(err)
 	})
 }
+
+func TestDescribe(t *testing.T) {
+	t.Parallel()
+
+	t.Run("describes the methods and errors of an actor", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		ctx := context.Background()
+		cst := hamt.NewCborStore()
+		addr := address.NewForTestGetter()()
+
+		acctActor := th.RequireNewAccountActor(require, types.NewAttoFILFromFIL(10000))
+		_, st := th.RequireMakeStateTree(require, cst, map[address.Address]*actor.Actor{
+			addr: acctActor,
+		})
+		getter := mthdsig.NewGetter(&fakeChainReadStore{st})
+
+		desc, err := getter.DescribeCode(ctx, types.MinerActorCodeCid)
+		require.NoError(err)
+		assert.Equal("MinerActor", desc.Name)
+		assert.Len(desc.Methods, len(builtin.Actors[types.MinerActorCodeCid].Exports()))
+		assert.NotEmpty(desc.Errors)
+		assert.NotEmpty(desc.VMErrors)
+
+		var getAsk *exec.MethodDescription
+		for _, m := range desc.Methods {
+			if m.Name == "getAsk" {
+				getAsk = m
+			}
+		}
+		require.NotNil(getAsk)
+		assert.Equal(abi.TupleSchema([]abi.Type{abi.Integer}), getAsk.Params)
+	})
+
+	t.Run("errors if actor undefined", func(t *testing.T) {
+		require := require.New(t)
+
+		ctx := context.Background()
+		cst := hamt.NewCborStore()
+		addr := address.NewForTestGetter()()
+
+		emptyActor := th.RequireNewEmptyActor(require, types.NewAttoFILFromFIL(0))
+		_, st := th.RequireMakeStateTree(require, cst, map[address.Address]*actor.Actor{
+			addr: emptyActor,
+		})
+		getter := mthdsig.NewGetter(&fakeChainReadStore{st})
+
+		_, err := getter.Describe(ctx, addr)
+		require.Equal(mthdsig.ErrNoActorImpl, err)
+	})
+}
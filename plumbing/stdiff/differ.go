@@ -0,0 +1,210 @@
+package stdiff
+
+import (
+	"context"
+	"sort"
+
+	"gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// ActorDiff describes how an actor differs between the states of two
+// tipsets.  For actors whose state the Differ knows how to decode, the
+// decoded states are included.
+type ActorDiff struct {
+	*state.ActorDiff
+
+	// MinerBefore and MinerAfter are the decoded states of a miner actor.
+	MinerBefore *miner.State `json:",omitempty"`
+	MinerAfter  *miner.State `json:",omitempty"`
+
+	// Channels lists the payment channels that differ, for the payment
+	// broker actor.
+	Channels []*ChannelDiff `json:",omitempty"`
+}
+
+// ChannelDiff describes how a payment channel differs between two states of
+// the payment broker.  Before is nil if the channel was created, After is
+// nil if it was removed.
+type ChannelDiff struct {
+	Payer     string
+	ChannelID string
+	Before    *paymentbroker.PaymentChannel
+	After     *paymentbroker.PaymentChannel
+}
+
+// ChainReadStore is the subset of chain.ReadStore that Differ needs.
+type ChainReadStore interface {
+	GetTipSetAndState(ctx context.Context, tsKey string) (*chain.TipSetAndState, error)
+}
+
+// Differ knows how to compute the difference between the states of two
+// tipsets.
+type Differ struct {
+	// To look up the state roots of tipsets.
+	chainReader ChainReadStore
+	// To load state trees and actor state.
+	cst *hamt.CborIpldStore
+}
+
+// NewDiffer constructs a Differ.
+func NewDiffer(chainReader ChainReadStore, cst *hamt.CborIpldStore) *Differ {
+	return &Differ{chainReader, cst}
+}
+
+// Diff returns the actors whose state differs between the tipsets a and b,
+// ordered by address.  Both tipsets must be in the chain store.
+func (d *Differ) Diff(ctx context.Context, a, b types.SortedCidSet) ([]*ActorDiff, error) {
+	rootA, err := d.stateRoot(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	rootB, err := d.stateRoot(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs, err := state.DiffStateTrees(ctx, d.cst, rootA, rootB)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to diff state trees")
+	}
+
+	out := make([]*ActorDiff, len(diffs))
+	for i, diff := range diffs {
+		out[i] = &ActorDiff{ActorDiff: diff}
+		if err := d.decode(ctx, out[i]); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode state of actor %s", diff.Address)
+		}
+	}
+	return out, nil
+}
+
+func (d *Differ) stateRoot(ctx context.Context, ids types.SortedCidSet) (cid.Cid, error) {
+	tsas, err := d.chainReader.GetTipSetAndState(ctx, ids.String())
+	if err != nil {
+		return cid.Undef, errors.Wrapf(err, "failed to get state of tipset %s", ids.String())
+	}
+	return tsas.TipSetStateRoot, nil
+}
+
+// decode fills in the decoded state of miner and payment broker actors whose
+// head changed.
+func (d *Differ) decode(ctx context.Context, diff *ActorDiff) error {
+	before, after := diff.Before, diff.After
+	if before != nil && after != nil && before.Head.Equals(after.Head) {
+		return nil
+	}
+	code := actorCode(before, after)
+
+	switch {
+	case code.Equals(types.MinerActorCodeCid), code.Equals(types.BootstrapMinerActorCodeCid):
+		var err error
+		if diff.MinerBefore, err = d.minerState(ctx, before); err != nil {
+			return err
+		}
+		diff.MinerAfter, err = d.minerState(ctx, after)
+		return err
+	case code.Equals(types.PaymentBrokerActorCodeCid):
+		channelsBefore, err := d.paymentChannels(ctx, before)
+		if err != nil {
+			return err
+		}
+		channelsAfter, err := d.paymentChannels(ctx, after)
+		if err != nil {
+			return err
+		}
+		diff.Channels = diffChannels(channelsBefore, channelsAfter)
+	}
+	return nil
+}
+
+func actorCode(before, after *actor.Actor) cid.Cid {
+	if after != nil && after.Code.Defined() {
+		return after.Code
+	}
+	if before != nil {
+		return before.Code
+	}
+	return cid.Undef
+}
+
+func (d *Differ) minerState(ctx context.Context, act *actor.Actor) (*miner.State, error) {
+	if act == nil || !act.Head.Defined() {
+		return nil, nil
+	}
+	var st miner.State
+	if err := d.cst.Get(ctx, act.Head, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// paymentChannels returns the payment broker's channels keyed by payer and
+// then by channel id.  The broker's head is a HAMT mapping payers to the
+// root of a HAMT mapping channel ids to channels.
+func (d *Differ) paymentChannels(ctx context.Context, act *actor.Actor) (map[string]map[string]*paymentbroker.PaymentChannel, error) {
+	channels := make(map[string]map[string]*paymentbroker.PaymentChannel)
+	if act == nil || !act.Head.Defined() {
+		return channels, nil
+	}
+
+	err := state.ForEachKV(ctx, d.cst, act.Head, func(payer string, value interface{}) error {
+		var byChannelID cid.Cid
+		if err := state.DecodeHAMTValue(value, &byChannelID); err != nil {
+			return err
+		}
+		channels[payer] = make(map[string]*paymentbroker.PaymentChannel)
+		return state.ForEachKV(ctx, d.cst, byChannelID, func(id string, value interface{}) error {
+			var pc paymentbroker.PaymentChannel
+			if err := state.DecodeHAMTValue(value, &pc); err != nil {
+				return err
+			}
+			channels[payer][id] = &pc
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+func diffChannels(before, after map[string]map[string]*paymentbroker.PaymentChannel) []*ChannelDiff {
+	var out []*ChannelDiff
+	for payer, chans := range before {
+		for id, pc := range chans {
+			if afterPC, ok := after[payer][id]; !ok || !channelsEqual(pc, afterPC) {
+				out = append(out, &ChannelDiff{Payer: payer, ChannelID: id, Before: pc, After: after[payer][id]})
+			}
+		}
+	}
+	for payer, chans := range after {
+		for id, pc := range chans {
+			if _, ok := before[payer][id]; !ok {
+				out = append(out, &ChannelDiff{Payer: payer, ChannelID: id, After: pc})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Payer != out[j].Payer {
+			return out[i].Payer < out[j].Payer
+		}
+		return out[i].ChannelID < out[j].ChannelID
+	})
+	return out
+}
+
+func channelsEqual(a, b *paymentbroker.PaymentChannel) bool {
+	return a.Target == b.Target &&
+		a.Amount.Equal(b.Amount) &&
+		a.AmountRedeemed.Equal(b.AmountRedeemed) &&
+		a.Eol.Equal(b.Eol)
+}
@@ -0,0 +1,96 @@
+package stdiff_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	hamt "gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/plumbing/stdiff"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	"github.com/filecoin-project/go-filecoin/types"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
+)
+
+type fakeChainReadStore struct {
+	roots map[string]cid.Cid
+}
+
+func (f *fakeChainReadStore) GetTipSetAndState(ctx context.Context, tsKey string) (*chain.TipSetAndState, error) {
+	root, ok := f.roots[tsKey]
+	if !ok {
+		return nil, errors.New("no such tipset")
+	}
+	return &chain.TipSetAndState{TipSetStateRoot: root}, nil
+}
+
+func requireMinerActor(require *require.Assertions, cst *hamt.CborIpldStore, power int64) *actor.Actor {
+	head, err := cst.Put(context.Background(), &miner.State{Power: big.NewInt(power)})
+	require.NoError(err)
+	act := miner.NewActor()
+	act.Head = head
+	return act
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+	cst := hamt.NewCborStore()
+	addrGetter := address.NewForTestGetter()
+	minerAddr, acctAddr := addrGetter(), addrGetter()
+
+	rootA, _ := th.RequireMakeStateTree(require, cst, map[address.Address]*actor.Actor{
+		minerAddr: requireMinerActor(require, cst, 1),
+		acctAddr:  th.RequireNewAccountActor(require, types.NewAttoFILFromFIL(100)),
+	})
+	rootB, _ := th.RequireMakeStateTree(require, cst, map[address.Address]*actor.Actor{
+		minerAddr: requireMinerActor(require, cst, 2),
+		acctAddr:  th.RequireNewAccountActor(require, types.NewAttoFILFromFIL(90)),
+	})
+
+	newCid := types.NewCidForTestGetter()
+	tsA := types.NewSortedCidSet(newCid())
+	tsB := types.NewSortedCidSet(newCid())
+	differ := stdiff.NewDiffer(&fakeChainReadStore{roots: map[string]cid.Cid{
+		tsA.String(): rootA,
+		tsB.String(): rootB,
+	}}, cst)
+
+	t.Run("decodes changed miner state", func(t *testing.T) {
+		diffs, err := differ.Diff(ctx, tsA, tsB)
+		require.NoError(err)
+		require.Len(diffs, 2)
+
+		for _, d := range diffs {
+			switch d.Address {
+			case minerAddr:
+				require.NotNil(d.MinerBefore)
+				require.NotNil(d.MinerAfter)
+				assert.Equal(big.NewInt(1), d.MinerBefore.Power)
+				assert.Equal(big.NewInt(2), d.MinerAfter.Power)
+			case acctAddr:
+				assert.Nil(d.MinerBefore)
+				assert.Equal(types.NewAttoFILFromFIL(100), d.Before.Balance)
+				assert.Equal(types.NewAttoFILFromFIL(90), d.After.Balance)
+			default:
+				t.Fatalf("unexpected actor %s in diff", d.Address)
+			}
+		}
+	})
+
+	t.Run("errors for unknown tipsets", func(t *testing.T) {
+		_, err := differ.Diff(ctx, tsA, types.NewSortedCidSet(newCid()))
+		assert.Error(err)
+	})
+}
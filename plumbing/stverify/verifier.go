@@ -0,0 +1,64 @@
+package stverify
+
+import (
+	"context"
+
+	"gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
+	bstore "gx/ipfs/QmRu7tiRnFk9mMPpVECQTBQJqXtmG132jJxA1w9A7TtpBz/go-ipfs-blockstore"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+// Verifier checks the invariants of the states of tipsets in the chain, see
+// consensus.CheckInvariants.
+type Verifier struct {
+	// To get the states of tipsets and of genesis.
+	chainReader chain.ReadStore
+	// To load state trees.
+	cst *hamt.CborIpldStore
+	// For vm storage.
+	bs bstore.Blockstore
+}
+
+// NewVerifier constructs a Verifier.
+func NewVerifier(chainReader chain.ReadStore, cst *hamt.CborIpldStore, bs bstore.Blockstore) *Verifier {
+	return &Verifier{chainReader, cst, bs}
+}
+
+// Verify returns the invariants the state after the tipset referenced by at
+// violates. The supply of the network is that of the genesis state.
+func (v *Verifier) Verify(ctx context.Context, at chain.TipSetRef) ([]*consensus.Violation, error) {
+	supply, err := v.genesisSupply(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tsas, err := v.chainReader.GetTipSetAndStateAt(ctx, at)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get state of tipset")
+	}
+	st, err := state.LoadStateTree(ctx, v.cst, tsas.TipSetStateRoot, builtin.Actors)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load state tree")
+	}
+	return consensus.CheckInvariants(ctx, st, vm.NewStorageMap(v.bs), supply)
+}
+
+func (v *Verifier) genesisSupply(ctx context.Context) (*types.AttoFIL, error) {
+	var genesisHeight uint64
+	tsas, err := v.chainReader.GetTipSetAndStateAt(ctx, chain.TipSetRef{Height: &genesisHeight})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get genesis state")
+	}
+	st, err := state.LoadStateTree(ctx, v.cst, tsas.TipSetStateRoot, builtin.Actors)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load genesis state tree")
+	}
+	return consensus.TotalBalance(ctx, st)
+}
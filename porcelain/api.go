@@ -46,6 +46,16 @@ func (a *API) ChainBlockHeight(ctx context.Context) (*types.BlockHeight, error)
 	return ChainBlockHeight(ctx, a)
 }
 
+// ChainCheckpointSet sets the tipset with the given block cids as the checkpoint
+func (a *API) ChainCheckpointSet(ctx context.Context, ids types.SortedCidSet) error {
+	return ChainCheckpointSet(ctx, a, ids)
+}
+
+// ChainCheckpointGet returns the checkpoint
+func (a *API) ChainCheckpointGet() (ChainCheckpoint, error) {
+	return ChainCheckpointGet(a)
+}
+
 // CreatePayments establishes a payment channel and create multiple payments against it
 func (a *API) CreatePayments(ctx context.Context, config CreatePaymentsParams) (*CreatePaymentsReturn, error) {
 	return CreatePayments(ctx, a, config)
@@ -57,6 +67,11 @@ func (a *API) MessagePoolWait(ctx context.Context, messageCount uint) ([]*types.
 	return MessagePoolWait(ctx, a, messageCount)
 }
 
+// SuggestGasPrice returns the gas price to pay for a message sent without one
+func (a *API) SuggestGasPrice(ctx context.Context) (types.AttoFIL, error) {
+	return SuggestGasPrice(ctx, a)
+}
+
 // MessageSendWithDefaultAddress calls MessageSend but with a default from
 // address if none is provided
 func (a *API) MessageSendWithDefaultAddress(
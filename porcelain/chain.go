@@ -2,7 +2,11 @@ package porcelain
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
@@ -27,3 +31,73 @@ func ChainBlockHeight(ctx context.Context, plumbing chPlumbing) (*types.BlockHei
 	}
 	return types.NewBlockHeight(currentHeight), nil
 }
+
+// ChainCheckpoint is a tipset the node's chain must always include.
+type ChainCheckpoint struct {
+	TipSet types.SortedCidSet
+	Height uint64
+}
+
+// The subset of plumbing used by ChainCheckpointSet
+type chainCheckpointSetPlumbing interface {
+	BlockGet(ctx context.Context, id cid.Cid) (*types.Block, error)
+	ConfigSet(dottedPath string, paramJSON string) error
+}
+
+// ChainCheckpointSet sets the tipset with the given block cids as the node's
+// checkpoint.  The blocks must be in the node's chain store.
+func ChainCheckpointSet(ctx context.Context, plumbing chainCheckpointSetPlumbing, ids types.SortedCidSet) error {
+	if ids.Empty() {
+		return errors.New("checkpoint must contain at least one block")
+	}
+
+	var blks []*types.Block
+	for it := ids.Iter(); !it.Complete(); it.Next() {
+		blk, err := plumbing.BlockGet(ctx, it.Value())
+		if err != nil {
+			return err
+		}
+		blks = append(blks, blk)
+	}
+	ts, err := types.NewTipSet(blks...)
+	if err != nil {
+		return err
+	}
+	height, err := ts.Height()
+	if err != nil {
+		return err
+	}
+
+	// Set both fields in one call so the checkpoint and its height are
+	// never out of sync.
+	chainJSON, err := json.Marshal(map[string]interface{}{
+		"checkpoint":       ids,
+		"checkpointHeight": height,
+	})
+	if err != nil {
+		return err
+	}
+	return plumbing.ConfigSet("chain", string(chainJSON))
+}
+
+// The subset of plumbing used by ChainCheckpointGet
+type chainCheckpointGetPlumbing interface {
+	ConfigGet(dottedPath string) (interface{}, error)
+}
+
+// ChainCheckpointGet returns the node's checkpoint.  The checkpoint's tipset
+// is empty if none is set.
+func ChainCheckpointGet(plumbing chainCheckpointGetPlumbing) (ChainCheckpoint, error) {
+	ids, err := plumbing.ConfigGet("chain.checkpoint")
+	if err != nil {
+		return ChainCheckpoint{}, err
+	}
+	height, err := plumbing.ConfigGet("chain.checkpointHeight")
+	if err != nil {
+		return ChainCheckpoint{}, err
+	}
+	return ChainCheckpoint{
+		TipSet: ids.(types.SortedCidSet),
+		Height: height.(uint64),
+	}, nil
+}
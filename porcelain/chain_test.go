@@ -0,0 +1,98 @@
+package porcelain_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/plumbing/cfg"
+	"github.com/filecoin-project/go-filecoin/porcelain"
+	"github.com/filecoin-project/go-filecoin/repo"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+type fakeChainCheckpointPlumbing struct {
+	config *cfg.Config
+	blocks map[cid.Cid]*types.Block
+}
+
+func newFakeChainCheckpointPlumbing(blks ...*types.Block) *fakeChainCheckpointPlumbing {
+	blocks := make(map[cid.Cid]*types.Block)
+	for _, blk := range blks {
+		blocks[blk.Cid()] = blk
+	}
+	return &fakeChainCheckpointPlumbing{
+		config: cfg.NewConfig(repo.NewInMemoryRepo()),
+		blocks: blocks,
+	}
+}
+
+func (fccp *fakeChainCheckpointPlumbing) BlockGet(ctx context.Context, id cid.Cid) (*types.Block, error) {
+	blk, ok := fccp.blocks[id]
+	if !ok {
+		return nil, errors.New("block not found")
+	}
+	return blk, nil
+}
+
+func (fccp *fakeChainCheckpointPlumbing) ConfigGet(dottedPath string) (interface{}, error) {
+	return fccp.config.Get(dottedPath)
+}
+
+func (fccp *fakeChainCheckpointPlumbing) ConfigSet(dottedPath string, paramJSON string) error {
+	return fccp.config.Set(dottedPath, paramJSON)
+}
+
+func TestChainCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	t.Run("get returns an empty checkpoint by default", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		checkpoint, err := porcelain.ChainCheckpointGet(newFakeChainCheckpointPlumbing())
+		require.NoError(err)
+		assert.True(checkpoint.TipSet.Empty())
+		assert.Equal(uint64(0), checkpoint.Height)
+	})
+
+	t.Run("set records the tipset and its height", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		blk1 := &types.Block{Height: 7}
+		blk2 := &types.Block{Height: 7, Nonce: 1}
+		fp := newFakeChainCheckpointPlumbing(blk1, blk2)
+		ids := types.NewSortedCidSet(blk1.Cid(), blk2.Cid())
+
+		require.NoError(porcelain.ChainCheckpointSet(context.Background(), fp, ids))
+
+		checkpoint, err := porcelain.ChainCheckpointGet(fp)
+		require.NoError(err)
+		assert.True(ids.Equals(checkpoint.TipSet))
+		assert.Equal(uint64(7), checkpoint.Height)
+	})
+
+	t.Run("set fails for blocks that are not a tipset", func(t *testing.T) {
+		assert := assert.New(t)
+
+		blk1 := &types.Block{Height: 7}
+		blk2 := &types.Block{Height: 8}
+		fp := newFakeChainCheckpointPlumbing(blk1, blk2)
+
+		assert.Error(porcelain.ChainCheckpointSet(context.Background(), fp, types.NewSortedCidSet(blk1.Cid(), blk2.Cid())))
+	})
+
+	t.Run("set fails for unknown blocks", func(t *testing.T) {
+		assert := assert.New(t)
+
+		blk := &types.Block{Height: 7}
+		fp := newFakeChainCheckpointPlumbing()
+
+		assert.Error(porcelain.ChainCheckpointSet(context.Background(), fp, types.NewSortedCidSet(blk.Cid())))
+	})
+}
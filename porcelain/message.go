@@ -7,6 +7,7 @@ import (
 	logging "gx/ipfs/QmbkT7eMTyXfpeyB3ZMxxcxg7XH8t6uXp49jqzz4HB7BGF/go-log"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/plumbing/msg"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
@@ -15,15 +16,52 @@ var ErrNoDefaultFromAddress = errors.New("unable to determine a default address
 
 var log = logging.Logger("porcelain") // nolint: deadcode
 
+// sgpAPI is the subset of the plumbing.API that SuggestGasPrice uses.
+type sgpAPI interface {
+	ConfigGet(dottedPath string) (interface{}, error)
+	MessageGasPriceStats(ctx context.Context) (*msg.GasPriceStats, error)
+}
+
+// SuggestGasPrice returns the gas price to pay for a message sent without
+// one: the median gas price of recently included messages, but at least the
+// configured mpool.minGasPrice. If recent blocks include no messages it is
+// mpool.minGasPrice.
+func SuggestGasPrice(ctx context.Context, plumbing sgpAPI) (types.AttoFIL, error) {
+	ret, err := plumbing.ConfigGet("mpool.minGasPrice")
+	if err != nil {
+		return types.AttoFIL{}, errors.Wrap(err, "failed to read mpool.minGasPrice")
+	}
+	minPrice, ok := ret.(*types.AttoFIL)
+	if !ok || minPrice == nil {
+		return types.AttoFIL{}, errors.New("mpool.minGasPrice is not set")
+	}
+
+	stats, err := plumbing.MessageGasPriceStats(ctx)
+	if err != nil {
+		return types.AttoFIL{}, err
+	}
+	if stats.Samples == 0 || stats.P50.LessThan(minPrice) {
+		return *minPrice, nil
+	}
+	return stats.P50, nil
+}
+
 // mswdaAPI is the subset of the plumbing.API that MessageSendWithDefaultAddress uses.
 type mswdaAPI interface {
+	sgpAPI
 	GetAndMaybeSetDefaultSenderAddress() (address.Address, error)
+	MessageEstimateGasLimit(ctx context.Context, from, to address.Address, value *types.AttoFIL, method string, params ...interface{}) (types.GasUnits, error)
 	MessageSend(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
 }
 
 // MessageSendWithDefaultAddress calls MessageSend but with a default from
 // address if none is provided. If you don't need a default address provided,
 // use MessageSend instead.
+//
+// If gasPrice is the zero value types.AttoFIL{} it is set by SuggestGasPrice.
+// If gasLimit is zero it is estimated by
+// applying the message on top of the sender's pending messages, and an error is
+// returned if the message would fail.
 func MessageSendWithDefaultAddress(
 	ctx context.Context,
 	plumbing mswdaAPI,
@@ -44,6 +82,21 @@ func MessageSendWithDefaultAddress(
 		from = ret
 	}
 
+	if gasPrice == (types.AttoFIL{}) {
+		suggested, err := SuggestGasPrice(ctx, plumbing)
+		if err != nil {
+			return cid.Undef, errors.Wrap(err, "failed to suggest a gas price")
+		}
+		gasPrice = suggested
+	}
+	if gasLimit == types.NewGasUnits(0) {
+		estimate, err := plumbing.MessageEstimateGasLimit(ctx, from, to, value, method, params...)
+		if err != nil {
+			return cid.Undef, errors.Wrap(err, "failed to estimate gas limit")
+		}
+		gasLimit = estimate
+	}
+
 	return plumbing.MessageSend(ctx, from, to, value, gasPrice, gasLimit, method, params...)
 }
 
@@ -1,12 +1,18 @@
 package porcelain_test
 
 import (
+	"context"
+	"errors"
 	"testing"
 
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/plumbing/cfg"
+	"github.com/filecoin-project/go-filecoin/plumbing/msg"
 	"github.com/filecoin-project/go-filecoin/porcelain"
 	"github.com/filecoin-project/go-filecoin/repo"
+	"github.com/filecoin-project/go-filecoin/types"
 	"github.com/filecoin-project/go-filecoin/wallet"
 	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
 	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
@@ -85,6 +91,155 @@ func TestGetAndMaybeSetDefaultSenderAddress(t *testing.T) {
 	})
 }
 
+type fakeMessageSendWithDefaultAddressPlumbing struct {
+	defaultAddr   address.Address
+	estimate      types.GasUnits
+	estimateErr   error
+	estimateCalls int
+	estimateValue *types.AttoFIL
+	priceStats    *msg.GasPriceStats
+	minGasPrice   *types.AttoFIL
+
+	sent     bool
+	from     address.Address
+	gasPrice types.AttoFIL
+	gasLimit types.GasUnits
+}
+
+func (fp *fakeMessageSendWithDefaultAddressPlumbing) ConfigGet(dottedPath string) (interface{}, error) {
+	if dottedPath != "mpool.minGasPrice" {
+		return nil, errors.New("unexpected config key")
+	}
+	return fp.minGasPrice, nil
+}
+
+func (fp *fakeMessageSendWithDefaultAddressPlumbing) GetAndMaybeSetDefaultSenderAddress() (address.Address, error) {
+	return fp.defaultAddr, nil
+}
+
+func (fp *fakeMessageSendWithDefaultAddressPlumbing) MessageEstimateGasLimit(ctx context.Context, from, to address.Address, value *types.AttoFIL, method string, params ...interface{}) (types.GasUnits, error) {
+	fp.estimateCalls++
+	fp.estimateValue = value
+	return fp.estimate, fp.estimateErr
+}
+
+func (fp *fakeMessageSendWithDefaultAddressPlumbing) MessageGasPriceStats(ctx context.Context) (*msg.GasPriceStats, error) {
+	return fp.priceStats, nil
+}
+
+func (fp *fakeMessageSendWithDefaultAddressPlumbing) MessageSend(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error) {
+	fp.sent = true
+	fp.from = from
+	fp.gasPrice = gasPrice
+	fp.gasLimit = gasLimit
+	return types.SomeCid(), nil
+}
+
+func TestMessageSendWithDefaultAddress(t *testing.T) {
+	t.Parallel()
+
+	newAddr := address.NewForTestGetter()
+	newPlumbing := func() *fakeMessageSendWithDefaultAddressPlumbing {
+		return &fakeMessageSendWithDefaultAddressPlumbing{
+			defaultAddr: newAddr(),
+			estimate:    types.NewGasUnits(120),
+			priceStats:  &msg.GasPriceStats{Samples: 3, P50: *types.NewAttoFILFromFIL(2)},
+			minGasPrice: types.NewAttoFILFromFIL(1),
+		}
+	}
+	value := types.NewAttoFILFromFIL(5)
+
+	t.Run("fills in the sender, gas price and gas limit", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		fp := newPlumbing()
+		_, err := porcelain.MessageSendWithDefaultAddress(context.Background(), fp, address.Address{}, newAddr(), value, types.AttoFIL{}, types.NewGasUnits(0), "method")
+		require.NoError(err)
+
+		require.True(fp.sent)
+		assert.Equal(fp.defaultAddr, fp.from)
+		assert.Equal(*types.NewAttoFILFromFIL(2), fp.gasPrice)
+		assert.Equal(types.NewGasUnits(120), fp.gasLimit)
+		assert.Equal(value, fp.estimateValue)
+	})
+
+	t.Run("keeps the given gas price and gas limit", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		fp := newPlumbing()
+		from := newAddr()
+		_, err := porcelain.MessageSendWithDefaultAddress(context.Background(), fp, from, newAddr(), value, *types.NewAttoFILFromFIL(7), types.NewGasUnits(300), "method")
+		require.NoError(err)
+
+		require.True(fp.sent)
+		assert.Equal(from, fp.from)
+		assert.Equal(*types.NewAttoFILFromFIL(7), fp.gasPrice)
+		assert.Equal(types.NewGasUnits(300), fp.gasLimit)
+		assert.Equal(0, fp.estimateCalls)
+	})
+
+	t.Run("returns the error of the gas limit estimation", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fp := newPlumbing()
+		fp.estimateErr = errors.New("message would fail")
+		_, err := porcelain.MessageSendWithDefaultAddress(context.Background(), fp, address.Address{}, newAddr(), value, types.AttoFIL{}, types.NewGasUnits(0), "method")
+		assert.Error(err)
+		assert.Contains(err.Error(), "message would fail")
+		assert.False(fp.sent)
+	})
+}
+
+func TestSuggestGasPrice(t *testing.T) {
+	t.Parallel()
+
+	newPlumbing := func(stats *msg.GasPriceStats) *fakeMessageSendWithDefaultAddressPlumbing {
+		return &fakeMessageSendWithDefaultAddressPlumbing{
+			priceStats:  stats,
+			minGasPrice: types.NewAttoFILFromFIL(2),
+		}
+	}
+
+	t.Run("suggests the median price", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		fp := newPlumbing(&msg.GasPriceStats{Samples: 3, P50: *types.NewAttoFILFromFIL(3)})
+		price, err := porcelain.SuggestGasPrice(context.Background(), fp)
+		require.NoError(err)
+		assert.Equal(*types.NewAttoFILFromFIL(3), price)
+	})
+
+	t.Run("suggests at least the minimum price", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		fp := newPlumbing(&msg.GasPriceStats{Samples: 3, P50: *types.NewAttoFILFromFIL(1)})
+		price, err := porcelain.SuggestGasPrice(context.Background(), fp)
+		require.NoError(err)
+		assert.Equal(*types.NewAttoFILFromFIL(2), price)
+	})
+
+	t.Run("falls back to the minimum price without recent messages", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		fp := newPlumbing(&msg.GasPriceStats{Samples: 0})
+		price, err := porcelain.SuggestGasPrice(context.Background(), fp)
+		require.NoError(err)
+		assert.Equal(*types.NewAttoFILFromFIL(2), price)
+	})
+
+	t.Run("fails without a minimum price", func(t *testing.T) {
+		fp := newPlumbing(&msg.GasPriceStats{Samples: 0})
+		fp.minGasPrice = nil
+		_, err := porcelain.SuggestGasPrice(context.Background(), fp)
+		assert.Error(t, err)
+	})
+}
+
 func isInList(needle address.Address, haystack []address.Address) bool {
 	for _, a := range haystack {
 		if a == needle {
@@ -3,7 +3,6 @@ package porcelain
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"math/big"
 
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
@@ -21,7 +20,6 @@ import (
 
 // mpcAPI is the subset of the plumbing.API that MinerPreviewCreate uses.
 type mpcAPI interface {
-	ConfigGet(dottedPath string) (interface{}, error)
 	GetAndMaybeSetDefaultSenderAddress() (address.Address, error)
 	MessagePreview(ctx context.Context, from, to address.Address, method string, params ...interface{}) (types.GasUnits, error)
 	NetworkGetPeerID() peer.ID
@@ -48,10 +46,6 @@ func MinerPreviewCreate(
 		pid = plumbing.NetworkGetPeerID()
 	}
 
-	if _, err := plumbing.ConfigGet("mining.minerAddress"); err != nil {
-		return types.NewGasUnits(0), fmt.Errorf("can only have one miner per node")
-	}
-
 	ctx = log.Start(ctx, "Node.CreateMiner")
 	defer func() {
 		log.FinishWithErr(ctx, err)
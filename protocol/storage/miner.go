@@ -298,15 +298,19 @@ func (sm *Miner) getPaymentChannel(ctx context.Context, p *DealProposal) (*payme
 
 	payer := p.Payment.Payer
 
-	ret, _, err := sm.porcelainAPI.MessageQuery(ctx, address.Address{}, address.PaymentBrokerAddress, "ls", payer)
+	ret, sig, err := sm.porcelainAPI.MessageQuery(ctx, address.Address{}, address.PaymentBrokerAddress, "ls", payer)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error getting payment channel for payer")
 	}
 
-	var channels map[string]*paymentbroker.PaymentChannel
-	if err := cbor.DecodeInto(ret[0], &channels); err != nil {
+	val, err := abi.Deserialize(ret[0], sig.Return[0])
+	if err != nil {
 		return nil, errors.Wrap(err, "Could not decode payment channels for payer")
 	}
+	channels, ok := val.Val.(map[string]*paymentbroker.PaymentChannel)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type for payment channels of payer %s: %T", payer.String(), val.Val)
+	}
 	channel, ok := channels[p.Payment.Channel.KeyString()]
 	if !ok {
 		return nil, fmt.Errorf("could not find payment channel for payer %s and id %s", payer.String(), p.Payment.Channel.KeyString())
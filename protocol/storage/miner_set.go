@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"sync"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	inet "gx/ipfs/QmTGxDz2CjBucFzPNTiWwzQmTWdrBnzqbqrMucDYMsjuPb/go-libp2p-net"
+	"gx/ipfs/Qmd52WKRSwrBK5gUaJKawryZQ5by6UbNB8KVW2Zy6JtbyW/go-libp2p-host"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	cbu "github.com/filecoin-project/go-filecoin/cborutil"
+)
+
+// MinerSet serves the storage deal protocol for several Miners on one host,
+// handing each proposal to the Miner it is addressed to.
+type MinerSet struct {
+	mu     sync.Mutex
+	miners map[address.Address]*Miner
+}
+
+// NewMinerSet returns a MinerSet serving miners on h.  It replaces the stream
+// handlers NewMiner set for each of them.
+func NewMinerSet(h host.Host, miners ...*Miner) *MinerSet {
+	ms := &MinerSet{miners: make(map[address.Address]*Miner)}
+	for _, m := range miners {
+		ms.miners[m.minerAddr] = m
+	}
+
+	h.SetStreamHandler(makeDealProtocol, ms.handleMakeDeal)
+	h.SetStreamHandler(queryDealProtocol, ms.handleQueryDeal)
+
+	return ms
+}
+
+// Get returns the Miner for the miner actor at addr.
+func (ms *MinerSet) Get(addr address.Address) (*Miner, bool) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	m, ok := ms.miners[addr]
+	return m, ok
+}
+
+func (ms *MinerSet) handleMakeDeal(s inet.Stream) {
+	defer s.Close() // nolint: errcheck
+
+	var proposal DealProposal
+	if err := cbu.NewMsgReader(s).ReadMsg(&proposal); err != nil {
+		log.Errorf("received invalid proposal: %s", err)
+		return
+	}
+
+	ctx := context.Background()
+	resp, err := ms.receiveStorageProposal(ctx, &proposal)
+	if err != nil {
+		log.Errorf("failed to process proposal: %s", err)
+		return
+	}
+
+	if err := cbu.NewMsgWriter(s).WriteMsg(resp); err != nil {
+		log.Errorf("failed to write proposal response: %s", err)
+	}
+}
+
+// receiveStorageProposal hands p to the Miner it is addressed to.
+func (ms *MinerSet) receiveStorageProposal(ctx context.Context, p *DealProposal) (*DealResponse, error) {
+	m, ok := ms.Get(p.MinerAddress)
+	if !ok {
+		return &DealResponse{
+			State:   Rejected,
+			Message: "no such miner on this node: " + p.MinerAddress.String(),
+		}, nil
+	}
+	return m.receiveStorageProposal(ctx, p)
+}
+
+func (ms *MinerSet) handleQueryDeal(s inet.Stream) {
+	defer s.Close() // nolint: errcheck
+
+	var q queryRequest
+	if err := cbu.NewMsgReader(s).ReadMsg(&q); err != nil {
+		log.Errorf("received invalid query: %s", err)
+		return
+	}
+
+	ctx := context.Background()
+	resp := ms.Query(ctx, q.Cid)
+
+	if err := cbu.NewMsgWriter(s).WriteMsg(resp); err != nil {
+		log.Errorf("failed to write query response: %s", err)
+	}
+}
+
+// Query returns the response to the deal with proposal cid c from whichever
+// Miner has it.
+func (ms *MinerSet) Query(ctx context.Context, c cid.Cid) *DealResponse {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, m := range ms.miners {
+		if resp := m.Query(ctx, c); resp.State != Unknown {
+			return resp
+		}
+	}
+	return &DealResponse{
+		State:   Unknown,
+		Message: "no such deal",
+	}
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestMinerSet(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	addrGetter := address.NewForTestGetter()
+	newAcceptingMiner := func(addr address.Address) *Miner {
+		return &Miner{
+			minerAddr: addr,
+			deals:     make(map[cid.Cid]*storageDeal),
+			proposalAcceptor: func(ctx context.Context, m *Miner, p *DealProposal) (*DealResponse, error) {
+				resp := &DealResponse{State: Accepted, ProposalCid: p.PieceRef}
+				m.deals[p.PieceRef] = &storageDeal{Proposal: p, Response: resp}
+				return resp, nil
+			},
+		}
+	}
+	m1, m2 := newAcceptingMiner(addrGetter()), newAcceptingMiner(addrGetter())
+	ms := &MinerSet{miners: map[address.Address]*Miner{m1.minerAddr: m1, m2.minerAddr: m2}}
+
+	porcelainAPI := newMinerTestPorcelain()
+	m2.porcelainAPI = porcelainAPI
+	m2.minerOwnerAddr = porcelainAPI.targetAddress
+	proposal := testDealProposal(porcelainAPI, VoucherInterval, 1773, porcelainAPI.targetAddress)
+	proposal.PieceRef = types.SomeCid()
+	proposal.MinerAddress = m2.minerAddr
+
+	resp, err := ms.receiveStorageProposal(context.Background(), proposal)
+	require.NoError(err)
+	assert.Equal(Accepted, resp.State)
+	assert.Len(m1.deals, 0)
+	assert.Len(m2.deals, 1)
+
+	assert.Equal(Accepted, ms.Query(context.Background(), proposal.PieceRef).State)
+	assert.Equal(Unknown, ms.Query(context.Background(), types.SomeCid()).State)
+
+	proposal.MinerAddress = addrGetter()
+	resp, err = ms.receiveStorageProposal(context.Background(), proposal)
+	require.NoError(err)
+	assert.Equal(Rejected, resp.State)
+}
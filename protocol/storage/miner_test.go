@@ -6,7 +6,7 @@ import (
 
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 
-	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/exec"
@@ -372,11 +372,12 @@ func (mtp *minerTestPorcelain) MessageQuery(ctx context.Context, optFrom, to add
 		}
 	}
 
-	channelsBytes, err := actor.MarshalStorage(channels)
+	sig := (&paymentbroker.Actor{}).Exports()["ls"]
+	channelsBytes, err := (&abi.Value{Type: sig.Return[0], Val: channels}).Serialize()
 	if err != nil {
 		panic(err)
 	}
-	return [][]byte{channelsBytes}, nil, nil
+	return [][]byte{channelsBytes}, sig, nil
 }
 
 func (mtp *minerTestPorcelain) ConfigGet(dottedPath string) (interface{}, error) {
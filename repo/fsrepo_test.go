@@ -47,6 +47,7 @@ const (
 	"mining": {
 		"minerAddress": "",
 		"blockSignerAddress": "",
+		"miners": [],
 		"autoSealIntervalSeconds": 120,
 		"storagePrice": "0"
 	},
@@ -58,6 +59,21 @@ const (
 		"beatPeriod": "3s",
 		"reconnectPeriod": "10s",
 		"nickname": ""
+	},
+	"chain": {
+		"maxReorgDepth": 0,
+		"checkpoint": null,
+		"checkpointHeight": 0,
+		"checkInvariants": false
+	},
+	"mpool": {
+		"maxPoolSize": 10000,
+		"maxPerSender": 256,
+		"maxNonceGap": 100,
+		"maxMessageAgeSeconds": 21600,
+		"replaceByFeePercent": 10,
+		"stuckAfterTipSets": 10,
+		"minGasPrice": "0"
 	}
 }`
 )
@@ -2,6 +2,8 @@ package state
 
 import (
 	"context"
+	"sort"
+
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 
 	"github.com/filecoin-project/go-filecoin/actor"
@@ -70,3 +72,32 @@ func (t *CachedTree) Commit(ctx context.Context) error {
 	t.cache = make(map[address.Address]*actor.Actor)
 	return nil
 }
+
+// Changes returns the cached actors that differ from the underlying tree,
+// ordered by address. Actors created in the cache are reported as added.
+func (t *CachedTree) Changes(ctx context.Context) ([]*ActorDiff, error) {
+	var out []*ActorDiff
+	for addr, after := range t.cache {
+		before, err := t.st.GetActor(ctx, addr)
+		if IsActorNotFoundError(err) {
+			out = append(out, &ActorDiff{Address: addr, After: after})
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		beforeCid, err := before.Cid()
+		if err != nil {
+			return nil, err
+		}
+		afterCid, err := after.Cid()
+		if err != nil {
+			return nil, err
+		}
+		if !beforeCid.Equals(afterCid) {
+			out = append(out, &ActorDiff{Address: addr, Before: before, After: after})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Address.String() < out[j].Address.String() })
+	return out, nil
+}
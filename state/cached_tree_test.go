@@ -111,6 +111,47 @@ func TestCachedStateGetOrCreate(t *testing.T) {
 	require.Equal("actor not found", err.Error())
 }
 
+func TestCachedStateChanges(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	cst := hamt.NewCborStore()
+	ctx := context.Background()
+
+	underlying := NewEmptyStateTree(cst)
+	tree := NewCachedStateTree(underlying)
+
+	addrGetter := address.NewForTestGetter()
+	addr1, addr2, addr3 := addrGetter(), addrGetter(), addrGetter()
+	require.NoError(underlying.SetActor(ctx, addr1, actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(10))))
+	require.NoError(underlying.SetActor(ctx, addr2, actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(10))))
+
+	// addr1 is changed, addr2 only read and addr3 created.
+	act1, err := tree.GetActor(ctx, addr1)
+	require.NoError(err)
+	act1.IncNonce()
+	_, err = tree.GetActor(ctx, addr2)
+	require.NoError(err)
+	act3, err := tree.GetOrCreateActor(ctx, addr3, func() (*actor.Actor, error) {
+		return &actor.Actor{}, nil
+	})
+	require.NoError(err)
+
+	changes, err := tree.Changes(ctx)
+	require.NoError(err)
+	require.Len(changes, 2)
+
+	byAddr := make(map[address.Address]*ActorDiff)
+	for _, c := range changes {
+		byAddr[c.Address] = c
+	}
+	require.Contains(byAddr, addr1)
+	assert.Equal(uint64(0), uint64(byAddr[addr1].Before.Nonce))
+	assert.Equal(act1, byAddr[addr1].After)
+	require.Contains(byAddr, addr3)
+	assert.True(byAddr[addr3].Added())
+	assert.Equal(act3, byAddr[addr3].After)
+}
+
 func requireCid(t *testing.T, data string) cid.Cid {
 	prefix := cid.V1Builder{Codec: cid.Raw, MhType: types.DefaultHashFunction}
 	id, err := prefix.Sum([]byte(data))
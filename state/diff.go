@@ -0,0 +1,168 @@
+package state
+
+import (
+	"context"
+	"sort"
+
+	"gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+)
+
+// ActorDiff describes how a single actor differs between two state trees.
+// Before is nil if the actor was added, After is nil if it was removed.
+type ActorDiff struct {
+	Address address.Address
+	Before  *actor.Actor
+	After   *actor.Actor
+}
+
+// Added is true if the actor only exists in the second tree.
+func (d *ActorDiff) Added() bool {
+	return d.Before == nil
+}
+
+// Removed is true if the actor only exists in the first tree.
+func (d *ActorDiff) Removed() bool {
+	return d.After == nil
+}
+
+// DiffStateTrees returns the actors that differ between the state trees with
+// roots a and b, ordered by address.  Subtrees of the underlying HAMT that
+// the two trees share are skipped without being loaded, so the cost of a
+// diff is proportional to the size of the change rather than the size of
+// the state.
+func DiffStateTrees(ctx context.Context, store *hamt.CborIpldStore, a, b cid.Cid) ([]*ActorDiff, error) {
+	if a.Equals(b) {
+		return nil, nil
+	}
+	nodeA, err := hamt.LoadNode(ctx, store, a)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load state tree %s", a)
+	}
+	nodeB, err := hamt.LoadNode(ctx, store, b)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load state tree %s", b)
+	}
+
+	diffs := make(map[string]*ActorDiff)
+	if err := diffNodes(ctx, store, nodeA, nodeB, diffs); err != nil {
+		return nil, err
+	}
+
+	out := make([]*ActorDiff, 0, len(diffs))
+	for _, d := range diffs {
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Address.String() < out[j].Address.String() })
+	return out, nil
+}
+
+// diffNodes records the differences between two HAMT nodes at the same
+// depth.  A node's pointers are stored densely in the order of the set bits
+// of its bitfield, so pointers are paired up by walking both bitfields.
+func diffNodes(ctx context.Context, store *hamt.CborIpldStore, a, b *hamt.Node, diffs map[string]*ActorDiff) error {
+	width := a.Bitfield.BitLen()
+	if b.Bitfield.BitLen() > width {
+		width = b.Bitfield.BitLen()
+	}
+
+	var ia, ib int
+	for i := 0; i < width; i++ {
+		var pa, pb *hamt.Pointer
+		if a.Bitfield.Bit(i) == 1 {
+			pa = a.Pointers[ia]
+			ia++
+		}
+		if b.Bitfield.Bit(i) == 1 {
+			pb = b.Pointers[ib]
+			ib++
+		}
+		if err := diffPointers(ctx, store, pa, pb, diffs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffPointers records the differences between two pointers in the same
+// slot of a HAMT node.  Either pointer may be nil if the slot is empty.
+func diffPointers(ctx context.Context, store *hamt.CborIpldStore, a, b *hamt.Pointer, diffs map[string]*ActorDiff) error {
+	if a != nil && b != nil && a.Link.Defined() && b.Link.Defined() {
+		if a.Link.Equals(b.Link) {
+			return nil
+		}
+		nodeA, err := hamt.LoadNode(ctx, store, a.Link)
+		if err != nil {
+			return err
+		}
+		nodeB, err := hamt.LoadNode(ctx, store, b.Link)
+		if err != nil {
+			return err
+		}
+		return diffNodes(ctx, store, nodeA, nodeB, diffs)
+	}
+
+	// The slot holds actors inline on at least one side, so there is no
+	// shared structure left to exploit.  Compare the actors directly.
+	before, err := pointerActors(ctx, store, a)
+	if err != nil {
+		return err
+	}
+	after, err := pointerActors(ctx, store, b)
+	if err != nil {
+		return err
+	}
+
+	for addr, act := range before {
+		diffs[addr.String()] = &ActorDiff{Address: addr, Before: act}
+	}
+	for addr, act := range after {
+		d, ok := diffs[addr.String()]
+		if !ok {
+			diffs[addr.String()] = &ActorDiff{Address: addr, After: act}
+			continue
+		}
+		same, err := actorsEqual(d.Before, act)
+		if err != nil {
+			return err
+		}
+		if same {
+			delete(diffs, addr.String())
+			continue
+		}
+		d.After = act
+	}
+	return nil
+}
+
+// pointerActors returns all actors stored under a pointer.
+func pointerActors(ctx context.Context, store *hamt.CborIpldStore, p *hamt.Pointer) (map[address.Address]*actor.Actor, error) {
+	actors := make(map[address.Address]*actor.Actor)
+	if p == nil {
+		return actors, nil
+	}
+	collect := func(addr address.Address, act *actor.Actor) error {
+		actors[addr] = act
+		return nil
+	}
+	if err := forEachActor(ctx, store, &hamt.Node{Pointers: []*hamt.Pointer{p}}, collect); err != nil {
+		return nil, err
+	}
+	return actors, nil
+}
+
+func actorsEqual(a, b *actor.Actor) (bool, error) {
+	ca, err := a.Cid()
+	if err != nil {
+		return false, err
+	}
+	cb, err := b.Cid()
+	if err != nil {
+		return false, err
+	}
+	return ca.Equals(cb), nil
+}
@@ -0,0 +1,83 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestDiffStateTrees(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+	cst := hamt.NewCborStore()
+	addrGetter := address.NewForTestGetter()
+
+	// Enough actors that the HAMT has linked subtrees.
+	tree := NewEmptyStateTree(cst)
+	var addrs []address.Address
+	for i := 0; i < 1000; i++ {
+		addr := addrGetter()
+		addrs = append(addrs, addr)
+		require.NoError(tree.SetActor(ctx, addr, actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(uint64(i)))))
+	}
+	rootA, err := tree.Flush(ctx)
+	require.NoError(err)
+
+	changed, err := tree.GetActor(ctx, addrs[10])
+	require.NoError(err)
+	changed.IncNonce()
+	require.NoError(tree.SetActor(ctx, addrs[10], changed))
+	added := addrGetter()
+	require.NoError(tree.SetActor(ctx, added, actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(1))))
+	rootB, err := tree.Flush(ctx)
+	require.NoError(err)
+
+	t.Run("identical roots have no diff", func(t *testing.T) {
+		diffs, err := DiffStateTrees(ctx, cst, rootA, rootA)
+		require.NoError(err)
+		assert.Empty(diffs)
+	})
+
+	t.Run("changed and added actors", func(t *testing.T) {
+		diffs, err := DiffStateTrees(ctx, cst, rootA, rootB)
+		require.NoError(err)
+		require.Len(diffs, 2)
+
+		byAddr := make(map[address.Address]*ActorDiff)
+		for _, d := range diffs {
+			byAddr[d.Address] = d
+		}
+
+		require.Contains(byAddr, addrs[10])
+		assert.False(byAddr[addrs[10]].Added())
+		assert.False(byAddr[addrs[10]].Removed())
+		assert.Equal(types.Uint64(0), byAddr[addrs[10]].Before.Nonce)
+		assert.Equal(types.Uint64(1), byAddr[addrs[10]].After.Nonce)
+
+		require.Contains(byAddr, added)
+		assert.True(byAddr[added].Added())
+	})
+
+	t.Run("reversed diff reports removals", func(t *testing.T) {
+		diffs, err := DiffStateTrees(ctx, cst, rootB, rootA)
+		require.NoError(err)
+		require.Len(diffs, 2)
+
+		for _, d := range diffs {
+			if d.Address == added {
+				assert.True(d.Removed())
+			} else {
+				assert.Equal(addrs[10], d.Address)
+				assert.Equal(types.Uint64(1), d.Before.Nonce)
+			}
+		}
+	})
+}
@@ -186,28 +186,44 @@ func (t *tree) ForEachActor(ctx context.Context, walkFn ActorWalkFn) error {
 }
 
 func forEachActor(ctx context.Context, cst *hamt.CborIpldStore, nd *hamt.Node, walkFn ActorWalkFn) error {
-	for _, p := range nd.Pointers {
-		for _, kv := range p.KVs {
-			var a actor.Actor
-			if err := hackTransferObject(kv.Value, &a); err != nil {
-				return err
-			}
+	return forEachNodeKV(ctx, cst, nd, func(key string, value interface{}) error {
+		var a actor.Actor
+		if err := DecodeHAMTValue(value, &a); err != nil {
+			return err
+		}
 
-			addr, err := address.NewFromString(kv.Key)
-			if err != nil {
-				return err
-			}
+		addr, err := address.NewFromString(key)
+		if err != nil {
+			return err
+		}
+
+		return walkFn(addr, &a)
+	})
+}
 
-			if err := walkFn(addr, &a); err != nil {
+// KVWalkFn is a visitor function for the entries of a HAMT.  value is the
+// generically decoded entry; DecodeHAMTValue decodes it into a concrete type.
+type KVWalkFn func(key string, value interface{}) error
+
+// ForEachKV calls walkFn for each entry of the HAMT rooted at root, such as
+// the state tree or a HAMT held in an actor's state.
+func ForEachKV(ctx context.Context, cst *hamt.CborIpldStore, root cid.Cid, walkFn KVWalkFn) error {
+	nd, err := hamt.LoadNode(ctx, cst, root)
+	if err != nil {
+		return err
+	}
+	return forEachNodeKV(ctx, cst, nd, walkFn)
+}
+
+func forEachNodeKV(ctx context.Context, cst *hamt.CborIpldStore, nd *hamt.Node, walkFn KVWalkFn) error {
+	for _, p := range nd.Pointers {
+		for _, kv := range p.KVs {
+			if err := walkFn(kv.Key, kv.Value); err != nil {
 				return err
 			}
 		}
 		if p.Link.Defined() {
-			n, err := hamt.LoadNode(context.Background(), cst, p.Link)
-			if err != nil {
-				return err
-			}
-			if err := forEachActor(ctx, cst, n, walkFn); err != nil {
+			if err := ForEachKV(ctx, cst, p.Link, walkFn); err != nil {
 				return err
 			}
 		}
@@ -215,6 +231,11 @@ func forEachActor(ctx context.Context, cst *hamt.CborIpldStore, nd *hamt.Node, w
 	return nil
 }
 
+// DecodeHAMTValue decodes a value visited by ForEachKV into to.
+func DecodeHAMTValue(value, to interface{}) error {
+	return hackTransferObject(value, to)
+}
+
 // DebugStateTree prints a debug version of the current state tree.
 func DebugStateTree(t Tree) {
 	st, ok := t.(*tree)
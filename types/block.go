@@ -2,12 +2,14 @@ package types
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"sort"
 
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 	node "gx/ipfs/QmRL22E4paat7ky7vx9MLpR97JHHbFPrg3ytFQw6qp1y1s/go-ipld-format"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 	cbor "gx/ipfs/QmcZLyosDwMKdB6NLRsiss9HXzDPhVhhRtPy67JFKTDQDX/go-ipld-cbor"
 
 	"github.com/filecoin-project/go-filecoin/address"
@@ -40,20 +42,153 @@ type Block struct {
 	// Nonce is a temporary field used to differentiate blocks for testing
 	Nonce Uint64 `json:"nonce"`
 
-	// Messages is the set of messages included in this block
-	// TODO: should be a merkletree-ish thing
-	Messages []*SignedMessage `json:"messages"`
+	// Messages is the set of messages included in this block. They are not
+	// part of the header, which commits to them with MessagesRoot, and are
+	// stored apart from it, see PutBlock.
+	Messages []*SignedMessage `json:"messages" refmt:"-"`
+
+	// MessagesRoot is the root of the collection of Messages, see
+	// MessagesCollection. It is undefined if there are none.
+	//
+	// Committing to messages and receipts by root changed the cbor encoding
+	// of blocks, and so their cids, from when the header embedded them.
+	// Individual messages can now be proven to light clients without the
+	// whole block. Blocks of the old format are not readable, so networks
+	// made with it must restart from a new genesis.
+	MessagesRoot cid.Cid `json:"messagesRoot,omitempty" refmt:",omitempty"`
 
 	// StateRoot is a cid pointer to the state tree after application of the
 	// transactions state transitions.
 	StateRoot cid.Cid `json:"stateRoot,omitempty" refmt:",omitempty"`
 
-	// MessageReceipts is a set of receipts matching to the sending of the `Messages`.
-	MessageReceipts []*MessageReceipt `json:"messageReceipts"`
+	// MessageReceipts is a set of receipts matching to the sending of the
+	// `Messages`. Like Messages they are not part of the header.
+	MessageReceipts []*MessageReceipt `json:"messageReceipts" refmt:"-"`
+
+	// ReceiptsRoot is the root of the collection of MessageReceipts, see
+	// ReceiptsCollection. It is undefined if there are none.
+	ReceiptsRoot cid.Cid `json:"receiptsRoot,omitempty" refmt:",omitempty"`
 
 	// Proof is a proof of spacetime generated using the hash of the previous ticket as
 	// a challenge
 	Proof proofs.PoStProof `json:"proof"`
+
+	// Timestamp is the unix time in seconds the block was created at.  It is
+	// set on genesis blocks, epochs are counted from it.  It is omitted from
+	// the encoding when zero so that blocks without one keep their cid.
+	Timestamp Uint64 `json:"timestamp" refmt:",omitempty"`
+}
+
+// MessagesCollection returns the collection of the cbor encodings of msgs,
+// whose root a block including them has as MessagesRoot.
+func MessagesCollection(msgs []*SignedMessage) (*Collection, error) {
+	values := make([][]byte, len(msgs))
+	for i, msg := range msgs {
+		data, err := msg.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		values[i] = data
+	}
+	return NewCollection(values)
+}
+
+// ReceiptsCollection returns the collection of the cbor encodings of
+// receipts, whose root a block including them has as ReceiptsRoot.
+func ReceiptsCollection(receipts []*MessageReceipt) (*Collection, error) {
+	values := make([][]byte, len(receipts))
+	for i, r := range receipts {
+		data, err := cbor.DumpObject(r)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = data
+	}
+	return NewCollection(values)
+}
+
+// SetCollectionRoots sets the MessagesRoot and ReceiptsRoot of b to the roots
+// of its messages and receipts.
+func (b *Block) SetCollectionRoots() error {
+	msgs, err := MessagesCollection(b.Messages)
+	if err != nil {
+		return errors.Wrap(err, "failed to build messages collection")
+	}
+	receipts, err := ReceiptsCollection(b.MessageReceipts)
+	if err != nil {
+		return errors.Wrap(err, "failed to build receipts collection")
+	}
+	b.MessagesRoot = msgs.Root
+	b.ReceiptsRoot = receipts.Root
+	return nil
+}
+
+// PutBlock stores the header of b in s along with the collections of its
+// messages and receipts, and returns the cid of b. It fails if the roots in
+// the header do not match them.
+func PutBlock(ctx context.Context, s CborStore, b *Block) (cid.Cid, error) {
+	msgs, err := MessagesCollection(b.Messages)
+	if err != nil {
+		return cid.Undef, errors.Wrap(err, "failed to build messages collection")
+	}
+	if !msgs.Root.Equals(b.MessagesRoot) {
+		return cid.Undef, fmt.Errorf("messages root %s of block does not match its messages %s", b.MessagesRoot, msgs.Root)
+	}
+	receipts, err := ReceiptsCollection(b.MessageReceipts)
+	if err != nil {
+		return cid.Undef, errors.Wrap(err, "failed to build receipts collection")
+	}
+	if !receipts.Root.Equals(b.ReceiptsRoot) {
+		return cid.Undef, fmt.Errorf("receipts root %s of block does not match its receipts %s", b.ReceiptsRoot, receipts.Root)
+	}
+
+	if err := msgs.Store(ctx, s); err != nil {
+		return cid.Undef, err
+	}
+	if err := receipts.Store(ctx, s); err != nil {
+		return cid.Undef, err
+	}
+	return s.Put(ctx, b)
+}
+
+// GetBlock loads the block with cid c from s, along with its messages and
+// receipts.
+func GetBlock(ctx context.Context, s CborStore, c cid.Cid) (*Block, error) {
+	var b Block
+	if err := s.Get(ctx, c, &b); err != nil {
+		return nil, err
+	}
+	if err := b.loadCollections(ctx, s); err != nil {
+		return nil, errors.Wrapf(err, "failed to load messages of block %s", c)
+	}
+	return &b, nil
+}
+
+func (b *Block) loadCollections(ctx context.Context, s CborStore) error {
+	msgs, err := LoadCollection(ctx, s, b.MessagesRoot)
+	if err != nil {
+		return err
+	}
+	b.Messages = make([]*SignedMessage, len(msgs))
+	for i, data := range msgs {
+		b.Messages[i] = &SignedMessage{}
+		if err := b.Messages[i].Unmarshal(data); err != nil {
+			return err
+		}
+	}
+
+	receipts, err := LoadCollection(ctx, s, b.ReceiptsRoot)
+	if err != nil {
+		return err
+	}
+	b.MessageReceipts = make([]*MessageReceipt, len(receipts))
+	for i, data := range receipts {
+		b.MessageReceipts[i] = &MessageReceipt{}
+		if err := cbor.DecodeInto(data, b.MessageReceipts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Cid returns the content id of this block.
@@ -68,7 +203,7 @@ func (b Block) IsParentOf(c Block) bool {
 	return c.Parents.Has(b.Cid())
 }
 
-// ToNode converts the Block to an IPLD node.
+// ToNode converts the header of the Block to an IPLD node.
 func (b *Block) ToNode() node.Node {
 	// Use 32 byte / 256 bit digest. TODO pull this out into a constant?
 	obj, err := cbor.WrapObject(b, DefaultHashFunction, -1)
@@ -89,7 +224,8 @@ func (b *Block) String() string {
 	return fmt.Sprintf("Block cid=[%v]: %s", cid, string(js))
 }
 
-// DecodeBlock decodes raw cbor bytes into a Block.
+// DecodeBlock decodes raw cbor bytes into a Block. Only the header is
+// encoded, so the block has no messages or receipts until they are loaded.
 func DecodeBlock(b []byte) (*Block, error) {
 	var out Block
 	if err := cbor.DecodeInto(b, &out); err != nil {
@@ -0,0 +1,249 @@
+package types
+
+import (
+	"bytes"
+	"context"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+	cbor "gx/ipfs/QmcZLyosDwMKdB6NLRsiss9HXzDPhVhhRtPy67JFKTDQDX/go-ipld-cbor"
+)
+
+func init() {
+	cbor.RegisterCborType(CollectionNode{})
+	cbor.RegisterCborType(InclusionProof{})
+}
+
+// collectionWidth is the number of values in a leaf of a collection, and of
+// children of its other nodes.
+const collectionWidth = 8
+
+// maxCollectionHeight bounds the height of collections, so that the number of
+// values under a node fits in a uint64.
+const maxCollectionHeight = 20
+
+// CollectionNode is a node of the tree a collection is stored as. Leaves have
+// height 0 and hold up to collectionWidth values and no links. A node of
+// height h > 0 holds the cids of up to collectionWidth children, all of
+// height h-1, and no values. A node's cid is the CIDv1 of its cbor encoding
+// with the dag-cbor codec and a blake2b-256 hash (DefaultHashFunction).
+type CollectionNode struct {
+	Height uint64    `json:"height"`
+	Links  []cid.Cid `json:"links"`
+	Values [][]byte  `json:"values"`
+}
+
+// Collection is a list of values stored as a tree of cbor nodes, like an
+// array mapped trie, so that the value at an index can be proven with the
+// nodes on the path to it without the rest of the list. See InclusionProof.
+//
+// The tree is built bottom up. The values fill leaves of collectionWidth
+// values in order, and each further level has one parent for every
+// collectionWidth nodes of the level below, until a level has a single
+// node, the root. Every node but the last of its level is full, so the tree
+// is determined by the values alone. A list of at most collectionWidth
+// values is a single leaf. The value at index i is at position
+// i%collectionWidth of leaf i/collectionWidth, and the child on its path
+// from a node of height h is at position (i/collectionWidth^h)%collectionWidth.
+//
+// The HAMT that stores actor state is not used because it orders entries by
+// the hash of their key, so its shape depends on more than the list, and
+// go-hamt-ipld does not produce proofs.
+type Collection struct {
+	// Root is the cid of the root node, or cid.Undef if the collection is
+	// empty.
+	Root cid.Cid
+
+	count int
+	// levels are the nodes of the tree by height.
+	levels [][]*CollectionNode
+}
+
+// CborStore is the subset of hamt.CborIpldStore that collections and blocks
+// are stored in.
+type CborStore interface {
+	Get(ctx context.Context, c cid.Cid, out interface{}) error
+	Put(ctx context.Context, v interface{}) (cid.Cid, error)
+}
+
+// NewCollection builds the collection of values.
+func NewCollection(values [][]byte) (*Collection, error) {
+	c := &Collection{count: len(values)}
+	if len(values) == 0 {
+		return c, nil
+	}
+
+	var level []*CollectionNode
+	for i := 0; i < len(values); i += collectionWidth {
+		level = append(level, &CollectionNode{Values: values[i:minInt(i+collectionWidth, len(values))]})
+	}
+	c.levels = append(c.levels, level)
+
+	for len(level) > 1 {
+		var parents []*CollectionNode
+		for i := 0; i < len(level); i += collectionWidth {
+			parent := &CollectionNode{Height: uint64(len(c.levels))}
+			for _, child := range level[i:minInt(i+collectionWidth, len(level))] {
+				obj, err := cbor.WrapObject(child, DefaultHashFunction, -1)
+				if err != nil {
+					return nil, err
+				}
+				parent.Links = append(parent.Links, obj.Cid())
+			}
+			parents = append(parents, parent)
+		}
+		level = parents
+		c.levels = append(c.levels, level)
+	}
+
+	obj, err := cbor.WrapObject(level[0], DefaultHashFunction, -1)
+	if err != nil {
+		return nil, err
+	}
+	c.Root = obj.Cid()
+	return c, nil
+}
+
+// Store puts the nodes of c in s.
+func (c *Collection) Store(ctx context.Context, s CborStore) error {
+	for _, level := range c.levels {
+		for _, nd := range level {
+			if _, err := s.Put(ctx, nd); err != nil {
+				return errors.Wrap(err, "failed to store collection node")
+			}
+		}
+	}
+	return nil
+}
+
+// Prove returns the proof that the value at index i is in c.
+func (c *Collection) Prove(i int) (*InclusionProof, error) {
+	if i < 0 || i >= c.count {
+		return nil, errors.Errorf("index %d out of range of collection of %d values", i, c.count)
+	}
+
+	p := &InclusionProof{Index: uint64(i)}
+	for h := len(c.levels) - 1; h >= 0; h-- {
+		nd := c.levels[h][uint64(i)/collectionSpan(uint64(h))]
+		raw, err := cbor.DumpObject(nd)
+		if err != nil {
+			return nil, err
+		}
+		p.Nodes = append(p.Nodes, raw)
+	}
+	p.Value = c.levels[0][i/collectionWidth].Values[i%collectionWidth]
+	return p, nil
+}
+
+// LoadCollection loads the values of the collection with the given root from
+// s. The collection with an undefined root is empty.
+func LoadCollection(ctx context.Context, s CborStore, root cid.Cid) ([][]byte, error) {
+	if !root.Defined() {
+		return nil, nil
+	}
+
+	var nd CollectionNode
+	if err := s.Get(ctx, root, &nd); err != nil {
+		return nil, errors.Wrapf(err, "failed to load collection node %s", root)
+	}
+	return loadCollectionNode(ctx, s, &nd)
+}
+
+func loadCollectionNode(ctx context.Context, s CborStore, nd *CollectionNode) ([][]byte, error) {
+	if nd.Height == 0 {
+		return nd.Values, nil
+	}
+
+	var values [][]byte
+	for _, link := range nd.Links {
+		var child CollectionNode
+		if err := s.Get(ctx, link, &child); err != nil {
+			return nil, errors.Wrapf(err, "failed to load collection node %s", link)
+		}
+		if child.Height != nd.Height-1 {
+			return nil, errors.Errorf("collection node %s has height %d, expected %d", link, child.Height, nd.Height-1)
+		}
+		vs, err := loadCollectionNode(ctx, s, &child)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, vs...)
+	}
+	return values, nil
+}
+
+// InclusionProof proves that Value is at Index of a collection.
+type InclusionProof struct {
+	Index uint64 `json:"index"`
+	Value []byte `json:"value"`
+	// Nodes are the cbor encodings of the nodes on the path from the root of
+	// the collection to the leaf holding Value.
+	Nodes [][]byte `json:"nodes"`
+}
+
+// Verify checks that p proves the inclusion of its value in the collection
+// with the given root. It walks the nodes from the root: each must hash to
+// the cid expected of it, starting with root, and have the height of its
+// place on the path. The root must span the index, and each node gives the
+// cid expected of the next at the position of the index. The last node must
+// be a leaf with the value at the index's position.
+func (p *InclusionProof) Verify(root cid.Cid) error {
+	if !root.Defined() {
+		return errors.New("collection is empty")
+	}
+
+	if len(p.Nodes) > maxCollectionHeight+1 {
+		return errors.Errorf("proof has %d nodes, at most %d expected", len(p.Nodes), maxCollectionHeight+1)
+	}
+
+	expected := root
+	for i, raw := range p.Nodes {
+		c, err := expected.Prefix().Sum(raw)
+		if err != nil {
+			return err
+		}
+		if !c.Equals(expected) {
+			return errors.Errorf("node %d of proof is not %s", i, expected)
+		}
+
+		var nd CollectionNode
+		if err := cbor.DecodeInto(raw, &nd); err != nil {
+			return errors.Wrapf(err, "failed to decode node %d of proof", i)
+		}
+		if nd.Height != uint64(len(p.Nodes)-1-i) {
+			return errors.Errorf("node %d of proof has height %d, expected %d", i, nd.Height, len(p.Nodes)-1-i)
+		}
+		if i == 0 && p.Index/collectionSpan(nd.Height) != 0 {
+			return errors.Errorf("index %d out of range of collection", p.Index)
+		}
+
+		pos := p.Index / (collectionSpan(nd.Height) / collectionWidth) % collectionWidth
+		if nd.Height == 0 {
+			if pos >= uint64(len(nd.Values)) || !bytes.Equal(nd.Values[pos], p.Value) {
+				return errors.Errorf("value is not at index %d", p.Index)
+			}
+			return nil
+		}
+		if pos >= uint64(len(nd.Links)) {
+			return errors.Errorf("index %d out of range of collection", p.Index)
+		}
+		expected = nd.Links[pos]
+	}
+	return errors.New("proof ends before a leaf")
+}
+
+// collectionSpan is the number of values under a node of height h.
+func collectionSpan(h uint64) uint64 {
+	span := uint64(collectionWidth)
+	for ; h > 0; h-- {
+		span *= collectionWidth
+	}
+	return span
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,156 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	hamt "gx/ipfs/QmNf3wujpV2Y7Lnj2hy2UrmuX8bhMDStRHbnSLh7Ypf36h/go-hamt-ipld"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
+)
+
+func testValues(n int) [][]byte {
+	values := make([][]byte, n)
+	for i := range values {
+		values[i] = []byte(fmt.Sprintf("value %d", i))
+	}
+	return values
+}
+
+func TestCollection(t *testing.T) {
+	t.Parallel()
+
+	for _, n := range []int{1, 7, 8, 9, 64, 65, 600} {
+		n := n
+		t.Run(fmt.Sprintf("%d values", n), func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			ctx := context.Background()
+			values := testValues(n)
+			c, err := NewCollection(values)
+			require.NoError(err)
+
+			cst := hamt.NewCborStore()
+			require.NoError(c.Store(ctx, cst))
+			loaded, err := LoadCollection(ctx, cst, c.Root)
+			require.NoError(err)
+			assert.Equal(values, loaded)
+
+			for i := range values {
+				p, err := c.Prove(i)
+				require.NoError(err)
+				assert.Equal(values[i], p.Value)
+				assert.NoError(p.Verify(c.Root))
+			}
+		})
+	}
+
+	t.Run("is empty without values", func(t *testing.T) {
+		c, err := NewCollection(nil)
+		require.NoError(t, err)
+		assert.False(t, c.Root.Defined())
+
+		_, err = c.Prove(0)
+		assert.Error(t, err)
+	})
+
+	t.Run("proofs of other values or indexes fail", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		c, err := NewCollection(testValues(20))
+		require.NoError(err)
+		other, err := NewCollection(testValues(21))
+		require.NoError(err)
+
+		p, err := c.Prove(11)
+		require.NoError(err)
+		assert.Error(p.Verify(other.Root))
+
+		p.Value = []byte("value 12")
+		assert.Error(p.Verify(c.Root))
+
+		p, err = c.Prove(11)
+		require.NoError(err)
+		p.Index = 12
+		assert.Error(p.Verify(c.Root))
+
+		p, err = c.Prove(11)
+		require.NoError(err)
+		p.Nodes = p.Nodes[1:]
+		assert.Error(p.Verify(c.Root))
+	})
+}
+
+func TestBlockCollections(t *testing.T) {
+	t.Parallel()
+
+	newBlock := func(require *require.Assertions, n int) *Block {
+		blk := &Block{Height: 3}
+		for i, msg := range NewSignedMsgs(n, mockSignerForTest) {
+			blk.Messages = append(blk.Messages, msg)
+			blk.MessageReceipts = append(blk.MessageReceipts, &MessageReceipt{ExitCode: uint8(i), Return: []Bytes{}})
+		}
+		require.NoError(blk.SetCollectionRoots())
+		return blk
+	}
+
+	t.Run("blocks are stored with their messages and receipts", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		ctx := context.Background()
+		cst := hamt.NewCborStore()
+		blk := newBlock(require, 10)
+
+		c, err := PutBlock(ctx, cst, blk)
+		require.NoError(err)
+		assert.Equal(blk.Cid(), c)
+
+		loaded, err := GetBlock(ctx, cst, c)
+		require.NoError(err)
+		assert.Equal(blk, loaded)
+	})
+
+	t.Run("blocks whose roots do not match are not stored", func(t *testing.T) {
+		require := require.New(t)
+
+		blk := newBlock(require, 2)
+		blk.Messages = blk.Messages[:1]
+
+		_, err := PutBlock(context.Background(), hamt.NewCborStore(), blk)
+		assert.Error(t, err)
+	})
+
+	t.Run("message proofs are checked against the block cid", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		blk := newBlock(require, 10)
+		msgCid, err := blk.Messages[4].Cid()
+		require.NoError(err)
+
+		p, err := NewMessageProof(blk, 4)
+		require.NoError(err)
+
+		receipt, err := p.Verify(blk.Cid(), msgCid)
+		require.NoError(err)
+		assert.Equal(blk.MessageReceipts[4], receipt)
+
+		otherMsgCid, err := blk.Messages[5].Cid()
+		require.NoError(err)
+		_, err = p.Verify(blk.Cid(), otherMsgCid)
+		assert.Error(err)
+
+		_, err = p.Verify(SomeCid(), msgCid)
+		assert.Error(err)
+
+		other, err := NewMessageProof(blk, 5)
+		require.NoError(err)
+		p.Receipt = other.Receipt
+		_, err = p.Verify(blk.Cid(), msgCid)
+		assert.Error(err)
+	})
+}
@@ -44,18 +44,18 @@ var BootstrapMinerActorCodeCid cid.Cid
 var ActorCodeCidTypeNames = make(map[cid.Cid]string)
 
 func init() {
-	AccountActorCodeObj = dag.NewRawNode([]byte("accountactor"))
+	AccountActorCodeObj = NewActorCodeObj("accountactor")
 	AccountActorCodeCid = AccountActorCodeObj.Cid()
-	StorageMarketActorCodeObj = dag.NewRawNode([]byte("storagemarket"))
+	StorageMarketActorCodeObj = NewActorCodeObj("storagemarket")
 	StorageMarketActorCodeCid = StorageMarketActorCodeObj.Cid()
-	PaymentBrokerActorCodeObj = dag.NewRawNode([]byte("paymentbroker"))
+	PaymentBrokerActorCodeObj = NewActorCodeObj("paymentbroker")
 	PaymentBrokerActorCodeCid = PaymentBrokerActorCodeObj.Cid()
-	MinerActorCodeObj = dag.NewRawNode([]byte("mineractor"))
+	MinerActorCodeObj = NewActorCodeObj("mineractor")
 	MinerActorCodeCid = MinerActorCodeObj.Cid()
-	BootstrapMinerActorCodeObj = dag.NewRawNode([]byte("bootstrapmineractor"))
+	BootstrapMinerActorCodeObj = NewActorCodeObj("bootstrapmineractor")
 	BootstrapMinerActorCodeCid = BootstrapMinerActorCodeObj.Cid()
 
-	// New Actors need to be added here.
+	// Other actors are named when they are registered with builtin.Register.
 	// TODO: Make this work with reflection -- but note that nasty import cycles lie on that path.
 	// This is good enough for now.
 	ActorCodeCidTypeNames[AccountActorCodeCid] = "AccountActor"
@@ -65,6 +65,13 @@ func init() {
 	ActorCodeCidTypeNames[BootstrapMinerActorCodeCid] = "MinerActor"
 }
 
+// NewActorCodeObj returns the code representation of the actor called name.
+// Its cid is the code of the actor, under which the implementation of the
+// actor must be registered.
+func NewActorCodeObj(name string) ipld.Node {
+	return dag.NewRawNode([]byte(name))
+}
+
 // ActorCodeTypeName returns the (string) name of the Go type of the actor with cid, code.
 func ActorCodeTypeName(code cid.Cid) string {
 	if !code.Defined() {
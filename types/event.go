@@ -0,0 +1,40 @@
+package types
+
+import (
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	cbor "gx/ipfs/QmcZLyosDwMKdB6NLRsiss9HXzDPhVhhRtPy67JFKTDQDX/go-ipld-cbor"
+
+	"github.com/filecoin-project/go-filecoin/address"
+)
+
+func init() {
+	cbor.RegisterCborType(Event{})
+}
+
+// Event is a notice an actor emits while processing a message, so that
+// clients can follow what happens on chain without decoding messages or
+// comparing states.
+type Event struct {
+	// Emitter is the address of the actor that emitted the event.
+	Emitter address.Address `json:"emitter"`
+
+	// Topic names the kind of event, e.g. "channel-created".
+	Topic string `json:"topic"`
+
+	// Data is the cbor encoding of the details of the event, whose type
+	// depends on the topic.
+	Data []byte `json:"data"`
+}
+
+// EventsRoot returns the cid of the list of events the receipt of a message
+// that emitted them commits to, or cid.Undef if there are none.
+func EventsRoot(events []*Event) (cid.Cid, error) {
+	if len(events) == 0 {
+		return cid.Undef, nil
+	}
+	obj, err := cbor.WrapObject(events, DefaultHashFunction, -1)
+	if err != nil {
+		return cid.Undef, err
+	}
+	return obj.Cid(), nil
+}
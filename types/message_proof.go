@@ -0,0 +1,87 @@
+package types
+
+import (
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+	cbor "gx/ipfs/QmcZLyosDwMKdB6NLRsiss9HXzDPhVhhRtPy67JFKTDQDX/go-ipld-cbor"
+)
+
+func init() {
+	cbor.RegisterCborType(MessageProof{})
+}
+
+// MessageProof proves that a block includes a message and what its receipt
+// is, without the other messages of the block, so that light clients can
+// check it knowing only the cid of the block.
+type MessageProof struct {
+	// Header is the cbor encoding of the header of the block.
+	Header []byte `json:"header"`
+	// Message proves the inclusion of the message in the messages of the
+	// block, and Receipt that of its receipt at the same index.
+	Message *InclusionProof `json:"message"`
+	Receipt *InclusionProof `json:"receipt"`
+}
+
+// NewMessageProof returns the proof that blk includes its i'th message.
+func NewMessageProof(blk *Block, i int) (*MessageProof, error) {
+	msgs, err := MessagesCollection(blk.Messages)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build messages collection")
+	}
+	receipts, err := ReceiptsCollection(blk.MessageReceipts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build receipts collection")
+	}
+
+	p := &MessageProof{Header: blk.ToNode().RawData()}
+	if p.Message, err = msgs.Prove(i); err != nil {
+		return nil, err
+	}
+	if p.Receipt, err = receipts.Prove(i); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Verify checks that p proves that the block with cid blockCid includes the
+// message with cid msgCid, and returns the message's receipt.
+func (p *MessageProof) Verify(blockCid, msgCid cid.Cid) (*MessageReceipt, error) {
+	c, err := blockCid.Prefix().Sum(p.Header)
+	if err != nil {
+		return nil, err
+	}
+	if !c.Equals(blockCid) {
+		return nil, errors.Errorf("header is not block %s", blockCid)
+	}
+	blk, err := DecodeBlock(p.Header)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode header")
+	}
+
+	if p.Message == nil || p.Receipt == nil {
+		return nil, errors.New("proof is incomplete")
+	}
+	c, err = msgCid.Prefix().Sum(p.Message.Value)
+	if err != nil {
+		return nil, err
+	}
+	if !c.Equals(msgCid) {
+		return nil, errors.Errorf("proven message is not %s", msgCid)
+	}
+	if err := p.Message.Verify(blk.MessagesRoot); err != nil {
+		return nil, errors.Wrap(err, "invalid message proof")
+	}
+
+	if p.Receipt.Index != p.Message.Index {
+		return nil, errors.Errorf("receipt %d is not of message %d", p.Receipt.Index, p.Message.Index)
+	}
+	if err := p.Receipt.Verify(blk.ReceiptsRoot); err != nil {
+		return nil, errors.Wrap(err, "invalid receipt proof")
+	}
+
+	var receipt MessageReceipt
+	if err := cbor.DecodeInto(p.Receipt.Value, &receipt); err != nil {
+		return nil, errors.Wrap(err, "failed to decode receipt")
+	}
+	return &receipt, nil
+}
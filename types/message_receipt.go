@@ -1,6 +1,7 @@
 package types
 
 import (
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 	cbor "gx/ipfs/QmcZLyosDwMKdB6NLRsiss9HXzDPhVhhRtPy67JFKTDQDX/go-ipld-cbor"
 )
 
@@ -20,4 +21,10 @@ type MessageReceipt struct {
 
 	// GasAttoFIL Charge is the actual amount of FIL transferred from the sender to the miner for processing the message
 	GasAttoFIL *AttoFIL `json:"gasAttoFIL"`
+
+	// EventsRoot is the cid of the list of events emitted while processing
+	// the message, see EventsRoot. It is undefined if the message emitted
+	// none or failed, and before the network upgrade that enables events, so
+	// that the encoding of earlier receipts does not change.
+	EventsRoot cid.Cid `json:"eventsRoot,omitempty" refmt:",omitempty"`
 }
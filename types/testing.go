@@ -85,14 +85,29 @@ func (ms MockSigner) SignBytes(data []byte, addr address.Address) (Signature, er
 // future.
 // TODO support chosing from address
 func NewSignedMessageForTestGetter(ms MockSigner) func() *SignedMessage {
+	return newSignedMessageForTestGetter(ms, false)
+}
+
+// NewSignedMessageSequenceForTestGetter is like NewSignedMessageForTestGetter
+// but each message has the next nonce from the signer's first address, starting
+// at 0, so that the messages can be pending in a message pool together.
+func NewSignedMessageSequenceForTestGetter(ms MockSigner) func() *SignedMessage {
+	return newSignedMessageForTestGetter(ms, true)
+}
+
+func newSignedMessageForTestGetter(ms MockSigner, incNonce bool) func() *SignedMessage {
 	i := 0
 	return func() *SignedMessage {
 		s := fmt.Sprintf("smsg%d", i)
+		nonce := uint64(0)
+		if incNonce {
+			nonce = uint64(i)
+		}
 		i++
 		msg := NewMessage(
 			ms.Addresses[0], // from needs to be an address from the signer
 			address.NewMainnet([]byte(s+"-to")),
-			0,
+			nonce,
 			NewAttoFILFromFIL(0),
 			s,
 			[]byte("params"))
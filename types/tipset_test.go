@@ -40,7 +40,7 @@ func block(require *require.Assertions, height int, parentCid cid.Cid, parentWei
 	require.NoError(err)
 	ret := []byte{1, 2}
 
-	return &Block{
+	b := &Block{
 		Parents:         NewSortedCidSet(parentCid),
 		ParentWeight:    Uint64(parentWeight),
 		Height:          Uint64(42 + uint64(height)),
@@ -49,6 +49,8 @@ func block(require *require.Assertions, height int, parentCid cid.Cid, parentWei
 		StateRoot:       SomeCid(),
 		MessageReceipts: []*MessageReceipt{{ExitCode: 1, Return: []Bytes{ret}}},
 	}
+	require.NoError(b.SetCollectionRoots())
+	return b
 }
 
 func TestTipSet(t *testing.T) {
@@ -0,0 +1,146 @@
+// Package upgrade schedules the versions of the network by block height.
+//
+// A change to the code of the builtin actors or to a consensus rule, such as
+// the gas schedule, the block reward or the parameters of expected consensus,
+// is a hard fork: every node must switch to it at the same height. Such
+// changes are made by adding a Version, scheduling it at the height of the
+// upgrade and checking the version in effect wherever the behavior changes.
+//
+// So far the gas schedule (vm.GasScheduleAt), the constant V of expected
+// consensus (consensus.ECVAt) and whether receipts commit to events
+// (vm.EventsEnabledAt) check the version. The block gas limit, the
+// proving period and the block reward do not yet: changing them needs them
+// to be looked up by height first.
+package upgrade
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// Version is a version of the rules of the network.
+type Version uint64
+
+const (
+	// Version0 is the version of the network at genesis.
+	Version0 Version = iota
+	// Version1 charges gas for each operation of a message rather than only
+	// for method invocations.
+	Version1
+	// Version2 records the events actors emit and commits to them in the
+	// events root of message receipts.
+	Version2
+)
+
+// Upgrade switches the network to Version at Height.
+type Upgrade struct {
+	Height  uint64  `json:"height"`
+	Version Version `json:"version"`
+}
+
+// Schedule is the upgrades of a network in ascending order of height.
+type Schedule []Upgrade
+
+// DefaultSchedule is the schedule of the network unless SetSchedule replaces
+// it. It schedules no upgrades, so networks opt in to them, e.g. with
+// chain.upgrades in the node's config. Once a network has reached the height
+// of an upgrade it must never change; upgrades are only added.
+var DefaultSchedule = Schedule{
+	{Height: 0, Version: Version0},
+}
+
+var (
+	// scheduleMu protects schedule.
+	scheduleMu sync.RWMutex
+	// schedule is the schedule in effect.
+	schedule = DefaultSchedule
+)
+
+// SetSchedule makes s the schedule of the network in place of
+// DefaultSchedule, e.g. for a private network whose upgrades are set in the
+// node's config. All nodes of a network must use the same schedule, and set it
+// before processing any block.
+func SetSchedule(s Schedule) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	scheduleMu.Lock()
+	defer scheduleMu.Unlock()
+	schedule = s
+	return nil
+}
+
+// CurrentSchedule returns the schedule of the network.
+func CurrentSchedule() Schedule {
+	scheduleMu.RLock()
+	defer scheduleMu.RUnlock()
+	return schedule
+}
+
+// VersionAt returns the version of the network in effect at block height bh
+// according to CurrentSchedule.
+func VersionAt(bh *types.BlockHeight) Version {
+	return CurrentSchedule().VersionAt(bh)
+}
+
+// HeightOf returns the height at which CurrentSchedule upgrades to v.
+func HeightOf(v Version) (uint64, bool) {
+	return CurrentSchedule().HeightOf(v)
+}
+
+// VersionAt returns the version in effect at block height bh. A nil height,
+// as used by queries, is taken to be genesis.
+func (s Schedule) VersionAt(bh *types.BlockHeight) Version {
+	v := Version0
+	for _, u := range s {
+		if bh == nil || bh.LessThan(types.NewBlockHeight(u.Height)) {
+			break
+		}
+		v = u.Version
+	}
+	return v
+}
+
+// HeightOf returns the height at which s upgrades to v, and false if s does
+// not schedule v.
+func (s Schedule) HeightOf(v Version) (uint64, bool) {
+	for _, u := range s {
+		if u.Version == v {
+			return u.Height, true
+		}
+	}
+	return 0, false
+}
+
+// Between returns the upgrades of s after parentHeight up to and including
+// height, in order. These are the upgrades to run on the state of a tipset at
+// parentHeight before applying a tipset at height to it; with null blocks in
+// between there may be more than one.
+func (s Schedule) Between(parentHeight, height uint64) []Upgrade {
+	var upgrades []Upgrade
+	for _, u := range s {
+		if u.Height > parentHeight && u.Height <= height {
+			upgrades = append(upgrades, u)
+		}
+	}
+	return upgrades
+}
+
+// Validate returns an error if s does not start at genesis with Version0 or
+// if its heights and versions do not both increase.
+func (s Schedule) Validate() error {
+	if len(s) == 0 || s[0].Height != 0 || s[0].Version != Version0 {
+		return fmt.Errorf("schedule must start with version %d at height 0", Version0)
+	}
+	for i := 1; i < len(s); i++ {
+		if s[i].Height <= s[i-1].Height {
+			return fmt.Errorf("upgrade to version %d at height %d is not after height %d", s[i].Version, s[i].Height, s[i-1].Height)
+		}
+		if s[i].Version <= s[i-1].Version {
+			return fmt.Errorf("upgrade to version %d at height %d does not follow version %d", s[i].Version, s[i].Height, s[i-1].Version)
+		}
+	}
+	return nil
+}
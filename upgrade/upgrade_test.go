@@ -0,0 +1,68 @@
+package upgrade
+
+import (
+	"testing"
+
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestDefaultScheduleIsValid(t *testing.T) {
+	assert.NoError(t, DefaultSchedule.Validate())
+}
+
+func TestScheduleVersionAt(t *testing.T) {
+	assert := assert.New(t)
+
+	s := Schedule{{Height: 0, Version: Version0}, {Height: 10, Version: Version1}}
+	assert.Equal(Version0, s.VersionAt(nil))
+	assert.Equal(Version0, s.VersionAt(types.NewBlockHeight(0)))
+	assert.Equal(Version0, s.VersionAt(types.NewBlockHeight(9)))
+	assert.Equal(Version1, s.VersionAt(types.NewBlockHeight(10)))
+	assert.Equal(Version1, s.VersionAt(types.NewBlockHeight(11)))
+
+	h, ok := s.HeightOf(Version1)
+	assert.True(ok)
+	assert.Equal(uint64(10), h)
+	_, ok = s.HeightOf(Version(2))
+	assert.False(ok)
+}
+
+func TestScheduleBetween(t *testing.T) {
+	assert := assert.New(t)
+
+	s := Schedule{{Height: 0, Version: Version0}, {Height: 10, Version: Version1}, {Height: 12, Version: Version(2)}}
+	assert.Empty(s.Between(0, 9))
+	assert.Equal([]Upgrade{s[1]}, s.Between(9, 10))
+	assert.Empty(s.Between(10, 11))
+	// Null blocks may skip the heights of several upgrades.
+	assert.Equal([]Upgrade{s[1], s[2]}, s.Between(8, 15))
+	// Genesis never upgrades.
+	assert.Empty(s.Between(0, 0))
+}
+
+func TestScheduleValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Error(Schedule{}.Validate())
+	assert.Error(Schedule{{Height: 1, Version: Version0}}.Validate())
+	assert.Error(Schedule{{Height: 0, Version: Version0}, {Height: 0, Version: Version1}}.Validate())
+	assert.Error(Schedule{{Height: 0, Version: Version0}, {Height: 5, Version: Version0}}.Validate())
+	assert.NoError(Schedule{{Height: 0, Version: Version0}, {Height: 5, Version: Version1}}.Validate())
+}
+
+func TestSetSchedule(t *testing.T) {
+	assert := assert.New(t)
+
+	defer func(s Schedule) { schedule = s }(CurrentSchedule())
+
+	assert.Error(SetSchedule(Schedule{{Height: 5, Version: Version0}}))
+	assert.Equal(DefaultSchedule, CurrentSchedule())
+
+	s := Schedule{{Height: 0, Version: Version0}, {Height: 7, Version: Version1}}
+	assert.NoError(SetSchedule(s))
+	assert.Equal(s, CurrentSchedule())
+	assert.Equal(Version0, VersionAt(types.NewBlockHeight(6)))
+	assert.Equal(Version1, VersionAt(types.NewBlockHeight(7)))
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/binary"
 
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	cbor "gx/ipfs/QmcZLyosDwMKdB6NLRsiss9HXzDPhVhhRtPy67JFKTDQDX/go-ipld-cbor"
 
 	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/actor"
@@ -28,6 +29,8 @@ type Context struct {
 	blockHeight *types.BlockHeight
 	ancestors   []types.TipSet
 	lookBack    int
+	trace       *Trace
+	events      *EventLog
 
 	deps *deps // Inject external dependencies so we can unit test robustly.
 }
@@ -45,6 +48,11 @@ type NewContextParams struct {
 	BlockHeight *types.BlockHeight
 	Ancestors   []types.TipSet
 	LookBack    int
+	// Trace is optional. If set the execution is recorded into it.
+	Trace *Trace
+	// Events is optional. If set the events emitted by actors are appended
+	// to it.
+	Events *EventLog
 }
 
 // NewVMContext returns an initialized context.
@@ -59,6 +67,8 @@ func NewVMContext(params NewContextParams) *Context {
 		blockHeight: params.BlockHeight,
 		ancestors:   params.Ancestors,
 		lookBack:    params.LookBack,
+		trace:       params.Trace,
+		events:      params.Events,
 		deps:        makeDeps(params.State),
 	}
 }
@@ -67,7 +77,7 @@ var _ exec.VMContext = (*Context)(nil)
 
 // Storage returns an implementation of the storage module for this context.
 func (ctx *Context) Storage() exec.Storage {
-	return ctx.storageMap.NewStorage(ctx.message.To, ctx.to)
+	return meteredStorage{Storage: ctx.storageMap.NewStorage(ctx.message.To, ctx.to), ctx: ctx}
 }
 
 // Message retrieves the message associated with this context.
@@ -95,7 +105,66 @@ func (ctx *Context) ReadStorage() ([]byte, error) {
 
 // Charge attempts to add the given cost to the accrued gas cost of this transaction
 func (ctx *Context) Charge(cost types.GasUnits) error {
-	return ctx.gasTracker.Charge(cost)
+	before := ctx.gasTracker.gasConsumedByMessage
+	err := ctx.gasTracker.Charge(cost)
+	ctx.trace.charge(ctx.gasTracker.gasConsumedByMessage - before)
+	return err
+}
+
+// chargeUnfailing charges cost for an operation that must not fail for lack of
+// gas, such as a storage access whose errors actors treat as faults. Running
+// out of gas is noted in the gas tracker instead, and the message reverts when
+// the called method returns.
+func (ctx *Context) chargeUnfailing(cost types.GasUnits) {
+	if cost == 0 || ctx.gasTracker.OutOfGas() {
+		return
+	}
+	_ = ctx.Charge(cost)
+}
+
+// gasSchedule returns the gas schedule in effect for this context.
+func (ctx *Context) gasSchedule() *GasSchedule {
+	return GasScheduleAt(ctx.blockHeight)
+}
+
+// ChargeSignatureVerification charges for checking a signature, such as that
+// of a payment channel voucher.
+func (ctx *Context) ChargeSignatureVerification() error {
+	return ctx.Charge(ctx.gasSchedule().SignatureVerification)
+}
+
+// ChargeSealVerification charges for checking the proof of a sealed sector.
+func (ctx *Context) ChargeSealVerification() error {
+	return ctx.Charge(ctx.gasSchedule().SealVerification)
+}
+
+// ChargePoStVerification charges for checking a proof of spacetime.
+func (ctx *Context) ChargePoStVerification() error {
+	return ctx.Charge(ctx.gasSchedule().PoStVerification)
+}
+
+// EmitEvent records an event with the given topic, emitted by the actor the
+// message was sent to. data is stored cbor encoded. Since events are committed
+// to by receipts, emitting one is charged by the size of its topic and data.
+// Before events are enabled, see EventsEnabledAt, it does nothing.
+func (ctx *Context) EmitEvent(topic string, data interface{}) error {
+	if !EventsEnabledAt(ctx.blockHeight) {
+		return nil
+	}
+	raw, err := cbor.DumpObject(data)
+	if err != nil {
+		return errors.FaultErrorWrapf(err, "failed to encode data of event %s", topic)
+	}
+	if err := ctx.Charge(ctx.gasSchedule().EventCost(topic, raw)); err != nil {
+		return err
+	}
+
+	ctx.events.emit(&types.Event{
+		Emitter: ctx.message.To,
+		Topic:   topic,
+		Data:    raw,
+	})
+	return nil
 }
 
 // GasUnits retrieves the gas cost so far
@@ -171,6 +240,8 @@ func (ctx *Context) Send(to address.Address, method string, value *types.AttoFIL
 		GasTracker:  ctx.gasTracker,
 		BlockHeight: ctx.blockHeight,
 		Ancestors:   ctx.ancestors,
+		Trace:       ctx.trace.newSend(msg),
+		Events:      ctx.events.newSend(),
 	}
 	innerCtx := NewVMContext(innerParams)
 
@@ -178,6 +249,7 @@ func (ctx *Context) Send(to address.Address, method string, value *types.AttoFIL
 	if err != nil {
 		return nil, ret, err
 	}
+	ctx.events.merge(innerParams.Events)
 
 	return out, ret, nil
 }
@@ -209,6 +281,8 @@ func computeActorAddress(creator address.Address, nonce uint64) (address.Address
 // CreateNewActor creates and initializes an actor at the given address.
 // If the address is occupied by a non-empty actor, this method will fail.
 func (ctx *Context) CreateNewActor(addr address.Address, code cid.Cid, initializerData interface{}) error {
+	ctx.chargeUnfailing(ctx.gasSchedule().ActorCreation)
+
 	// Check existing address. If nothing there, create empty actor.
 	newActor, err := ctx.state.GetOrCreateActor(context.TODO(), addr, func() (*actor.Actor, error) {
 		return &actor.Actor{}, nil
@@ -225,7 +299,7 @@ func (ctx *Context) CreateNewActor(addr address.Address, code cid.Cid, initializ
 	// make this the right 'type' of actor
 	newActor.Code = code
 
-	childStorage := ctx.storageMap.NewStorage(addr, newActor)
+	childStorage := meteredStorage{Storage: ctx.storageMap.NewStorage(addr, newActor), ctx: ctx}
 	execActor, err := ctx.state.GetBuiltinActorCode(code)
 	if err != nil {
 		return errors.NewRevertErrorf("attempt to create executable actor from non-existent code %s", code.String())
@@ -22,6 +22,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/upgrade"
 	"github.com/filecoin-project/go-filecoin/vm/errors"
 )
 
@@ -271,6 +272,100 @@ func TestVMContextSendFailures(t *testing.T) {
 
 }
 
+func TestVMContextEvents(t *testing.T) {
+	newMsg := types.NewMessageForTestGetter()
+	newAddress := address.NewForTestGetter()
+
+	defer func(s upgrade.Schedule) { require.NoError(t, upgrade.SetSchedule(s)) }(upgrade.CurrentSchedule())
+	require.NoError(t, upgrade.SetSchedule(upgrade.Schedule{
+		{Height: 0, Version: upgrade.Version0},
+		{Height: 10, Version: upgrade.Version2},
+	}))
+
+	newContext := func() *Context {
+		ctx := NewVMContext(NewContextParams{
+			From:        actor.NewActor(cid.Undef, types.NewZeroAttoFIL()),
+			To:          actor.NewActor(cid.Undef, types.NewZeroAttoFIL()),
+			Message:     newMsg(),
+			State:       state.NewCachedStateTree(&state.MockStateTree{}),
+			StorageMap:  NewStorageMap(blockstore.NewBlockstore(datastore.NewMapDatastore())),
+			GasTracker:  NewGasTracker(),
+			BlockHeight: types.NewBlockHeight(10),
+			Events:      &EventLog{},
+		})
+		ctx.deps = &deps{
+			EncodeValues: func(_ []*abi.Value) ([]byte, error) {
+				return nil, nil
+			},
+			GetOrCreateActor: func(_ context.Context, _ address.Address, f func() (*actor.Actor, error)) (*actor.Actor, error) {
+				return f()
+			},
+			ToValues: func(_ []interface{}) ([]*abi.Value, error) {
+				return nil, nil
+			},
+		}
+		return ctx
+	}
+
+	// sendEmitting makes the messages ctx sends emit an event with the given
+	// topic and then fail with sendErr.
+	sendEmitting := func(ctx *Context, topic string, sendErr error) {
+		ctx.deps.Send = func(_ context.Context, innerCtx *Context) ([][]byte, uint8, error) {
+			if err := innerCtx.EmitEvent(topic, "data"); err != nil {
+				return nil, 1, err
+			}
+			if sendErr != nil {
+				return nil, 1, sendErr
+			}
+			return nil, 0, nil
+		}
+	}
+
+	topics := func(ctx *Context) []string {
+		var out []string
+		for _, e := range ctx.events.Events {
+			out = append(out, e.Topic)
+		}
+		return out
+	}
+
+	t.Run("events of sent messages are kept when they succeed", func(t *testing.T) {
+		require := require.New(t)
+
+		ctx := newContext()
+		require.NoError(ctx.EmitEvent("before", "data"))
+		sendEmitting(ctx, "inner", nil)
+		_, _, err := ctx.Send(newAddress(), "foo", nil, []interface{}{})
+		require.NoError(err)
+		require.NoError(ctx.EmitEvent("after", "data"))
+
+		assert.Equal(t, []string{"before", "inner", "after"}, topics(ctx))
+	})
+
+	t.Run("events of sent messages are dropped when they fail", func(t *testing.T) {
+		require := require.New(t)
+
+		ctx := newContext()
+		require.NoError(ctx.EmitEvent("before", "data"))
+		sendEmitting(ctx, "inner", errors.NewRevertError("boom"))
+		_, _, err := ctx.Send(newAddress(), "foo", nil, []interface{}{})
+		require.Error(err)
+		require.NoError(ctx.EmitEvent("after", "data"))
+
+		assert.Equal(t, []string{"before", "after"}, topics(ctx))
+	})
+
+	t.Run("events are dropped before the upgrade that enables them", func(t *testing.T) {
+		require := require.New(t)
+
+		ctx := newContext()
+		ctx.blockHeight = types.NewBlockHeight(9)
+		require.NoError(ctx.EmitEvent("before", "data"))
+
+		assert.Empty(t, topics(ctx))
+	})
+}
+
 func TestVMContextIsAccountActor(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
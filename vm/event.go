@@ -0,0 +1,49 @@
+package vm
+
+import (
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/upgrade"
+)
+
+// EventsEnabledAt returns whether the events actors emit are recorded, and
+// committed to by receipts, at block height bh. Before upgrade.Version2 they
+// are dropped so that receipts, and the blocks that include them, are the
+// same as those of nodes that do not know about events.
+func EventsEnabledAt(bh *types.BlockHeight) bool {
+	return upgrade.VersionAt(bh) >= upgrade.Version2
+}
+
+// EventLog collects the events the actors emit while processing a message,
+// including the messages they send each other, in the order they are emitted.
+// All methods of EventLog are safe to call on nil.
+type EventLog struct {
+	Events []*types.Event
+}
+
+// emit appends e to the log.
+func (l *EventLog) emit(e *types.Event) {
+	if l == nil {
+		return
+	}
+	l.Events = append(l.Events, e)
+}
+
+// newSend returns the log for the events of a message sent by an actor. It is
+// nil if l is, so that events are only collected when the caller asked for
+// them.
+func (l *EventLog) newSend() *EventLog {
+	if l == nil {
+		return nil
+	}
+	return &EventLog{}
+}
+
+// merge appends the events of the log of a sent message to l. It is called
+// only once the message succeeds: the events of messages that fail are
+// reverted along with their changes to the state.
+func (l *EventLog) merge(sent *EventLog) {
+	if l == nil || sent == nil {
+		return
+	}
+	l.Events = append(l.Events, sent.Events...)
+}
@@ -0,0 +1,103 @@
+package vm
+
+import (
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/upgrade"
+)
+
+// GasSchedule is the gas the vm charges for each operation of a message. A
+// schedule must never change once the network has upgraded to it: new costs
+// go in a new schedule introduced by a new network version.
+type GasSchedule struct {
+	// MethodInvocation is charged for each call of an actor method, including
+	// the calls actors make to each other.
+	MethodInvocation types.GasUnits
+	// MessageBase is charged for each message sent, including messages sent by
+	// actors, and MessageByte for each byte of its parameters.
+	MessageBase types.GasUnits
+	MessageByte types.GasUnits
+	// StorageReadBase is charged for each chunk an actor reads from its
+	// storage and StorageReadByte for each byte of it. Writes are charged
+	// alike.
+	StorageReadBase  types.GasUnits
+	StorageReadByte  types.GasUnits
+	StorageWriteBase types.GasUnits
+	StorageWriteByte types.GasUnits
+	// ActorCreation is charged for each actor created by another actor.
+	ActorCreation types.GasUnits
+	// SignatureVerification is charged for checking the signature of a
+	// message or of a payment channel voucher.
+	SignatureVerification types.GasUnits
+	// SealVerification and PoStVerification are charged for checking the
+	// proofs miners submit.
+	SealVerification types.GasUnits
+	PoStVerification types.GasUnits
+	// EventBase is charged for each event an actor emits and EventByte for
+	// each byte of its topic and encoded data.
+	EventBase types.GasUnits
+	EventByte types.GasUnits
+}
+
+// GasScheduleV0 is the schedule in effect from genesis. It charges only for
+// invoking methods, which the builtin actors used to charge themselves.
+var GasScheduleV0 = GasSchedule{
+	MethodInvocation: types.NewGasUnits(100),
+}
+
+// GasScheduleV1 charges for the operations that make messages expensive to
+// validate. It is in effect from upgrade.Version1.
+var GasScheduleV1 = GasSchedule{
+	MethodInvocation:      types.NewGasUnits(100),
+	MessageBase:           types.NewGasUnits(10),
+	MessageByte:           types.NewGasUnits(1),
+	StorageReadBase:       types.NewGasUnits(10),
+	StorageReadByte:       types.NewGasUnits(1),
+	StorageWriteBase:      types.NewGasUnits(20),
+	StorageWriteByte:      types.NewGasUnits(2),
+	ActorCreation:         types.NewGasUnits(500),
+	SignatureVerification: types.NewGasUnits(200),
+	SealVerification:      types.NewGasUnits(10000),
+	PoStVerification:      types.NewGasUnits(10000),
+	EventBase:             types.NewGasUnits(20),
+	EventByte:             types.NewGasUnits(2),
+}
+
+// gasSchedules are the gas schedules introduced by each network version.
+var gasSchedules = map[upgrade.Version]*GasSchedule{
+	upgrade.Version0: &GasScheduleV0,
+	upgrade.Version1: &GasScheduleV1,
+}
+
+// GasScheduleAt returns the gas schedule in effect at block height bh: that
+// of the latest network version at bh to introduce one.
+func GasScheduleAt(bh *types.BlockHeight) *GasSchedule {
+	for v := upgrade.VersionAt(bh); ; v-- {
+		if schedule, ok := gasSchedules[v]; ok {
+			return schedule
+		}
+	}
+}
+
+// MessageCost returns the gas charged by schedule s for sending a message
+// with the given encoded parameters.
+func (s *GasSchedule) MessageCost(params []byte) types.GasUnits {
+	return s.MessageBase + s.MessageByte*types.GasUnits(len(params))
+}
+
+// StorageReadCost returns the gas charged by schedule s for reading a chunk of
+// size bytes from actor storage.
+func (s *GasSchedule) StorageReadCost(size int) types.GasUnits {
+	return s.StorageReadBase + s.StorageReadByte*types.GasUnits(size)
+}
+
+// StorageWriteCost returns the gas charged by schedule s for writing a chunk
+// of size bytes to actor storage.
+func (s *GasSchedule) StorageWriteCost(size int) types.GasUnits {
+	return s.StorageWriteBase + s.StorageWriteByte*types.GasUnits(size)
+}
+
+// EventCost returns the gas charged by schedule s for emitting an event with
+// the given topic and encoded data.
+func (s *GasSchedule) EventCost(topic string, data []byte) types.GasUnits {
+	return s.EventBase + s.EventByte*types.GasUnits(len(topic)+len(data))
+}
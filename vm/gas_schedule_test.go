@@ -0,0 +1,173 @@
+package vm
+
+import (
+	"context"
+	"testing"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmRu7tiRnFk9mMPpVECQTBQJqXtmG132jJxA1w9A7TtpBz/go-ipfs-blockstore"
+	"gx/ipfs/QmUadX5EcvrBmxAV9sE7wUWtWSqxns5K84qKJBixmcT1w9/go-datastore"
+	cbor "gx/ipfs/QmcZLyosDwMKdB6NLRsiss9HXzDPhVhhRtPy67JFKTDQDX/go-ipld-cbor"
+
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/assert"
+	"gx/ipfs/QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/upgrade"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+func TestGasScheduleAt(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	defer func(s upgrade.Schedule) { require.NoError(upgrade.SetSchedule(s)) }(upgrade.CurrentSchedule())
+	v1Height := uint64(100)
+	require.NoError(upgrade.SetSchedule(upgrade.Schedule{
+		{Height: 0, Version: upgrade.Version0},
+		{Height: v1Height, Version: upgrade.Version1},
+	}))
+
+	assert.Equal(&GasScheduleV0, GasScheduleAt(nil))
+	assert.Equal(&GasScheduleV0, GasScheduleAt(types.NewBlockHeight(0)))
+	assert.Equal(&GasScheduleV0, GasScheduleAt(types.NewBlockHeight(v1Height-1)))
+	assert.Equal(&GasScheduleV1, GasScheduleAt(types.NewBlockHeight(v1Height)))
+	assert.Equal(&GasScheduleV1, GasScheduleAt(types.NewBlockHeight(v1Height+1)))
+
+	// Versions that do not introduce a schedule keep the one before them.
+	s := append(upgrade.Schedule{}, upgrade.CurrentSchedule()...)
+	require.NoError(upgrade.SetSchedule(append(s, upgrade.Upgrade{Height: v1Height + 10, Version: upgrade.Version1 + 1})))
+	assert.Equal(&GasScheduleV1, GasScheduleAt(types.NewBlockHeight(v1Height+10)))
+}
+
+func TestGasScheduleCharges(t *testing.T) {
+	fakeActorCid := types.NewCidForTestGetter()()
+	newAddress := address.NewForTestGetter()
+	fromAddr, toAddr := newAddress(), newAddress()
+	defer func(s upgrade.Schedule) { require.NoError(t, upgrade.SetSchedule(s)) }(upgrade.CurrentSchedule())
+	require.NoError(t, upgrade.SetSchedule(upgrade.Schedule{
+		{Height: 0, Version: upgrade.Version0},
+		{Height: 100, Version: upgrade.Version1},
+		{Height: 200, Version: upgrade.Version2},
+	}))
+	v1Height := types.NewBlockHeight(100)
+	v2Height := types.NewBlockHeight(200)
+
+	setup := func(require *require.Assertions, method string, gasLimit types.GasUnits) *Context {
+		vms := NewStorageMap(blockstore.NewBlockstore(datastore.NewMapDatastore()))
+		tree := state.NewCachedStateTree(&state.MockStateTree{NoMocks: true, BuiltinActors: map[cid.Cid]exec.ExecutableActor{
+			fakeActorCid: &actor.FakeActor{},
+		}})
+
+		to := actor.NewActor(fakeActorCid, types.NewZeroAttoFIL())
+		require.NoError((&actor.FakeActor{}).InitializeState(vms.NewStorage(toAddr, to), &actor.FakeActorStorage{}))
+
+		gasTracker := NewGasTracker()
+		gasTracker.MsgGasLimit = gasLimit
+		return NewVMContext(NewContextParams{
+			From:        actor.NewActor(types.AccountActorCodeCid, types.NewZeroAttoFIL()),
+			To:          to,
+			Message:     types.NewMessage(fromAddr, toAddr, 0, nil, method, nil),
+			State:       tree,
+			StorageMap:  vms,
+			GasTracker:  gasTracker,
+			BlockHeight: v1Height,
+		})
+	}
+
+	t.Run("charges for the message and the method invocation", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		vmCtx := setup(require, "hasReturnValue", types.BlockGasLimit)
+		_, code, err := Send(context.Background(), vmCtx)
+		require.NoError(err)
+		assert.Equal(uint8(0), code)
+
+		assert.Equal(GasScheduleV1.MessageCost(nil)+GasScheduleV1.MethodInvocation, vmCtx.GasUnits())
+	})
+
+	t.Run("charges for storage read and written", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		before, err := cbor.DumpObject(&actor.FakeActorStorage{})
+		require.NoError(err)
+		after, err := cbor.DumpObject(&actor.FakeActorStorage{Changed: true})
+		require.NoError(err)
+
+		vmCtx := setup(require, "goodCall", types.BlockGasLimit)
+		_, code, err := Send(context.Background(), vmCtx)
+		require.NoError(err)
+		assert.Equal(uint8(0), code)
+
+		expected := GasScheduleV1.MessageCost(nil) + GasScheduleV1.MethodInvocation +
+			GasScheduleV1.StorageReadCost(len(before)) + GasScheduleV1.StorageWriteCost(len(after))
+		assert.Equal(expected, vmCtx.GasUnits())
+	})
+
+	t.Run("running out of gas on storage reverts the message", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		gasLimit := GasScheduleV1.MessageCost(nil) + GasScheduleV1.MethodInvocation + 1
+		vmCtx := setup(require, "goodCall", gasLimit)
+		_, code, err := Send(context.Background(), vmCtx)
+		require.Error(err)
+		assert.True(errors.ShouldRevert(err))
+		assert.Equal(uint8(exec.ErrInsufficientGas), code)
+		assert.Equal(gasLimit, vmCtx.GasUnits())
+	})
+
+	t.Run("charges for events by their size", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		data, err := cbor.DumpObject("data")
+		require.NoError(err)
+
+		vmCtx := setup(require, "goodCall", types.BlockGasLimit)
+		vmCtx.blockHeight = v2Height
+		require.NoError(vmCtx.EmitEvent("topic", "data"))
+		assert.Equal(GasScheduleV1.EventCost("topic", data), vmCtx.GasUnits())
+
+		vmCtx = setup(require, "goodCall", GasScheduleV1.EventBase)
+		vmCtx.blockHeight = v2Height
+		err = vmCtx.EmitEvent("topic", "data")
+		assert.Error(err)
+		assert.True(errors.ShouldRevert(err))
+
+		// Before events are enabled emitting one is free.
+		vmCtx = setup(require, "goodCall", types.BlockGasLimit)
+		require.NoError(vmCtx.EmitEvent("topic", "data"))
+		assert.Equal(types.GasUnits(0), vmCtx.GasUnits())
+	})
+
+	t.Run("charges for verifying signatures and proofs", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		vmCtx := setup(require, "goodCall", types.BlockGasLimit)
+		require.NoError(vmCtx.ChargeSignatureVerification())
+		require.NoError(vmCtx.ChargeSealVerification())
+		require.NoError(vmCtx.ChargePoStVerification())
+		expected := GasScheduleV1.SignatureVerification + GasScheduleV1.SealVerification + GasScheduleV1.PoStVerification
+		assert.Equal(expected, vmCtx.GasUnits())
+	})
+
+	t.Run("charges nothing but the invocation before the upgrade", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		vmCtx := setup(require, "goodCall", types.BlockGasLimit)
+		vmCtx.blockHeight = types.NewBlockHeight(0)
+		_, _, err := Send(context.Background(), vmCtx)
+		require.NoError(err)
+
+		assert.Equal(GasScheduleV0.MethodInvocation, vmCtx.GasUnits())
+	})
+}
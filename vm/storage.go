@@ -243,3 +243,32 @@ func (s Storage) liveDescendantIds(id cid.Cid) (*cid.Set, error) {
 
 	return ids, nil
 }
+
+// meteredStorage is the storage of an actor as seen by a context. It charges
+// the context for reads and writes according to its gas schedule.
+type meteredStorage struct {
+	Storage
+	ctx *Context
+}
+
+var _ exec.Storage = meteredStorage{}
+
+// Put adds a node to temporary storage by id and charges for its size.
+func (s meteredStorage) Put(v interface{}) (cid.Cid, error) {
+	c, err := s.Storage.Put(v)
+	if err != nil {
+		return c, err
+	}
+	s.ctx.chargeUnfailing(s.ctx.gasSchedule().StorageWriteCost(len(s.chunks[c].RawData())))
+	return c, nil
+}
+
+// Get retrieves a chunk from storage and charges for its size.
+func (s meteredStorage) Get(cid cid.Cid) ([]byte, error) {
+	data, err := s.Storage.Get(cid)
+	if err != nil {
+		return data, err
+	}
+	s.ctx.chargeUnfailing(s.ctx.gasSchedule().StorageReadCost(len(data)))
+	return data, nil
+}
@@ -0,0 +1,121 @@
+package vm
+
+import (
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// Trace records the execution of a message and, as a tree, of the messages
+// the actors it called sent in turn. A context records into its trace only if
+// it was given one, so tracing costs nothing when it is not asked for.
+// All methods of Trace are safe to call on nil.
+type Trace struct {
+	From   address.Address
+	To     address.Address
+	Method string
+	// Params are the decoded parameters of the call, or nil if they could not
+	// be decoded against the signature of the method.
+	Params []interface{}
+	Value  *types.AttoFIL
+
+	// Charges are the gas charges made by the called actor, in order. They
+	// do not include the charges made by the actors it sent messages to.
+	Charges []types.GasUnits
+	// GasUsed is the gas charged for the call including the messages it sent.
+	GasUsed types.GasUnits
+
+	// Return are the return values of the call, decoded when possible.
+	Return   []interface{}
+	ExitCode uint8
+	// Error is the reason the call failed, if it did.
+	Error string
+
+	// Sends are the traces of the messages the called actor sent.
+	Sends []*Trace
+
+	gasStart types.GasUnits
+}
+
+// NewTrace returns a trace for the execution of msg.
+func NewTrace(msg *types.Message) *Trace {
+	return &Trace{
+		From:   msg.From,
+		To:     msg.To,
+		Method: msg.Method,
+		Value:  msg.Value,
+	}
+}
+
+// newSend returns a trace for msg, sent by the call t traces, and adds it to
+// the sends of t.
+func (t *Trace) newSend(msg *types.Message) *Trace {
+	if t == nil {
+		return nil
+	}
+	send := NewTrace(msg)
+	t.Sends = append(t.Sends, send)
+	return send
+}
+
+// start records the beginning of the call made in vmCtx.
+func (t *Trace) start(vmCtx *Context) {
+	if t == nil {
+		return
+	}
+	if vmCtx.gasTracker != nil {
+		t.gasStart = vmCtx.gasTracker.gasConsumedByMessage
+	}
+	if sig := traceSignature(vmCtx); sig != nil && len(vmCtx.message.Params) > 0 {
+		if vals, err := abi.DecodeValues(vmCtx.message.Params, sig.Params); err == nil {
+			t.Params = abi.FromValues(vals)
+		}
+	}
+}
+
+// charge records a gas charge made by the traced call.
+func (t *Trace) charge(cost types.GasUnits) {
+	if t == nil || cost == 0 {
+		return
+	}
+	t.Charges = append(t.Charges, cost)
+}
+
+// finish records the outcome of the call made in vmCtx.
+func (t *Trace) finish(vmCtx *Context, ret [][]byte, exitCode uint8, err error) {
+	if t == nil {
+		return
+	}
+	if vmCtx.gasTracker != nil {
+		t.GasUsed = vmCtx.gasTracker.gasConsumedByMessage - t.gasStart
+	}
+	t.ExitCode = exitCode
+	if err != nil {
+		t.Error = err.Error()
+	}
+
+	sig := traceSignature(vmCtx)
+	for i, r := range ret {
+		if sig != nil && i < len(sig.Return) {
+			if v, err := abi.Deserialize(r, sig.Return[i]); err == nil {
+				t.Return = append(t.Return, v.Val)
+				continue
+			}
+		}
+		t.Return = append(t.Return, r)
+	}
+}
+
+// traceSignature returns the signature of the method called in vmCtx, or nil
+// if the called actor does not export it.
+func traceSignature(vmCtx *Context) *exec.FunctionSignature {
+	if vmCtx.message.Method == "" || vmCtx.to == nil || vmCtx.state == nil {
+		return nil
+	}
+	code, err := vmCtx.state.GetBuiltinActorCode(vmCtx.to.Code)
+	if err != nil {
+		return nil
+	}
+	return code.Exports()[vmCtx.message.Method]
+}
@@ -8,6 +8,7 @@ import (
 	cbor "gx/ipfs/QmcZLyosDwMKdB6NLRsiss9HXzDPhVhhRtPy67JFKTDQDX/go-ipld-cbor"
 
 	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/types"
 	"github.com/filecoin-project/go-filecoin/vm/errors"
 )
@@ -19,7 +20,10 @@ func Send(ctx context.Context, vmCtx *Context) ([][]byte, uint8, error) {
 		transfer: Transfer,
 	}
 
-	return send(ctx, deps, vmCtx)
+	vmCtx.trace.start(vmCtx)
+	ret, exitCode, err := send(ctx, deps, vmCtx)
+	vmCtx.trace.finish(vmCtx, ret, exitCode, err)
+	return ret, exitCode, err
 }
 
 type sendDeps struct {
@@ -28,6 +32,11 @@ type sendDeps struct {
 
 // send executes a message pass inside the VM. It exists alongside Send so that we can inject its dependencies during test.
 func send(ctx context.Context, deps sendDeps, vmCtx *Context) ([][]byte, uint8, error) {
+	schedule := vmCtx.gasSchedule()
+	if err := vmCtx.Charge(schedule.MessageCost(vmCtx.message.Params)); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
 	if vmCtx.message.Value != nil {
 		if err := deps.transfer(vmCtx.from, vmCtx.to, vmCtx.message.Value); err != nil {
 			if errors.ShouldRevert(err) {
@@ -52,7 +61,16 @@ func send(ctx context.Context, deps sendDeps, vmCtx *Context) ([][]byte, uint8,
 		return nil, 1, errors.Errors[errors.ErrMissingExport]
 	}
 
+	if err := vmCtx.Charge(schedule.MethodInvocation); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
 	r, code, err := actor.MakeTypedExport(toExecutable, vmCtx.message.Method)(vmCtx)
+	if err == nil && vmCtx.gasTracker.OutOfGas() {
+		// A charge the method could not fail on, such as for storage, ran
+		// out of gas.
+		return nil, exec.ErrInsufficientGas, errors.NewRevertError("Insufficient gas: gas cost exceeds gas limit")
+	}
 	if r != nil {
 		var rv [][]byte
 		err = cbor.DecodeInto(r, &rv)